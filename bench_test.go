@@ -60,14 +60,6 @@ func BenchmarkUnificationHash(b *testing.B) {
 func BenchmarkMaplist(b *testing.B) {
 	m := NewMachine().Consult(`
         always_a(_, a).
-
-        maplist(C, A, B) :-
-            maplist_(A, B, C).
-
-        maplist_([], [], _).
-        maplist_([B|D], [C|E], A) :-
-            call(A, B, C),
-            maplist_(D, E, A).
     `)
 	g := read.Term_(`maplist(always_a, [1,2,3,4,5], As).`)
 
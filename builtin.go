@@ -4,11 +4,19 @@ package golog
 // are defined here.
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"math"
 	"math/big"
 	"sort"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/mndrix/golog/lex"
+	"github.com/mndrix/golog/read"
 	"github.com/mndrix/golog/term"
 )
 import . "github.com/mndrix/golog/util"
@@ -31,6 +39,15 @@ func BuiltinCutTo(m Machine, args []term.Term) ForeignReturn {
 	return m.CutTo(barrierId)
 }
 
+// $catch_exit/1
+//
+// An internal system predicate which might be removed at any time
+// in the future.  It marks the end of a catch/3 goal.
+func BuiltinCatchExit(m Machine, args []term.Term) ForeignReturn {
+	id := args[0].(*term.Integer).Value().Int64()
+	return m.(*machine).exitCatch(id)
+}
+
 // ,/2
 func BuiltinComma(m Machine, args []term.Term) ForeignReturn {
 	return m.PushConj(args[1].(term.Callable)).PushConj(args[0].(term.Callable))
@@ -42,7 +59,9 @@ func BuiltinGround(m Machine, args []term.Term) ForeignReturn {
 	case term.VariableType:
 		return ForeignFail()
 	case term.AtomType,
+		term.StringType,
 		term.IntegerType,
+		term.RationalType,
 		term.FloatType,
 		term.ErrorType:
 		return ForeignTrue()
@@ -57,6 +76,12 @@ func BuiltinGround(m Machine, args []term.Term) ForeignReturn {
 			}
 		}
 		return ForeignTrue()
+	case term.DictType:
+		x := args[0].(*term.Dict)
+		if len(term.TermVariables(x)) > 0 {
+			return ForeignFail()
+		}
+		return ForeignTrue()
 	}
 	msg := fmt.Sprintf("Unexpected term type: %#v", args[0])
 	panic(msg)
@@ -66,7 +91,8 @@ func BuiltinGround(m Machine, args []term.Term) ForeignReturn {
 func BuiltinIs(m Machine, args []term.Term) ForeignReturn {
 	value := args[0]
 	expression := args[1]
-	num, err := term.ArithmeticEval(expression)
+	flags := m.(*machine).arithmeticFlags()
+	num, err := term.ArithmeticEvalWithFlags(expression, flags)
 	MaybePanic(err)
 	return ForeignUnify(value, num)
 }
@@ -115,21 +141,555 @@ func BuiltinUnify(m Machine, args []term.Term) ForeignReturn {
 	return ForeignUnify(args[0], args[1])
 }
 
-// =:=/2
-func BuiltinNumericEquals(m Machine, args []term.Term) ForeignReturn {
+// compare the values of two arithmetic expressions.  The comparison
+// succeeds if ok returns true for the result of NumberCmp.
+func numericComparison(m Machine, args []term.Term, ok func(int) bool) ForeignReturn {
 	// evaluate each arithmetic argument
-	a, err := term.ArithmeticEval(args[0])
+	flags := m.(*machine).arithmeticFlags()
+	a, err := term.ArithmeticEvalWithFlags(args[0], flags)
 	MaybePanic(err)
-	b, err := term.ArithmeticEval(args[1])
+	b, err := term.ArithmeticEvalWithFlags(args[1], flags)
 	MaybePanic(err)
 
+	// NaN is unordered, so only =\= can succeed
+	if term.IsNaN(a) || term.IsNaN(b) {
+		if ok(-1) && ok(1) && !ok(0) {
+			return ForeignTrue()
+		}
+		return ForeignFail()
+	}
+
 	// perform the actual comparison
-	if term.NumberCmp(a, b) == 0 {
+	if ok(term.NumberCmp(a, b)) {
+		return ForeignTrue()
+	}
+	return ForeignFail()
+}
+
+// =:=/2
+func BuiltinNumericEquals(m Machine, args []term.Term) ForeignReturn {
+	return numericComparison(m, args, func(c int) bool { return c == 0 })
+}
+
+// =\=/2
+func BuiltinNumericNotEquals(m Machine, args []term.Term) ForeignReturn {
+	return numericComparison(m, args, func(c int) bool { return c != 0 })
+}
+
+// </2
+func BuiltinNumericLess(m Machine, args []term.Term) ForeignReturn {
+	return numericComparison(m, args, func(c int) bool { return c < 0 })
+}
+
+// =</2
+func BuiltinNumericLessEquals(m Machine, args []term.Term) ForeignReturn {
+	return numericComparison(m, args, func(c int) bool { return c <= 0 })
+}
+
+// >/2
+func BuiltinNumericGreater(m Machine, args []term.Term) ForeignReturn {
+	return numericComparison(m, args, func(c int) bool { return c > 0 })
+}
+
+// >=/2
+func BuiltinNumericGreaterEquals(m Machine, args []term.Term) ForeignReturn {
+	return numericComparison(m, args, func(c int) bool { return c >= 0 })
+}
+
+// rational(@X) is semidet.
+//
+// True if X is an integer or a rational number.
+func BuiltinRational1(m Machine, args []term.Term) ForeignReturn {
+	if term.IsInteger(args[0]) || term.IsRational(args[0]) {
 		return ForeignTrue()
 	}
 	return ForeignFail()
 }
 
+// rational(@X, -Numerator, -Denominator) is semidet.
+//
+// True if X is a rational number with the given numerator and
+// denominator.  An integer's denominator is 1.
+func BuiltinRational3(m Machine, args []term.Term) ForeignReturn {
+	var r *big.Rat
+	switch x := args[0]; {
+	case term.IsInteger(x):
+		r = new(big.Rat).SetInt(x.(*term.Integer).Value())
+	case term.IsRational(x):
+		r = x.(*term.Rational).Value()
+	default:
+		return ForeignFail()
+	}
+	n := term.NewBigInt(new(big.Int).Set(r.Num()))
+	d := term.NewBigInt(new(big.Int).Set(r.Denom()))
+	return ForeignUnify(args[1], n, args[2], d)
+}
+
+// roundingArgs checks the arguments common to round_rational/4 and
+// format_rational/4
+func roundingArgs(args []term.Term) (*big.Rat, int, term.RoundingMode) {
+	x, places, mode := args[0], args[1], args[2]
+	if term.IsVariable(x) || term.IsVariable(places) || term.IsVariable(mode) {
+		panic(term.InstantiationError())
+	}
+
+	if !term.IsNumber(x) {
+		panic(term.TypeError("number", x))
+	}
+	n, err := term.ArithmeticRational(x.(term.Number))
+	MaybePanic(err)
+	r, _ := n.LosslessRat()
+
+	if !term.IsInteger(places) {
+		panic(term.TypeError("integer", places))
+	}
+	p := places.(*term.Integer).Value()
+	if p.Sign() < 0 {
+		panic(term.DomainError("not_less_than_zero", places))
+	}
+	if !p.IsInt64() {
+		panic(term.RepresentationError("max_integer"))
+	}
+
+	if !term.IsAtom(mode) {
+		panic(term.TypeError("atom", mode))
+	}
+	rm, ok := term.NewRoundingMode(mode.(*term.Atom).Name())
+	if !ok {
+		panic(term.DomainError("rounding_mode", mode))
+	}
+	return r, int(p.Int64()), rm
+}
+
+// round_rational(+X, +Places, +Mode, -Rounded) is det.
+//
+// Rounds X to Places decimal places producing an exact result.  Mode is
+// one of half_up, half_even, half_down, up, down, ceiling or floor.
+func BuiltinRoundRational4(m Machine, args []term.Term) ForeignReturn {
+	r, places, mode := roundingArgs(args)
+	rounded := term.RoundRational(r, places, mode)
+	if rounded.IsInt() {
+		return ForeignUnify(args[3], term.NewBigInt(rounded.Num()))
+	}
+	return ForeignUnify(args[3], term.NewBigRat(rounded))
+}
+
+// format_rational(+X, +Places, +Mode, -Atom) is det.
+//
+// Like round_rational/4 but Atom is the rounded value written in
+// decimal notation with exactly Places digits after the decimal point.
+// Useful for things like currency.
+func BuiltinFormatRational4(m Machine, args []term.Term) ForeignReturn {
+	r, places, mode := roundingArgs(args)
+	text := term.FormatDecimal(r, places, mode)
+	return ForeignUnify(args[3], term.NewAtom(text))
+}
+
+// set_prolog_flag(+Flag, +Value) see ISO §8.17.1
+//
+// Changes a flag's value for the remainder of the current proof.  Like
+// nb_setval/2, the change survives backtracking.
+func BuiltinSetPrologFlag2(m Machine, args []term.Term) ForeignReturn {
+	flag, value := args[0], args[1]
+	if term.IsVariable(flag) || term.IsVariable(value) {
+		panic(term.InstantiationError())
+	}
+	if !term.IsAtom(flag) {
+		panic(term.TypeError("atom", flag))
+	}
+	m1, err := m.SetPrologFlag(flag.(*term.Atom).Name(), value)
+	MaybePanic(err)
+	return m1
+}
+
+// dynamic(+PredicateIndicators) see ISO §7.4.2.1
+//
+// Declares each predicate dynamic.  PredicateIndicators is a single
+// Name/Arity term, a conjunction of them or a list of them.  Calling a
+// dynamic predicate which has no clauses fails instead of raising an
+// existence error.
+func BuiltinDynamic1(m Machine, args []term.Term) ForeignReturn {
+	m1 := m.(*machine)
+	var declare func(term.Term)
+	declare = func(spec term.Term) {
+		switch {
+		case term.IsVariable(spec):
+			panic(term.InstantiationError())
+		case term.IsCompound(spec) && spec.Indicator() == ",/2":
+			declare(spec.(*term.Compound).Arguments()[0])
+			declare(spec.(*term.Compound).Arguments()[1])
+		case term.IsList(spec):
+			for _, pi := range term.ProperListToTermSlice(spec) {
+				declare(pi)
+			}
+		default:
+			name, arity := mustPredicateIndicator(spec)
+			m1 = m1.dynamic(name, arity)
+		}
+	}
+	declare(args[0])
+	return m1
+}
+
+// clause(+Head, ?Body) see ISO §8.8.1
+//
+// True if Head :- Body is a clause in the database.  A fact's body is
+// true.  Foreign predicates have no clauses to inspect.
+func BuiltinClause2(m Machine, args []term.Term) ForeignReturn {
+	head, body := args[0], args[1]
+	if term.IsVariable(head) {
+		panic(term.InstantiationError())
+	}
+	if !term.IsCallable(head) {
+		panic(term.TypeError("callable", head))
+	}
+	if !term.IsVariable(body) && !term.IsCallable(body) {
+		panic(term.TypeError("callable", body))
+	}
+	h := head.(term.Callable)
+	m1 := m.(*machine)
+	if _, ok := m1.lookupForeign(h); ok {
+		pi := predicateIndicator(h.Name(), h.Arity())
+		panic(term.PermissionError("access", "private_procedure", pi))
+	}
+
+	clauses, err := m1.db.Candidates(h)
+	if err != nil {
+		return ForeignFail()
+	}
+	alternatives := make([][]term.Term, 0, len(clauses))
+	for _, clause := range clauses {
+		clause = term.RenameVariables(clause)
+		var b term.Term = term.NewAtom("true")
+		if term.IsClause(clause) {
+			clause, b = term.Head(clause), term.Body(clause)
+		}
+		alternatives = append(alternatives, []term.Term{head, clause, body, b})
+	}
+	return unifyAlternatives(m, alternatives)
+}
+
+// current_op(?Priority, ?Specifier, ?Operator) see ISO §8.14.4
+//
+// True if Operator is an operator with Priority and Specifier in the
+// machine's operator table.
+func BuiltinCurrentOp3(m Machine, args []term.Term) ForeignReturn {
+	priority, spec, op := args[0], args[1], args[2]
+	if !term.IsVariable(priority) {
+		if !term.IsInteger(priority) {
+			panic(term.DomainError("operator_priority", priority))
+		}
+		p := priority.(*term.Integer).Value()
+		if p.Sign() < 0 || p.Cmp(big.NewInt(1200)) > 0 {
+			panic(term.DomainError("operator_priority", priority))
+		}
+	}
+	if !term.IsVariable(spec) {
+		if !term.IsAtom(spec) || !isOperatorSpecifier(spec.(*term.Atom).Name()) {
+			panic(term.DomainError("operator_specifier", spec))
+		}
+	}
+	if !term.IsVariable(op) && !term.IsAtom(op) {
+		panic(term.TypeError("atom", op))
+	}
+
+	var alternatives [][]term.Term
+	for _, o := range m.(*machine).operators() {
+		if term.IsAtom(op) && op.(*term.Atom).Name() != o.Name {
+			continue
+		}
+		alternatives = append(alternatives, []term.Term{
+			priority, term.NewInt64(int64(o.Priority)),
+			spec, term.NewAtom(o.Specifier),
+			op, term.NewAtom(o.Name),
+		})
+	}
+	return unifyAlternatives(m, alternatives)
+}
+
+// isOperatorSpecifier returns true if name is an operator specifier
+// like xfx or fy
+func isOperatorSpecifier(name string) bool {
+	switch name {
+	case "xf", "yf", "xfx", "xfy", "yfx", "fx", "fy":
+		return true
+	}
+	return false
+}
+
+// op(+Priority, +Specifier, +Operators) see ISO §8.14.3
+//
+// Adds each of Operators, an atom or a list of atoms, to the machine's
+// operator table with Priority and Specifier.  A Priority of 0 removes
+// the operator.  The table is used by consult, read_term/2 and the term
+// writer.  Like Prolog flags, changes survive backtracking.
+func BuiltinOp3(m Machine, args []term.Term) ForeignReturn {
+	priority, spec, ops := args[0], args[1], args[2]
+	if term.IsVariable(priority) || term.IsVariable(spec) || term.IsVariable(ops) {
+		panic(term.InstantiationError())
+	}
+	if !term.IsInteger(priority) {
+		panic(term.TypeError("integer", priority))
+	}
+	p := priority.(*term.Integer).Value()
+	if p.Sign() < 0 || p.Cmp(big.NewInt(1200)) > 0 {
+		panic(term.DomainError("operator_priority", priority))
+	}
+	if !term.IsAtom(spec) {
+		panic(term.TypeError("atom", spec))
+	}
+	specifier := spec.(*term.Atom).Name()
+	if !isOperatorSpecifier(specifier) {
+		panic(term.DomainError("operator_specifier", spec))
+	}
+
+	var names []term.Term
+	switch {
+	case term.IsList(ops):
+		names = mustProperList(ops)
+	case term.IsAtom(ops):
+		names = []term.Term{ops}
+	default:
+		panic(term.TypeError("list", ops))
+	}
+
+	m1 := m.(*machine).clone()
+	m1.ops = m1.ops.Copy()
+	for _, name := range names {
+		if term.IsVariable(name) {
+			panic(term.InstantiationError())
+		}
+		if !term.IsAtom(name) {
+			panic(term.TypeError("atom", name))
+		}
+		mustCreateOperator(m1, int(p.Int64()), specifier, name.(*term.Atom))
+		err := m1.ops.Add(int(p.Int64()), specifier, name.(*term.Atom).Name())
+		MaybePanic(err)
+	}
+	return m1
+}
+
+// mustCreateOperator raises a permission error if op/3 may not define
+// an operator with this priority and specifier.  ISO doesn't allow an
+// operator to be both infix and postfix.
+func mustCreateOperator(m *machine, p int, specifier string, name *term.Atom) {
+	switch name.Name() {
+	case ",":
+		panic(term.PermissionError("modify", "operator", name))
+	case "{}":
+		panic(term.PermissionError("create", "operator", name))
+	case "|":
+		if p > 0 && (len(specifier) != 3 || p < 1001) {
+			panic(term.PermissionError("create", "operator", name))
+		}
+	}
+	if p == 0 {
+		return
+	}
+	for _, o := range m.operators() {
+		if o.Name == name.Name() && isInfix(o.Specifier) != isInfix(specifier) &&
+			!isPrefix(o.Specifier) && !isPrefix(specifier) {
+			panic(term.PermissionError("create", "operator", name))
+		}
+	}
+}
+
+// isInfix returns true for infix operator specifiers like xfy
+func isInfix(specifier string) bool {
+	return len(specifier) == 3
+}
+
+// isPrefix returns true for prefix operator specifiers like fy
+func isPrefix(specifier string) bool {
+	return specifier[0] == 'f'
+}
+
+// current_predicate(?PredicateIndicator) see ISO §8.8.2
+//
+// True if PredicateIndicator, like foo/2, names a defined predicate.
+// This includes predicates defined by clauses and those implemented in
+// Go.  Dynamic predicates without clauses are defined too.
+func BuiltinCurrentPredicate1(m Machine, args []term.Term) ForeignReturn {
+	pi := args[0]
+	var name term.Term = term.NewVar("_")
+	if !term.IsVariable(pi) {
+		if !term.IsCompound(pi) || pi.Indicator() != "//2" {
+			panic(term.TypeError("predicate_indicator", pi))
+		}
+		var arity term.Term
+		name, arity = pi.(*term.Compound).Arguments()[0], pi.(*term.Compound).Arguments()[1]
+		if !term.IsVariable(name) && !term.IsAtom(name) {
+			panic(term.TypeError("predicate_indicator", pi))
+		}
+		if !term.IsVariable(arity) && !term.IsInteger(arity) {
+			panic(term.TypeError("predicate_indicator", pi))
+		}
+	}
+
+	var alternatives [][]term.Term
+	for _, indicator := range m.(*machine).predicates() {
+		n, arity := splitIndicator(indicator)
+		if isInternal(n) || (term.IsAtom(name) && name.(*term.Atom).Name() != n) {
+			continue
+		}
+		alternatives = append(alternatives, []term.Term{pi, predicateIndicator(n, arity)})
+	}
+	return unifyAlternatives(m, alternatives)
+}
+
+// current_predicate(?Name, ?Head) is nondet.
+//
+// True if Head is the most general term for a defined predicate
+// called Name.
+func BuiltinCurrentPredicate2(m Machine, args []term.Term) ForeignReturn {
+	name, head := args[0], args[1]
+	if !term.IsVariable(name) && !term.IsAtom(name) {
+		panic(term.TypeError("atom", name))
+	}
+	if !term.IsVariable(head) {
+		if !term.IsCallable(head) {
+			panic(term.TypeError("callable", head))
+		}
+		h := head.(term.Callable)
+		if !m.(*machine).isDefined(h) {
+			return ForeignFail()
+		}
+		return ForeignUnify(name, term.NewAtom(h.Name()))
+	}
+
+	var alternatives [][]term.Term
+	for _, indicator := range m.(*machine).predicates() {
+		n, arity := splitIndicator(indicator)
+		if isInternal(n) || (term.IsAtom(name) && name.(*term.Atom).Name() != n) {
+			continue
+		}
+		alternatives = append(alternatives, []term.Term{
+			name, term.NewAtom(n),
+			head, predicateHead(n, arity),
+		})
+	}
+	return unifyAlternatives(m, alternatives)
+}
+
+// predicate_property(?Head, ?Property) is nondet.
+//
+// True if Head's predicate has Property.  Properties are defined,
+// dynamic, foreign, built_in, number_of_clauses(N), file(F) and
+// line_count(L).  A built_in predicate is implemented in Go and can't
+// be redefined.  file(F) and line_count(L) give the location of a
+// consulted predicate's first clause.
+func BuiltinPredicateProperty2(m Machine, args []term.Term) ForeignReturn {
+	head, prop := args[0], args[1]
+	m1 := m.(*machine)
+	if !term.IsVariable(head) {
+		if !term.IsCallable(head) {
+			panic(term.TypeError("callable", head))
+		}
+		h := head.(term.Callable)
+		if !m1.isDefined(h) {
+			return ForeignFail()
+		}
+		var alternatives [][]term.Term
+		for _, p := range m1.predicateProperties(h) {
+			alternatives = append(alternatives, []term.Term{prop, p})
+		}
+		return unifyAlternatives(m, alternatives)
+	}
+
+	var alternatives [][]term.Term
+	for _, indicator := range m1.predicates() {
+		n, arity := splitIndicator(indicator)
+		if isInternal(n) {
+			continue
+		}
+		h := predicateHead(n, arity)
+		for _, p := range m1.predicateProperties(h) {
+			alternatives = append(alternatives, []term.Term{head, h, prop, p})
+		}
+	}
+	return unifyAlternatives(m, alternatives)
+}
+
+// isInternal returns true if a predicate name is reserved for Golog's
+// own use.  Enumerating predicates skips these.
+func isInternal(name string) bool {
+	return strings.HasPrefix(name, "$")
+}
+
+// current_prolog_flag(?Flag, ?Value) see ISO §8.17.2
+//
+// True if Flag currently has Value.
+func BuiltinCurrentPrologFlag2(m Machine, args []term.Term) ForeignReturn {
+	flag, value := args[0], args[1]
+	if term.IsAtom(flag) {
+		v, ok := m.PrologFlag(flag.(*term.Atom).Name())
+		if !ok {
+			panic(term.DomainError("prolog_flag", flag))
+		}
+		return ForeignUnify(value, v)
+	}
+	if !term.IsVariable(flag) {
+		panic(term.TypeError("atom", flag))
+	}
+
+	// enumerate all flags
+	var alternatives [][]term.Term
+	for _, name := range flagNames() {
+		v, _ := m.PrologFlag(name)
+		alternatives = append(alternatives, []term.Term{flag, term.NewAtom(name), value, v})
+	}
+	return unifyAlternatives(m, alternatives)
+}
+
+// unifyAlternatives returns a machine which nondeterministically performs
+// each alternative unification, in order.  Each alternative is a list of
+// terms like the arguments to ForeignUnify.
+func unifyAlternatives(m Machine, alternatives [][]term.Term) ForeignReturn {
+	switch len(alternatives) {
+	case 0:
+		return ForeignFail()
+	case 1:
+		return ForeignUnify(alternatives[0]...)
+	}
+	return unifyEach(m, func(i int) ([]term.Term, int, bool) {
+		if i >= len(alternatives) {
+			return nil, 0, false
+		}
+		return alternatives[i], i + 1, true
+	})
+}
+
+// unifyEach is like unifyAlternatives but computes each alternative
+// only when backtracking reaches it.  next(i) returns the first
+// alternative at or after position i and the position which follows
+// it, or false if there are none.
+func unifyEach(m Machine, next func(int) ([]term.Term, int, bool)) ForeignReturn {
+	m1, err := newUnifyChoicePoint(m, next, 0).Follow()
+	if err == term.CantUnify {
+		return ForeignFail()
+	}
+	return m1
+}
+
+// alternativesGoal builds a disjunction which performs each alternative
+// unification, in order.  Returns nil if there are no alternatives.
+func alternativesGoal(alternatives [][]term.Term) term.Callable {
+	var goal term.Callable
+	for i := len(alternatives) - 1; i >= 0; i-- {
+		ts := alternatives[i]
+		conj := term.NewCallable("=", ts[len(ts)-2], ts[len(ts)-1])
+		for j := len(ts) - 4; j >= 0; j -= 2 {
+			conj = term.NewCallable(",", term.NewCallable("=", ts[j], ts[j+1]), conj)
+		}
+		if goal == nil {
+			goal = conj
+		} else {
+			goal = term.NewCallable(";", conj, goal)
+		}
+	}
+	return goal
+}
+
 // ==/2
 func BuiltinTermEquals(m Machine, args []term.Term) ForeignReturn {
 	a := args[0]
@@ -150,11 +710,32 @@ func BuiltinTermNotEquals(m Machine, args []term.Term) ForeignReturn {
 	return ForeignTrue()
 }
 
+// =@=(@A, @B) is semidet.
+//
+// True if A and B are variants of each other.  That's the case if
+// they're identical after consistently renaming their variables.
+func BuiltinVariant2(m Machine, args []term.Term) ForeignReturn {
+	if term.IsVariant(args[0], args[1]) {
+		return ForeignTrue()
+	}
+	return ForeignFail()
+}
+
+// \=@=(@A, @B) is semidet.
+//
+// True if A and B aren't variants of each other.
+func BuiltinNotVariant2(m Machine, args []term.Term) ForeignReturn {
+	if term.IsVariant(args[0], args[1]) {
+		return ForeignFail()
+	}
+	return ForeignTrue()
+}
+
 // @</2
 func BuiltinTermLess(m Machine, args []term.Term) ForeignReturn {
 	a := args[0]
 	b := args[1]
-	if term.Precedes(a, b) {
+	if precedes(m, a, b) {
 		return ForeignTrue()
 	}
 	return ForeignFail()
@@ -164,7 +745,7 @@ func BuiltinTermLess(m Machine, args []term.Term) ForeignReturn {
 func BuiltinTermLessEquals(m Machine, args []term.Term) ForeignReturn {
 	a := args[0]
 	b := args[1]
-	if term.Precedes(a, b) {
+	if precedes(m, a, b) {
 		return ForeignTrue()
 	}
 	return BuiltinTermEquals(m, args)
@@ -174,7 +755,7 @@ func BuiltinTermLessEquals(m Machine, args []term.Term) ForeignReturn {
 func BuiltinTermGreater(m Machine, args []term.Term) ForeignReturn {
 	a := args[0]
 	b := args[1]
-	if term.Precedes(b, a) {
+	if precedes(m, b, a) {
 		return ForeignTrue()
 	}
 	return ForeignFail()
@@ -184,223 +765,3006 @@ func BuiltinTermGreater(m Machine, args []term.Term) ForeignReturn {
 func BuiltinTermGreaterEquals(m Machine, args []term.Term) ForeignReturn {
 	a := args[0]
 	b := args[1]
-	if term.Precedes(b, a) {
+	if precedes(m, b, a) {
 		return ForeignTrue()
 	}
 	return BuiltinTermEquals(m, args)
 }
 
+// precedes returns true if a precedes b in the machine's standard order
+func precedes(m Machine, a, b term.Term) bool {
+	return m.(*machine).standardOrder().Compare(a, b) < 0
+}
+
 // (\+)/1
 func BuiltinNot(m Machine, args []term.Term) ForeignReturn {
 	var answer term.Bindings
 	var err error
-	m = m.ClearConjs().ClearDisjs().PushConj(args[0].(term.Callable))
+	sub := m.ClearConjs().ClearDisjs().PushConj(args[0].(term.Callable))
 
 	for {
-		m, answer, err = m.Step()
+		sub, answer, err = sub.Step()
 		if err == MachineDone {
-			return ForeignTrue()
+			return continueOn(m, sub, ForeignTrue())
 		}
 		MaybePanic(err)
 		if answer != nil {
-			return ForeignFail()
+			return continueOn(m, sub, ForeignFail())
 		}
 	}
 }
 
-// atom_codes/2 see ISO §8.16.5
+// atom_codes(?Atom, ?Codes) see ISO §8.16.5
+//
+// Codes is a list of the character codes of Atom.
 func BuiltinAtomCodes2(m Machine, args []term.Term) ForeignReturn {
-
-	if !term.IsVariable(args[0]) {
-		atom := args[0].(*term.Atom)
-		list := term.NewCodeList(atom.Name())
-		return ForeignUnify(args[1], list)
-	} else if !term.IsVariable(args[1]) {
-		runes := make([]rune, 0)
-		list := args[1].(term.Callable)
-		for {
-			switch list.Arity() {
-			case 2:
-				if list.Name() == "." {
-					args := list.Arguments()
-					code := args[0].(*term.Integer)
-					runes = append(runes, code.Code())
-					list = args[1].(term.Callable)
-				}
-			case 0:
-				if list.Name() == "[]" {
-					atom := term.NewAtom(string(runes))
-					return ForeignUnify(args[0], atom)
-				}
-			default:
-				msg := fmt.Sprintf("unexpected code list %s", args[1])
-				panic(msg)
-			}
-		}
+	atom, list := args[0], args[1]
+	if !term.IsVariable(atom) {
+		text := mustAtom(atom)
+		return ForeignUnify(list, term.NewCodeList(text))
 	}
-
-	msg := fmt.Sprintf("atom_codes/2: error with the arguments %s and %s", args[0], args[1])
-	panic(msg)
+	return ForeignUnify(atom, term.NewAtom(codesText(list)))
 }
 
-// atom_number/2 as defined in SWI-Prolog
-func BuiltinAtomNumber2(m Machine, args []term.Term) (ret ForeignReturn) {
-	number := args[1]
+// atom_chars(?Atom, ?Chars) see ISO §8.16.4
+//
+// Chars is a list of the characters (one character atoms) of Atom.
+func BuiltinAtomChars2(m Machine, args []term.Term) ForeignReturn {
+	atom, list := args[0], args[1]
+	if !term.IsVariable(atom) {
+		text := mustAtom(atom)
+		return ForeignUnify(list, newCharList(text))
+	}
+	return ForeignUnify(atom, term.NewAtom(charsText(list)))
+}
 
-	if !term.IsVariable(args[0]) {
-		atom := args[0].(term.Callable)
-		defer func() { // convert parsing panics into fail
-			if x := recover(); x != nil {
-				ret = ForeignFail()
-			}
-		}()
-		if strings.Contains(atom.Name(), ".") {
-			number = term.NewFloat(atom.Name())
-		} else {
-			number = term.NewInt(atom.Name())
+// atom_length(+Atom, ?Length) see ISO §8.16.1
+//
+// Length is the number of characters in Atom.
+func BuiltinAtomLength2(m Machine, args []term.Term) ForeignReturn {
+	text := mustAtom(args[0])
+	length := args[1]
+	if !term.IsVariable(length) {
+		if !term.IsInteger(length) {
+			panic(term.TypeError("integer", length))
+		}
+		if length.(*term.Integer).Value().Sign() < 0 {
+			panic(term.DomainError("not_less_than_zero", length))
 		}
-		return ForeignUnify(args[1], number)
-	} else if !term.IsVariable(number) {
-		atom := term.NewAtom(number.String())
-		return ForeignUnify(args[0], atom)
 	}
-
-	msg := fmt.Sprintf("atom_number/2: Arguments are not sufficiently instantiated: %s and %s", args[0], args[1])
-	panic(msg)
+	n := term.NewInt64(int64(utf8.RuneCountInString(text)))
+	return ForeignUnify(length, n)
 }
 
-// call/*
-func BuiltinCall(m Machine, args []term.Term) ForeignReturn {
-
-	// build a new goal with extra arguments attached
-	bodyTerm := args[0].(term.Callable)
-	functor := bodyTerm.Name()
-	newArgs := make([]term.Term, 0)
-	newArgs = append(newArgs, bodyTerm.Arguments()...)
-	newArgs = append(newArgs, args[1:]...)
-	goal := term.NewCallable(functor, newArgs...)
-
-	// construct a machine that will prove this goal next
-	return m.DemandCutBarrier().PushConj(goal)
+// atom_number(?Atom, ?Number) is semidet.
+//
+// True if Atom is the text of Number.  Fails if Atom doesn't
+// read as a number.
+func BuiltinAtomNumber2(m Machine, args []term.Term) ForeignReturn {
+	atom, number := args[0], args[1]
+	if !term.IsVariable(atom) {
+		n, ok := parseNumber(mustAtom(atom))
+		if !ok {
+			return ForeignFail()
+		}
+		return ForeignUnify(number, n)
+	}
+	if term.IsVariable(number) {
+		panic(term.InstantiationError())
+	}
+	if !term.IsNumber(number) {
+		panic(term.TypeError("number", number))
+	}
+	return ForeignUnify(atom, term.NewAtom(number.String()))
 }
 
-// downcase_atom(+AnyCase, -LowerCase)
+// char_code(?Char, ?Code) see ISO §8.16.6
 //
-// Converts the characters of AnyCase into lowercase and unifies the
-// lowercase atom with LowerCase.
-func BuiltinDowncaseAtom2(m Machine, args []term.Term) ForeignReturn {
-	if term.IsVariable(args[0]) {
-		panic("downcase_atom/2: instantiation_error")
+// Code is the character code of the one character atom Char.
+func BuiltinCharCode2(m Machine, args []term.Term) ForeignReturn {
+	char, code := args[0], args[1]
+	if !term.IsVariable(char) {
+		c := mustCharacter(char)
+		return ForeignUnify(code, term.NewCode(c))
 	}
-	anycase := args[0].(term.Callable)
-	if anycase.Arity() != 0 {
-		msg := fmt.Sprintf("downcase_atom/2: type_error(atom, %s)", anycase)
-		panic(msg)
+	if term.IsVariable(code) {
+		panic(term.InstantiationError())
 	}
+	c := mustCharacterCode(code)
+	return ForeignUnify(char, term.NewAtom(string(c)))
+}
 
-	lowercase := term.NewAtom(strings.ToLower(anycase.Name()))
-	return ForeignUnify(args[1], lowercase)
+// number_codes(?Number, ?Codes) see ISO §8.16.8
+//
+// Codes is a list of the character codes which represent Number.  If
+// Codes is ground, it's parsed as a number.
+func BuiltinNumberCodes2(m Machine, args []term.Term) ForeignReturn {
+	number, list := args[0], args[1]
+	if !term.IsVariable(number) && !term.IsNumber(number) {
+		panic(term.TypeError("number", number))
+	}
+	if isGroundList(list) || term.IsVariable(number) {
+		return ForeignUnify(number, mustParseNumber(codesText(list)))
+	}
+	mustPartialList(list)
+	return ForeignUnify(list, term.NewCodeList(number.String()))
 }
 
-// fail/0
-func BuiltinFail(m Machine, args []term.Term) ForeignReturn {
-	return ForeignFail()
+// number_chars(?Number, ?Chars) see ISO §8.16.7
+//
+// Like number_codes/2 but with a list of characters.
+func BuiltinNumberChars2(m Machine, args []term.Term) ForeignReturn {
+	number, list := args[0], args[1]
+	if !term.IsVariable(number) && !term.IsNumber(number) {
+		panic(term.TypeError("number", number))
+	}
+	if isGroundList(list) || term.IsVariable(number) {
+		return ForeignUnify(number, mustParseNumber(charsText(list)))
+	}
+	mustPartialList(list)
+	return ForeignUnify(list, newCharList(number.String()))
 }
 
-// findall/3
-func BuiltinFindall3(m Machine, args []term.Term) ForeignReturn {
-	template := args[0]
-	goal := args[1]
+// atom_concat(?Atom1, ?Atom2, ?Atom3) see ISO §8.16.2
+//
+// Atom3 is the concatenation of Atom1 and Atom2.  If Atom3 is bound,
+// enumerates each way of splitting it on backtracking.
+func BuiltinAtomConcat3(m Machine, args []term.Term) ForeignReturn {
+	return concatText(m, args, mustAtom, newAtom)
+}
 
-	// call(Goal), X=Template
-	x := term.NewVar("_")
-	call := term.NewCallable("call", goal)
-	unify := term.NewCallable("=", x, template)
-	prove := term.NewCallable(",", call, unify)
-	proofs := m.ClearConjs().ClearDisjs().ProveAll(prove)
+// sub_atom(+Atom, ?Before, ?Length, ?After, ?Sub) see ISO §8.16.3
+//
+// True if Atom can be split into three pieces: a prefix of Before
+// characters, Sub which has Length characters and a suffix of After
+// characters.  Enumerates each solution on backtracking.
+func BuiltinSubAtom5(m Machine, args []term.Term) ForeignReturn {
+	return subText(m, args, mustAtom, newAtom)
+}
 
-	// build a list from the results
-	instances := make([]term.Term, 0)
-	for _, proof := range proofs {
-		t, err := proof.Resolve(x)
-		MaybePanic(err)
-		instances = append(instances, t)
-	}
+// newAtom is term.NewAtom with a type suitable for concatText and subText
+func newAtom(text string) term.Term {
+	return term.NewAtom(text)
+}
 
-	return ForeignUnify(args[2], term.NewTermList(instances))
+// newString is term.NewString with a type suitable for concatText and
+// subText
+func newString(text string) term.Term {
+	return term.NewString(text)
 }
 
-// listing/0
+// concatText implements atom_concat/3 and string_concat/3.  mustText
+// extracts the text of each bound argument and newText builds results.
+func concatText(m Machine, args []term.Term, mustText func(term.Term) string, newText func(string) term.Term) ForeignReturn {
+	a1, a2, a3 := args[0], args[1], args[2]
+	if !term.IsVariable(a1) && !term.IsVariable(a2) {
+		whole := mustText(a1) + mustText(a2)
+		if term.IsVariable(a3) {
+			return ForeignUnify(a3, newText(whole))
+		}
+		if mustText(a3) != whole {
+			return ForeignFail()
+		}
+		return ForeignTrue()
+	}
+	if term.IsVariable(a3) {
+		panic(term.InstantiationError())
+	}
+	for _, a := range []term.Term{a1, a2} {
+		if !term.IsVariable(a) {
+			mustText(a)
+		}
+	}
+
+	// enumerate each way of splitting the whole
+	runes := []rune(mustText(a3))
+	return unifyEach(m, func(i int) ([]term.Term, int, bool) {
+		for ; i <= len(runes); i++ {
+			before, after := string(runes[:i]), string(runes[i:])
+			if matchesText(a1, before, mustText) && matchesText(a2, after, mustText) {
+				return []term.Term{
+					a1, textArg(a1, before, newText),
+					a2, textArg(a2, after, newText),
+				}, i + 1, true
+			}
+		}
+		return nil, 0, false
+	})
+}
+
+// matchesText returns true if arg is unbound or its text is text.
+// Bound arguments are compared as text, so that any kind of text
+// matches, not only the kind which newText builds.
+func matchesText(arg term.Term, text string, mustText func(term.Term) string) bool {
+	return term.IsVariable(arg) || mustText(arg) == text
+}
+
+// textArg returns the term to unify with arg when its text is text: a
+// new term if arg is unbound, otherwise arg itself
+func textArg(arg term.Term, text string, newText func(string) term.Term) term.Term {
+	if term.IsVariable(arg) {
+		return newText(text)
+	}
+	return arg
+}
+
+// subText implements sub_atom/5 and sub_string/5.  mustText extracts
+// the text of bound arguments and newText builds results.
+func subText(m Machine, args []term.Term, mustText func(term.Term) string, newText func(string) term.Term) ForeignReturn {
+	runes := []rune(mustText(args[0]))
+	bArg, lArg, aArg, sub := args[1], args[2], args[3], args[4]
+	b, bKnown := optionalInt(bArg)
+	l, lKnown := optionalInt(lArg)
+	a, aKnown := optionalInt(aArg)
+	n := len(runes)
+
+	// solution returns the terms to unify for a substring, or nil if
+	// there's no such substring
+	solution := func(b, l int) []term.Term {
+		if b < 0 || l < 0 || b+l > n {
+			return nil
+		}
+		return []term.Term{
+			bArg, term.NewInt64(int64(b)),
+			lArg, term.NewInt64(int64(l)),
+			aArg, term.NewInt64(int64(n - b - l)),
+			sub, textArg(sub, string(runes[b:b+l]), newText),
+		}
+	}
+	only := func(ts []term.Term) ForeignReturn {
+		if ts == nil {
+			return ForeignFail()
+		}
+		return ForeignUnify(ts...)
+	}
+
+	switch {
+	case !term.IsVariable(sub):
+		s := mustText(sub)
+		l := utf8.RuneCountInString(s)
+		return unifyEach(m, func(b int) ([]term.Term, int, bool) {
+			for ; b+l <= n; b++ {
+				if string(runes[b:b+l]) == s {
+					return solution(b, l), b + 1, true
+				}
+			}
+			return nil, 0, false
+		})
+	case bKnown && lKnown:
+		return only(solution(b, l))
+	case bKnown && aKnown:
+		return only(solution(b, n-b-a))
+	case lKnown && aKnown:
+		return only(solution(n-l-a, l))
+	}
+
+	// every substring, ordered by position then length.  Position i
+	// stands for the substring at i/(n+1) with length i%(n+1).
+	return unifyEach(m, func(i int) ([]term.Term, int, bool) {
+		for ; i/(n+1) <= n; i++ {
+			if ts := solution(i/(n+1), i%(n+1)); ts != nil {
+				return ts, i + 1, true
+			}
+		}
+		return nil, 0, false
+	})
+}
+
+// optionalInt returns the value of an integer argument.  Returns false
+// if the argument is a variable.  Arguments too large for an int can
+// never describe part of an atom, so they're reported as -1.
+func optionalInt(t term.Term) (int, bool) {
+	if term.IsVariable(t) {
+		return 0, false
+	}
+	if !term.IsInteger(t) {
+		panic(term.TypeError("integer", t))
+	}
+	i := t.(*term.Integer).Value()
+	if !i.IsInt64() || i.Int64() > math.MaxInt32 || i.Int64() < 0 {
+		return -1, true
+	}
+	return int(i.Int64()), true
+}
+
+// downcase_atom(+AnyCase, -LowerCase) is det.
+//
+// True if LowerCase is AnyCase with all letters in lower case.
+// AnyCase may be any atomic term.
+func BuiltinDowncaseAtom2(m Machine, args []term.Term) ForeignReturn {
+	text := mustAtomic(args[0])
+	return ForeignUnify(args[1], term.NewAtom(strings.ToLower(text)))
+}
+
+// upcase_atom(+AnyCase, -UpperCase) is det.
+//
+// Like downcase_atom/2 but converts letters to upper case.
+func BuiltinUpcaseAtom2(m Machine, args []term.Term) ForeignReturn {
+	text := mustAtomic(args[0])
+	return ForeignUnify(args[1], term.NewAtom(strings.ToUpper(text)))
+}
+
+// atomic_list_concat(+List, -Atom) is det.
+//
+// Atom is the concatenation of the atomic terms in List.
+func BuiltinAtomicListConcat2(m Machine, args []term.Term) ForeignReturn {
+	parts := mustProperList(args[0])
+	texts := make([]string, len(parts))
+	for i, part := range parts {
+		texts[i] = mustAtomic(part)
+	}
+	return ForeignUnify(args[1], term.NewAtom(strings.Join(texts, "")))
+}
+
+// atomic_list_concat(?List, +Separator, ?Atom) is det.
+//
+// Like atomic_list_concat/2 but places Separator between each element.
+// If List isn't a ground list, Atom is split at each Separator instead.
+func BuiltinAtomicListConcat3(m Machine, args []term.Term) ForeignReturn {
+	list, separator, atom := args[0], args[1], args[2]
+	sep := mustAtomic(separator)
+	if isGroundList(list) {
+		parts := mustProperList(list)
+		texts := make([]string, len(parts))
+		for i, part := range parts {
+			texts[i] = mustAtomic(part)
+		}
+		return ForeignUnify(atom, term.NewAtom(strings.Join(texts, sep)))
+	}
+
+	// split mode
+	if term.IsVariable(atom) {
+		panic(term.InstantiationError())
+	}
+	if sep == "" {
+		panic(term.DomainError("non_empty_atom", separator))
+	}
+	mustPartialList(list)
+	texts := strings.Split(mustAtomic(atom), sep)
+	parts := make([]term.Term, len(texts))
+	for i, text := range texts {
+		parts[i] = term.NewAtom(text)
+	}
+	return ForeignUnify(list, term.NewTermList(parts))
+}
+
+// term_to_atom(?Term, ?Atom) is det.
+//
+// Atom is the text of Term, as written by writeq/1.  If Atom is bound,
+// it's parsed to produce Term.
+func BuiltinTermToAtom2(m Machine, args []term.Term) ForeignReturn {
+	t, atom := args[0], args[1]
+	if term.IsVariable(atom) {
+		if term.IsVariable(t) {
+			panic(term.InstantiationError())
+		}
+		text := m.(*machine).termText(t, writeOptions{quoted: true})
+		return ForeignUnify(atom, term.NewAtom(text))
+	}
+	return ForeignUnify(t, mustParseText(m, atom))
+}
+
+// term_string(?Term, ?String) is det.
+//
+// Like term_to_atom/2 but the text is a string.
+func BuiltinTermString2(m Machine, args []term.Term) ForeignReturn {
+	t, s := args[0], args[1]
+	if term.IsVariable(s) {
+		text := m.(*machine).termText(t, writeOptions{quoted: true})
+		return ForeignUnify(s, term.NewString(text))
+	}
+	return ForeignUnify(t, mustParseText(m, s))
+}
+
+// read_term_from_atom(+Atom, -Term, +Options) is det.
+//
+// Parses the text of Atom, which may end with a full stop, as a term.
+// Options are those of read_term/2.
+func BuiltinReadTermFromAtom3(m Machine, args []term.Term) ForeignReturn {
+	text := mustText(args[0])
+	opts := mustReadOptions(args[2])
+	parsed, r, err := m.(*machine).parseText(text)
+	if err != nil {
+		return m.(*machine).syntaxError(opts, err)
+	}
+	return opts.unify(args[1], parsed, r)
+}
+
+// mustParseText parses the text of an atom, string or code list as a
+// single term.  Raises a syntax error if the text isn't a term.
+func mustParseText(m Machine, t term.Term) term.Term {
+	parsed, _, err := m.(*machine).parseText(mustText(t))
+	if err != nil {
+		panic(term.SyntaxError(err.Error()))
+	}
+	return parsed
+}
+
+// read(-Term) see ISO §8.14.1
+//
+// Like read_term/2 with no options.
+func BuiltinRead1(m Machine, args []term.Term) ForeignReturn {
+	return BuiltinReadTerm3(m, []term.Term{m.(*machine).input.Term(), args[0], term.NewAtom("[]")})
+}
+
+// read(+Stream, -Term) see ISO §8.14.1
+//
+// Like read_term/3 with no options.
+func BuiltinRead2(m Machine, args []term.Term) ForeignReturn {
+	return BuiltinReadTerm3(m, []term.Term{args[0], args[1], term.NewAtom("[]")})
+}
+
+// read_term(-Term, +Options) see ISO §8.14.1
+//
+// Like read_term/3 with the current input stream.
+func BuiltinReadTerm2(m Machine, args []term.Term) ForeignReturn {
+	return BuiltinReadTerm3(m, []term.Term{m.(*machine).input.Term(), args[0], args[1]})
+}
+
+// read_term(+Stream, -Term, +Options) see ISO §8.14.1
+//
+// Reads the next term from a text stream.  Term is end_of_file at the
+// end of the stream.  Options are variables(Vs), variable_names(Vs),
+// singletons(Vs) and syntax_errors(error|fail|quiet).
+func BuiltinReadTerm3(m Machine, args []term.Term) ForeignReturn {
+	s := mustInputStream(m, args[0], false)
+	opts := mustReadOptions(args[2])
+	return m.(*machine).readTermFrom(s, args[1], opts)
+}
+
+// string(@Term) is semidet.
+//
+// True if Term is a packed string.
+func BuiltinString1(m Machine, args []term.Term) ForeignReturn {
+	if term.IsString(args[0]) {
+		return ForeignTrue()
+	}
+	return ForeignFail()
+}
+
+// string_chars(?String, ?Chars) is det.
+//
+// Chars is a list of the characters of String.
+func BuiltinStringChars2(m Machine, args []term.Term) ForeignReturn {
+	s, list := args[0], args[1]
+	if !term.IsVariable(s) {
+		return ForeignUnify(list, newCharList(mustText(s)))
+	}
+	return ForeignUnify(s, term.NewString(charsText(list)))
+}
+
+// string_codes(?String, ?Codes) is det.
+//
+// Codes is a list of the character codes of String.
+func BuiltinStringCodes2(m Machine, args []term.Term) ForeignReturn {
+	s, list := args[0], args[1]
+	if !term.IsVariable(s) {
+		return ForeignUnify(list, term.NewCodeList(mustText(s)))
+	}
+	return ForeignUnify(s, term.NewString(codesText(list)))
+}
+
+// string_to_atom(?String, ?Atom) is det.
+//
+// Atom has the same characters as String.
+func BuiltinStringToAtom2(m Machine, args []term.Term) ForeignReturn {
+	s, atom := args[0], args[1]
+	if !term.IsVariable(s) {
+		return ForeignUnify(atom, term.NewAtom(mustText(s)))
+	}
+	return ForeignUnify(s, term.NewString(mustAtomic(atom)))
+}
+
+// string_length(+String, -Length) is det.
+//
+// Length is the number of characters in String.
+func BuiltinStringLength2(m Machine, args []term.Term) ForeignReturn {
+	n := utf8.RuneCountInString(mustText(args[0]))
+	return ForeignUnify(args[1], term.NewInt64(int64(n)))
+}
+
+// string_concat(?String1, ?String2, ?String3) is nondet.
+//
+// Like atom_concat/3 but the results are strings.
+func BuiltinStringConcat3(m Machine, args []term.Term) ForeignReturn {
+	return concatText(m, args, mustText, newString)
+}
+
+// sub_string(+String, ?Before, ?Length, ?After, ?Sub) is nondet.
+//
+// Like sub_atom/5 but Sub is a string.
+func BuiltinSubString5(m Machine, args []term.Term) ForeignReturn {
+	return subText(m, args, mustText, newString)
+}
+
+// string_code(+Index, +String, -Code) is semidet.
+//
+// Code is the character code at position Index of String, counting
+// from 1.  Fails if Index is out of range.
+func BuiltinStringCode3(m Machine, args []term.Term) ForeignReturn {
+	index, ok := optionalInt(args[0])
+	if !ok {
+		panic(term.InstantiationError())
+	}
+	runes := []rune(mustText(args[1]))
+	if index < 1 || index > len(runes) {
+		return ForeignFail()
+	}
+	return ForeignUnify(args[2], term.NewCode(runes[index-1]))
+}
+
+// split_string(+String, +SepChars, +PadChars, -SubStrings) is det.
+//
+// Breaks String into SubStrings at each character in SepChars.  Then
+// removes any characters in PadChars from both ends of each substring.
+// If SepChars is empty, String is only stripped of padding.
+func BuiltinSplitString4(m Machine, args []term.Term) ForeignReturn {
+	text := mustText(args[0])
+	sep := mustText(args[1])
+	pad := mustText(args[2])
+
+	var parts []string
+	if sep == "" {
+		parts = []string{text}
+	} else {
+		parts = splitKeepEmpty(text, sep)
+	}
+	strs := make([]term.Term, len(parts))
+	for i, part := range parts {
+		strs[i] = term.NewString(strings.Trim(part, pad))
+	}
+	return ForeignUnify(args[3], term.NewTermList(strs))
+}
+
+// string_lower(+String, -LowerCase) is det.
+//
+// LowerCase is String with all letters in lower case.
+func BuiltinStringLower2(m Machine, args []term.Term) ForeignReturn {
+	text := mustText(args[0])
+	return ForeignUnify(args[1], term.NewString(strings.ToLower(text)))
+}
+
+// string_upper(+String, -UpperCase) is det.
+//
+// UpperCase is String with all letters in upper case.
+func BuiltinStringUpper2(m Machine, args []term.Term) ForeignReturn {
+	text := mustText(args[0])
+	return ForeignUnify(args[1], term.NewString(strings.ToUpper(text)))
+}
+
+// number_string(?Number, ?String) is det.
+//
+// String is the text of Number.  If String is bound, it's parsed as a
+// number after removing leading and trailing white space.
+func BuiltinNumberString2(m Machine, args []term.Term) ForeignReturn {
+	number, s := args[0], args[1]
+	if !term.IsVariable(s) {
+		text := strings.TrimSpace(mustText(s))
+		return ForeignUnify(number, mustParseNumber(text))
+	}
+	if term.IsVariable(number) {
+		panic(term.InstantiationError())
+	}
+	if !term.IsNumber(number) {
+		panic(term.TypeError("number", number))
+	}
+	return ForeignUnify(s, term.NewString(number.String()))
+}
+
+// mustText returns the text of an atom, string, number, code list or
+// character list.  Panics with an ISO error for anything else.
+func mustText(t term.Term) string {
+	if term.IsVariable(t) {
+		panic(term.InstantiationError())
+	}
+	if term.IsAtomic(t) {
+		return mustAtomic(t)
+	}
+	if term.IsCodeList(t) {
+		return codesText(t)
+	}
+	if term.IsList(t) {
+		return charsText(t)
+	}
+	panic(term.TypeError("string", t))
+}
+
+// splitKeepEmpty splits text at each character of sep.  Adjacent
+// separators produce empty strings.
+func splitKeepEmpty(text, sep string) []string {
+	var parts []string
+	start := 0
+	for i, c := range text {
+		if strings.ContainsRune(sep, c) {
+			parts = append(parts, text[start:i])
+			start = i + utf8.RuneLen(c)
+		}
+	}
+	return append(parts, text[start:])
+}
+
+// char_type(?Char, ?Type) is nondet.
+//
+// True if Char has the character Type.  Types are those of
+// SWI-Prolog, like alpha, digit(Weight) or upper(Lower).  Enumerates
+// characters if Char is unbound and types if Type is unbound.
+func BuiltinCharType2(m Machine, args []term.Term) ForeignReturn {
+	return classifyChar(m, args, false)
+}
+
+// code_type(?Code, ?Type) is nondet.
+//
+// Like char_type/2 but for character codes.  Character arguments of
+// Type, like the Lower of upper(Lower), are codes too.
+func BuiltinCodeType2(m Machine, args []term.Term) ForeignReturn {
+	return classifyChar(m, args, true)
+}
+
+// $char_type/4
+//
+// An internal system predicate which might be removed at any time
+// in the future.  It enumerates characters, starting with the code in
+// its last argument, which have a given type.
+func BuiltinCharTypeFrom(m Machine, args []term.Term) ForeignReturn {
+	char, typ, kind := args[0], args[1], args[2]
+	asCode := kind.(*term.Atom).Name() == "code"
+	from := args[3].(*term.Integer).Value().Int64()
+	candidates := matchingCharTypes(typ)
+	variants, limited := caseCandidates(typ, candidates)
+	for c := rune(from); c <= unicode.MaxRune; c++ {
+		if limited { // skip ahead to the next case variant
+			for len(variants) > 0 && variants[0] < c {
+				variants = variants[1:]
+			}
+			if len(variants) == 0 {
+				break
+			}
+			c = variants[0]
+		}
+		if 0xD800 <= c && c <= 0xDFFF {
+			continue // surrogates aren't characters
+		}
+
+		// keep only those types which unify with the one requested
+		var alternatives [][]term.Term
+		for _, alt := range charTypeAlternatives(c, typ, candidates, asCode) {
+			if _, err := typ.Unify(term.NewBindings(), alt[1]); err == nil {
+				alternatives = append(alternatives, append([]term.Term{char, charTerm(c, asCode)}, alt...))
+			}
+		}
+		if alternatives == nil {
+			continue
+		}
+
+		// this character, then try those after it
+		next := term.NewCallable("$char_type", char, typ, kind, term.NewInt64(int64(c)+1))
+		return m.PushConj(term.NewCallable(";", alternativesGoal(alternatives), next))
+	}
+	return ForeignFail()
+}
+
+// classifyChar implements char_type/2 and code_type/2
+func classifyChar(m Machine, args []term.Term, asCode bool) ForeignReturn {
+	char, typ := args[0], args[1]
+	candidates := mustCharType(typ)
+	if term.IsVariable(char) {
+		if term.IsVariable(typ) {
+			panic(term.InstantiationError())
+		}
+		kind := term.NewAtom("char")
+		if asCode {
+			kind = term.NewAtom("code")
+		}
+		goal := term.NewCallable("$char_type", char, typ, kind, term.NewInt64(0))
+		return m.PushConj(goal)
+	}
+
+	var c rune
+	if isEndOfFile(char, asCode) {
+		c = endOfFile
+	} else if asCode && term.IsInteger(char) {
+		c = mustCharacterCode(char)
+	} else {
+		c = mustCharacter(char)
+	}
+	return unifyAlternatives(m, charTypeAlternatives(c, typ, candidates, asCode))
+}
+
+// isEndOfFile returns true if t is -1 for code_type/2 or end_of_file
+// for char_type/2
+func isEndOfFile(t term.Term, asCode bool) bool {
+	if asCode {
+		return term.IsInteger(t) && t.(*term.Integer).Value().Cmp(big.NewInt(-1)) == 0
+	}
+	return term.IsAtom(t) && t.(*term.Atom).Name() == "end_of_file"
+}
+
+// mustAtom returns the name of an atom.  Panics with an ISO error for
+// anything else.
+func mustAtom(t term.Term) string {
+	if term.IsVariable(t) {
+		panic(term.InstantiationError())
+	}
+	if !term.IsAtom(t) {
+		panic(term.TypeError("atom", t))
+	}
+	return t.(*term.Atom).Name()
+}
+
+// mustPredicateIndicator returns the name and arity of a predicate
+// indicator like foo/2.  Panics with an ISO error for anything else.
+func mustPredicateIndicator(t term.Term) (string, int) {
+	if term.IsVariable(t) {
+		panic(term.InstantiationError())
+	}
+	if !term.IsCompound(t) || t.Indicator() != "//2" {
+		panic(term.TypeError("predicate_indicator", t))
+	}
+	args := t.(*term.Compound).Arguments()
+	name, arity := args[0], args[1]
+	if term.IsVariable(name) || term.IsVariable(arity) {
+		panic(term.InstantiationError())
+	}
+	if !term.IsAtom(name) {
+		panic(term.TypeError("atom", name))
+	}
+	if !term.IsInteger(arity) {
+		panic(term.TypeError("integer", arity))
+	}
+	n := arity.(*term.Integer).Value()
+	if n.Sign() < 0 {
+		panic(term.DomainError("not_less_than_zero", arity))
+	}
+	if !n.IsInt64() || n.Int64() > math.MaxInt32 {
+		panic(term.RepresentationError("max_arity"))
+	}
+	return name.(*term.Atom).Name(), int(n.Int64())
+}
+
+// mustAtomic returns the text of an atom, string or number.  Panics
+// with an ISO error for anything else.
+func mustAtomic(t term.Term) string {
+	if term.IsVariable(t) {
+		panic(term.InstantiationError())
+	}
+	if term.IsAtom(t) {
+		return t.(*term.Atom).Name()
+	}
+	if term.IsString(t) {
+		return t.(*term.String).Text()
+	}
+	if term.IsNumber(t) {
+		return t.String()
+	}
+	panic(term.TypeError("atomic", t))
+}
+
+// mustCharacter returns the character of a one character atom.
+func mustCharacter(t term.Term) rune {
+	if term.IsVariable(t) {
+		panic(term.InstantiationError())
+	}
+	if term.IsAtom(t) {
+		name := t.(*term.Atom).Name()
+		if utf8.RuneCountInString(name) == 1 {
+			c, _ := utf8.DecodeRuneInString(name)
+			return c
+		}
+	}
+	panic(term.TypeError("character", t))
+}
+
+// mustCharacterCode returns the character with the given code.
+func mustCharacterCode(t term.Term) rune {
+	if term.IsVariable(t) {
+		panic(term.InstantiationError())
+	}
+	if !term.IsInteger(t) {
+		panic(term.TypeError("integer", t))
+	}
+	i := t.(*term.Integer).Value()
+	if !i.IsInt64() || i.Int64() < 0 || i.Int64() > unicode.MaxRune {
+		panic(term.RepresentationError("character_code"))
+	}
+	return rune(i.Int64())
+}
+
+// codesText returns the text represented by a list of character codes
+func codesText(list term.Term) string {
+	var buf bytes.Buffer
+	for _, code := range mustProperList(list) {
+		if term.IsVariable(code) {
+			panic(term.InstantiationError())
+		}
+		if !term.IsInteger(code) {
+			panic(term.RepresentationError("character_code"))
+		}
+		buf.WriteRune(mustCharacterCode(code))
+	}
+	return buf.String()
+}
+
+// charsText returns the text represented by a list of characters
+func charsText(list term.Term) string {
+	var buf bytes.Buffer
+	for _, char := range mustProperList(list) {
+		buf.WriteRune(mustCharacter(char))
+	}
+	return buf.String()
+}
+
+// newCharList returns a list of the characters in text, each as a one
+// character atom
+func newCharList(text string) term.Term {
+	chars := make([]term.Term, 0, len(text))
+	for _, c := range text {
+		chars = append(chars, term.NewAtom(string(c)))
+	}
+	return term.NewTermList(chars)
+}
+
+// isGroundList returns true if t is a proper list whose elements are
+// all ground
+func isGroundList(t term.Term) bool {
+	for {
+		switch {
+		case term.IsEmptyList(t):
+			return true
+		case term.IsCompound(t) && t.(term.Callable).Indicator() == "./2":
+			args := t.(term.Callable).Arguments()
+			if !isGround(args[0]) {
+				return false
+			}
+			t = args[1]
+		default:
+			return false
+		}
+	}
+}
+
+// isGround returns true if t contains no variables
+func isGround(t term.Term) bool {
+	return len(term.TermVariables(t)) == 0
+}
+
+// parseTerm reads a single term from text, which needn't end with a
+// full stop.  Double quoted text is read according to dq.
+func parseTerm(text string, dq read.DoubleQuotes) (t term.Term, err error) {
+	defer func() { // convert parsing panics into errors
+		if x := recover(); x != nil {
+			t, err = nil, fmt.Errorf("%v", x)
+		}
+	}()
+	r, err := read.NewTermReader(text + " .")
+	if err != nil {
+		return nil, err
+	}
+	r.SetDoubleQuotes(dq)
+	t, err = r.Next()
+	if err == read.NoMoreTerms {
+		return nil, fmt.Errorf("expected a term in %q", text)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err = r.Next(); err != read.NoMoreTerms {
+		return nil, fmt.Errorf("expected exactly one term in %q", text)
+	}
+	return t, nil
+}
+
+// parseNumber reads text as a number.  Returns false if text isn't
+// the representation of a number.  Layout text may precede the number
+// but nothing may follow it, not even an end token.
+func parseNumber(text string) (term.Number, bool) {
+	var tokens []*lex.Eme
+	for eme := range lex.Scan(strings.NewReader(text)) {
+		if eme.Type == lex.Comment && len(tokens) == 0 {
+			continue // leading layout text
+		}
+		tokens = append(tokens, eme)
+	}
+
+	// a single numeric token, perhaps with a minus sign directly before it
+	if len(tokens) == 2 && tokens[0].Type == lex.Atom && tokens[0].Content == "-" {
+		if tokens[1].Pos.Offset != tokens[0].Pos.Offset+1 {
+			return nil, false
+		}
+		tokens = tokens[1:]
+	}
+	if len(tokens) != 1 {
+		return nil, false
+	}
+	switch tokens[0].Type {
+	case lex.Int, lex.Float, lex.Rational:
+	default:
+		return nil, false
+	}
+	if tokens[0].Pos.Offset+len(tokens[0].Content) != len(text) {
+		return nil, false // layout text or an end token follows the number
+	}
+
+	t, err := parseTerm(text, read.DoubleQuotesCodes)
+	if err != nil || !term.IsNumber(t) {
+		return nil, false
+	}
+	return t.(term.Number), true
+}
+
+// mustParseNumber is like parseNumber but raises a syntax error
+// instead of returning false
+func mustParseNumber(text string) term.Number {
+	n, ok := parseNumber(text)
+	if !ok {
+		panic(term.SyntaxError("illegal_number"))
+	}
+	return n
+}
+
+// call/*
+func BuiltinCall(m Machine, args []term.Term) ForeignReturn {
+	if term.IsVariable(args[0]) {
+		panic(term.InstantiationError())
+	}
+	if !term.IsCallable(args[0]) {
+		panic(term.TypeError("callable", args[0]))
+	}
+
+	// build a new goal with extra arguments attached
+	bodyTerm := args[0].(term.Callable)
+	functor := bodyTerm.Name()
+	newArgs := make([]term.Term, 0)
+	newArgs = append(newArgs, bodyTerm.Arguments()...)
+	newArgs = append(newArgs, args[1:]...)
+	goal := term.NewCallable(functor, newArgs...)
+
+	// construct a machine that will prove this goal next
+	return m.DemandCutBarrier().PushConj(goal)
+}
+
+// catch(:Goal, ?Catcher, :Recovery) see ISO §7.8.9
+//
+// Behaves like call(Goal).  If an exception is raised while proving
+// Goal and the exception unifies with Catcher, backtracks to the catch/3
+// call, unifies the ball with Catcher and calls Recovery.
+func BuiltinCatch3(m Machine, args []term.Term) ForeignReturn {
+	cp := NewCatchChoicePoint(m, args[1], args[2])
+	id := term.NewInt64(cp.(*catchCP).id)
+	goal := term.NewCallable(",",
+		term.NewCallable("call", args[0]),
+		term.NewCallable("$catch_exit", id),
+	)
+	return m.PushDisj(cp).PushConj(goal)
+}
+
+// fail/0
+func BuiltinFail(m Machine, args []term.Term) ForeignReturn {
+	return ForeignFail()
+}
+
+// findall(+Template, :Goal, -Bag) see ISO §8.10.1
+//
+// Bag is a list of Template instances, one for each solution of Goal.
+func BuiltinFindall3(m Machine, args []term.Term) ForeignReturn {
+	instances, sub := findInstances(m, args[0], args[1])
+	return continueOn(m, sub, ForeignUnify(args[2], term.NewTermList(instances)))
+}
+
+// findall(+Template, :Goal, -Bag, ?Tail) is det.
+//
+// Like findall/3 but Bag is a difference list ending in Tail.
+func BuiltinFindall4(m Machine, args []term.Term) ForeignReturn {
+	instances, sub := findInstances(m, args[0], args[1])
+	bag := args[3]
+	for i := len(instances) - 1; i >= 0; i-- {
+		bag = term.NewCallable(".", instances[i], bag)
+	}
+	return continueOn(m, sub, ForeignUnify(args[2], bag))
+}
+
+// findnsols(+N, @Template, :Goal, -List) is nondet.
+//
+// Like findall/3 but finds at most N solutions.  Backtracking finds the
+// next N solutions.  The final List may be empty.
+func BuiltinFindnsols4(m Machine, args []term.Term) ForeignReturn {
+	n, template, goal := args[0], args[1], args[2]
+	if term.IsVariable(n) {
+		panic(term.InstantiationError())
+	}
+	if !term.IsInteger(n) {
+		panic(term.TypeError("integer", n))
+	}
+	count := n.(*term.Integer).Value()
+	if count.Sign() <= 0 {
+		panic(term.DomainError("not_less_than_one", n))
+	}
+	if !count.IsInt64() {
+		panic(term.RepresentationError("max_integer"))
+	}
+
+	sub := m.ClearConjs().ClearDisjs().PushConj(term.NewCallable("call", goal))
+	return findnsols(m, sub, int(count.Int64()), template, args[3])
+}
+
+// findnsols unifies list with the next chunk of at most n solutions
+// which the separate machine sub finds for template.  Unless sub is out
+// of solutions, the result leaves a choice point which resumes sub to
+// find the chunk after this one.  Template's bindings are never seen by
+// the caller.
+func findnsols(m, sub Machine, n int, template, list term.Term) ForeignReturn {
+	instances := make([]term.Term, 0, n)
+	done := false
+	for !done && len(instances) < n {
+		next, env, err := sub.Step()
+		if err == MachineDone {
+			done = true
+		} else {
+			MaybePanic(err)
+		}
+		sub = next
+		if env != nil {
+			instances = append(instances, term.CopyTerm(resolveIn(env, template)))
+		}
+	}
+
+	m1 := sub.(*machine).carryGlobals(m)
+	if !done {
+		m1 = m1.PushDisj(newResumeChoicePoint(m, func(now Machine) Machine {
+			later := now.(*machine)
+			return findnsols(later.carryGlobals(m), later.carryGlobals(sub), n, template, list).(Machine)
+		}))
+	}
+	return m1.PushConj(term.NewCallable("=", list, term.NewTermList(instances)))
+}
+
+// bagof(+Template, :Goal, -Bag) see ISO §8.10.2
+//
+// Like findall/3 but fails if Goal has no solutions.  If Goal has free
+// variables (those not in Template or marked with ^/2), the solutions
+// are grouped by the bindings of those variables.  Each group is found
+// on backtracking.
+func BuiltinBagof3(m Machine, args []term.Term) ForeignReturn {
+	return bagof(m, args, false)
+}
+
+// setof(+Template, :Goal, -Set) see ISO §8.10.3
+//
+// Like bagof/3 but each Set is sorted and has no duplicates.
+func BuiltinSetof3(m Machine, args []term.Term) ForeignReturn {
+	return bagof(m, args, true)
+}
+
+// bagof implements bagof/3 and setof/3
+func bagof(m Machine, args []term.Term, toSet bool) ForeignReturn {
+	template, goal, bag := args[0], args[1], args[2]
+	ordering := m.(*machine).standardOrder()
+
+	// which variables are free?
+	bound := make(map[string]bool)
+	for _, v := range term.TermVariables(template) {
+		bound[v.Indicator()] = true
+	}
+	for term.IsCompound(goal) && goal.(term.Callable).Indicator() == "^/2" {
+		quantified := goal.(term.Callable).Arguments()
+		for _, v := range term.TermVariables(quantified[0]) {
+			bound[v.Indicator()] = true
+		}
+		goal = quantified[1]
+	}
+	var free []term.Term
+	for _, v := range term.TermVariables(goal) {
+		if !bound[v.Indicator()] {
+			free = append(free, v)
+		}
+	}
+	witness := term.NewTermList(free)
+
+	// find solutions along with their witnesses
+	pairs, sub := findInstances(m, term.NewCallable("-", witness, template), goal)
+	if len(pairs) == 0 {
+		return continueOn(m, sub, ForeignFail())
+	}
+	if len(free) == 0 {
+		return continueOn(m, sub, ForeignUnify(bag, collectedBag(pairs, toSet, ordering)))
+	}
+	m = sub.(*machine).carryGlobals(m)
+
+	// group solutions with variant witnesses
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return ordering.Compare(pairKey(pairs[i]), pairKey(pairs[j])) < 0
+	})
+	var alternatives [][]term.Term
+	for len(pairs) > 0 {
+		var group, rest []term.Term
+		unifications := []term.Term{}
+		for _, pair := range pairs {
+			if term.IsVariant(pairKey(pair), pairKey(pairs[0])) {
+				group = append(group, pair)
+				unifications = append(unifications, witness, pairKey(pair))
+			} else {
+				rest = append(rest, pair)
+			}
+		}
+		unifications = append(unifications, bag, collectedBag(group, toSet, ordering))
+		alternatives = append(alternatives, unifications)
+		pairs = rest
+	}
+	return unifyAlternatives(m, alternatives)
+}
+
+// pairKey returns the Key of a Key-Value term
+func pairKey(pair term.Term) term.Term {
+	return pair.(term.Callable).Arguments()[0]
+}
+
+// collectedBag returns a list of the values in a list of Key-Value pairs.
+// If toSet is true, the values are sorted without duplicates.
+func collectedBag(pairs []term.Term, toSet bool, ordering term.StandardOrder) term.Term {
+	values := make([]term.Term, len(pairs))
+	for i, pair := range pairs {
+		values[i] = pair.(term.Callable).Arguments()[1]
+	}
+	if toSet {
+		sort.SliceStable(values, func(i, j int) bool {
+			return ordering.Compare(values[i], values[j]) < 0
+		})
+		unique := values[:0]
+		for i, v := range values {
+			if i == 0 || ordering.Compare(v, unique[len(unique)-1]) != 0 {
+				unique = append(unique, v)
+			}
+		}
+		values = unique
+	}
+	return term.NewTermList(values)
+}
+
+// aggregate_all(+Spec, :Goal, -Result) is semidet.
+//
+// Aggregates the solutions of Goal according to Spec, which is one of:
+//
+//   - count - the number of solutions
+//   - sum(Expr) - the sum of Expr over all solutions
+//   - max(Expr) - the largest value of Expr; fails without solutions
+//   - min(Expr) - the smallest value of Expr; fails without solutions
+//   - max(Expr, Witness) - max(Max, Witness) for the largest Expr
+//   - min(Expr, Witness) - min(Min, Witness) for the smallest Expr
+//   - bag(Template) - like findall/3
+//   - set(Template) - like findall/3 followed by sort/2
+//
+// Solutions are aggregated as they're found, so only bag and set
+// collect them all.
+func BuiltinAggregateAll3(m Machine, args []term.Term) ForeignReturn {
+	ret, sub := aggregateAll(m, args)
+	return continueOn(m, sub, ret)
+}
+
+// aggregateAll implements aggregate_all/3.  It returns the predicate's
+// result along with the machine in which Goal was proven.
+func aggregateAll(m Machine, args []term.Term) (ForeignReturn, Machine) {
+	spec, goal, result := args[0], args[1], args[2]
+	if term.IsVariable(spec) {
+		panic(term.InstantiationError())
+	}
+	if !term.IsCallable(spec) {
+		panic(term.TypeError("callable", spec))
+	}
+	s := spec.(term.Callable)
+	flags := m.(*machine).arithmeticFlags()
+	eval := func(env term.Bindings, t term.Term) term.Number {
+		n, err := term.ArithmeticEvalWithFlags(resolveIn(env, t), flags)
+		MaybePanic(err)
+		return n
+	}
+
+	switch s.Indicator() {
+	case "count/0":
+		count := int64(0)
+		sub := forEachSolution(m, goal, func(term.Bindings) bool {
+			count++
+			return true
+		})
+		return ForeignUnify(result, term.NewInt64(count)), sub
+	case "sum/1":
+		var sum term.Number = term.NewInt64(0)
+		sub := forEachSolution(m, goal, func(env term.Bindings) bool {
+			var err error
+			sum, err = term.ArithmeticAdd(sum, eval(env, s.Arguments()[0]))
+			MaybePanic(err)
+			return true
+		})
+		return ForeignUnify(result, sum), sub
+	case "max/1", "min/1", "max/2", "min/2":
+		sign := 1
+		if s.Name() == "min" {
+			sign = -1
+		}
+		var best term.Number
+		var witness term.Term
+		sub := forEachSolution(m, goal, func(env term.Bindings) bool {
+			n := eval(env, s.Arguments()[0])
+			if best == nil || sign*term.NumberCmp(n, best) > 0 {
+				best = n
+				if s.Arity() == 2 {
+					witness = term.CopyTerm(resolveIn(env, s.Arguments()[1]))
+				}
+			}
+			return true
+		})
+		if best == nil {
+			return ForeignFail(), sub
+		}
+		if s.Arity() == 2 {
+			return ForeignUnify(result, term.NewCallable(s.Name(), best, witness)), sub
+		}
+		return ForeignUnify(result, best), sub
+	case "bag/1":
+		instances, sub := findInstances(m, s.Arguments()[0], goal)
+		return ForeignUnify(result, term.NewTermList(instances)), sub
+	case "set/1":
+		instances, sub := findInstances(m, s.Arguments()[0], goal)
+		pairs := make([]term.Term, len(instances))
+		for i, instance := range instances {
+			pairs[i] = term.NewCallable("-", term.NewAtom("[]"), instance)
+		}
+		return ForeignUnify(result, collectedBag(pairs, true, m.(*machine).standardOrder())), sub
+	}
+	panic(term.DomainError("aggregate_spec", spec))
+}
+
+// findInstances returns a copy of template for each solution of goal.
+// It's the heart of findall/3.
+func findInstances(m Machine, template, goal term.Term) ([]term.Term, Machine) {
+	instances := make([]term.Term, 0)
+	sub := forEachSolution(m, goal, func(env term.Bindings) bool {
+		instances = append(instances, term.CopyTerm(resolveIn(env, template)))
+		return true
+	})
+	return instances, sub
+}
+
+// forEachSolution proves goal in a separate machine, calling f with the
+// bindings of each solution as it's found.  Stops early if f returns
+// false.  Exceptions raised by goal propagate to the caller.  Returns
+// the separate machine in its final state.  See continueOn.
+func forEachSolution(m Machine, goal term.Term, f func(term.Bindings) bool) Machine {
+	var env term.Bindings
+	var err error
+	m = m.ClearConjs().ClearDisjs().PushConj(term.NewCallable("call", goal))
+	for {
+		var next Machine
+		next, env, err = m.Step()
+		if err == MachineDone {
+			return next
+		}
+		MaybePanic(err)
+		m = next
+		if env != nil && !f(env) {
+			return m
+		}
+	}
+}
+
+// continueOn returns a foreign predicate's result, ret, adjusted to keep
+// the non-backtrackable state (see nb_setval/2) of sub, a separate machine
+// in which the predicate proved some goals.  m is the machine which
+// called the predicate.
+func continueOn(m, sub Machine, ret ForeignReturn) ForeignReturn {
+	m1 := sub.(*machine).carryGlobals(m)
+	if m1 == m {
+		return ret
+	}
+	switch x := ret.(type) {
+	case *foreignTrue:
+		return m1
+	case *foreignFail:
+		return m1.PushConj(term.NewAtom("fail"))
+	case *foreignUnify:
+		return m1.PushConj(alternativesGoal([][]term.Term{[]term.Term(*x)}))
+	}
+	return ret
+}
+
+// resolveIn returns t with all bound variables replaced by their values
+func resolveIn(env term.Bindings, t term.Term) term.Term {
+	if term.IsVariable(t) {
+		return env.Resolve_(t.(*term.Variable))
+	}
+	return t.ReplaceVariables(env)
+}
+
+// listing/0
 // This should be implemented in pure Prolog, but for debugging purposes,
 // I'm doing it for now as a foreign predicate.  This will go away.
 func BuiltinListing0(m Machine, args []term.Term) ForeignReturn {
-	fmt.Println(m.String())
+	out := mustOutputStream(m, m.(*machine).output.Term(), false)
+	out.write([]byte(m.String() + "\n"))
+	return ForeignTrue()
+}
+
+// must_be(+Type, @Term) is det.
+//
+// Raises an error unless Term has Type, which is one of integer,
+// positive_integer, nonneg, atom, callable, boolean or list.
+func BuiltinMustBe2(m Machine, args []term.Term) ForeignReturn {
+	typ, x := args[0], args[1]
+	name := mustAtom(typ)
+	if name == "list" {
+		mustProperList(x)
+		return ForeignTrue()
+	}
+	if term.IsVariable(x) {
+		panic(term.InstantiationError())
+	}
+
+	switch name {
+	case "integer", "positive_integer", "nonneg":
+		if !term.IsInteger(x) {
+			panic(term.TypeError("integer", x))
+		}
+		sign := x.(*term.Integer).Value().Sign()
+		if (name == "positive_integer" && sign <= 0) || (name == "nonneg" && sign < 0) {
+			panic(term.TypeError(name, x))
+		}
+	case "atom":
+		if !term.IsAtom(x) {
+			panic(term.TypeError(name, x))
+		}
+	case "callable":
+		if !term.IsCallable(x) {
+			panic(term.TypeError(name, x))
+		}
+	case "boolean":
+		if !term.IsAtom(x) || (x.(*term.Atom).Name() != "true" && x.(*term.Atom).Name() != "false") {
+			panic(term.TypeError(name, x))
+		}
+	default:
+		panic(term.DomainError("type", typ))
+	}
+	return ForeignTrue()
+}
+
+// msort(+Unsorted:list, -Sorted:list) is det.
+//
+// True if Sorted is a sorted version of Unsorted.  Duplicates are
+// not removed.  The sort is stable.
+func BuiltinMsort2(m Machine, args []term.Term) ForeignReturn {
+	return sortList(args[0], args[1], 0, "@=<", m.(*machine).standardOrder())
+}
+
+// sort(+List, -Sorted) see ISO §8.4.3
+//
+// Like msort/2 but removes duplicates.
+func BuiltinSort2(m Machine, args []term.Term) ForeignReturn {
+	return sortList(args[0], args[1], 0, "@<", m.(*machine).standardOrder())
+}
+
+// sort(+Key, +Order, +List, -Sorted) is det.
+//
+// Sorts List on the Key-th argument of each element, or on the whole
+// element if Key is 0.  Order is one of @< or @> which remove
+// elements with duplicate keys, or @=< or @>= which keep them.  The sort
+// is stable.
+func BuiltinSort4(m Machine, args []term.Term) ForeignReturn {
+	key, order := args[0], args[1]
+	if term.IsVariable(key) || term.IsVariable(order) {
+		panic(term.InstantiationError())
+	}
+	if !term.IsInteger(key) {
+		panic(term.TypeError("integer", key))
+	}
+	k := key.(*term.Integer).Value()
+	if k.Sign() < 0 {
+		panic(term.DomainError("not_less_than_zero", key))
+	}
+	if !term.IsAtom(order) {
+		panic(term.TypeError("atom", order))
+	}
+	switch o := order.(*term.Atom).Name(); o {
+	case "@<", "@=<", "@>", "@>=":
+		if !k.IsInt64() {
+			panic(term.RepresentationError("max_arity"))
+		}
+		return sortList(args[2], args[3], int(k.Int64()), o, m.(*machine).standardOrder())
+	}
+	panic(term.DomainError("order", order))
+}
+
+// keysort(+Pairs, -Sorted) see ISO §8.4.4
+//
+// Stable sort of a list of Key-Value pairs by Key.  Duplicates are
+// retained.
+func BuiltinKeysort2(m Machine, args []term.Term) ForeignReturn {
+	pairs := mustProperList(args[0])
+	mustPartialList(args[1])
+	for _, pair := range pairs {
+		if term.IsVariable(pair) {
+			panic(term.InstantiationError())
+		}
+		if !term.IsCompound(pair) || pair.(term.Callable).Indicator() != "-/2" {
+			panic(term.TypeError("pair", pair))
+		}
+	}
+	return sortTerms(pairs, args[1], 1, "@=<", m.(*machine).standardOrder())
+}
+
+// sortList sorts the elements of list, as described by sort/4, and
+// unifies the result with sorted
+func sortList(list, sorted term.Term, key int, order string, ordering term.StandardOrder) ForeignReturn {
+	terms := mustProperList(list)
+	mustPartialList(sorted)
+	return sortTerms(terms, sorted, key, order, ordering)
+}
+
+func sortTerms(terms []term.Term, sorted term.Term, key int, order string, ordering term.StandardOrder) ForeignReturn {
+	// extract the key from each term
+	keys := make([]term.Term, len(terms))
+	for i, t := range terms {
+		keys[i] = sortKey(t, key)
+	}
+
+	// sort the positions rather than the terms, so keys stay in sync
+	indices := make([]int, len(terms))
+	for i := range indices {
+		indices[i] = i
+	}
+	descending := order == "@>" || order == "@>="
+	sort.SliceStable(indices, func(i, j int) bool {
+		c := ordering.Compare(keys[indices[i]], keys[indices[j]])
+		if descending {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	// collect the results, removing duplicates if requested
+	dedup := order == "@<" || order == "@>"
+	results := make([]term.Term, 0, len(terms))
+	for n, i := range indices {
+		if dedup && n > 0 && ordering.Compare(keys[indices[n-1]], keys[i]) == 0 {
+			continue
+		}
+		results = append(results, terms[i])
+	}
+	return ForeignUnify(sorted, term.NewTermList(results))
+}
+
+// sortKey returns the key-th argument of t, or t itself if key is 0
+func sortKey(t term.Term, key int) term.Term {
+	if key == 0 {
+		return t
+	}
+	if term.IsVariable(t) {
+		panic(term.InstantiationError())
+	}
+	if !term.IsCompound(t) {
+		panic(term.TypeError("compound", t))
+	}
+	args := t.(term.Callable).Arguments()
+	if key > len(args) {
+		panic(term.TypeError("compound", t))
+	}
+	return args[key-1]
+}
+
+// length(?List, ?Length) is nondet.
+//
+// True if List has Length elements.  If List is a partial list and
+// Length is unbound, successively longer lists are generated on
+// backtracking.
+func BuiltinLength2(m Machine, args []term.Term) ForeignReturn {
+	list, length := args[0], args[1]
+	if !term.IsVariable(length) {
+		if !term.IsInteger(length) {
+			panic(term.TypeError("integer", length))
+		}
+		if length.(*term.Integer).Value().Sign() < 0 {
+			panic(term.DomainError("not_less_than_zero", length))
+		}
+	}
+
+	// count the elements which are already known
+	count := int64(0)
+	t := list
+	for isListCell(t) {
+		count++
+		t = t.(term.Callable).Arguments()[1]
+	}
+	switch {
+	case term.IsEmptyList(t):
+		return ForeignUnify(length, term.NewInt64(count))
+	case !term.IsVariable(t):
+		panic(term.TypeError("list", list))
+	case term.IsVariable(length):
+		return m.PushConj(term.NewCallable("$length", t, term.NewInt64(count), length))
+	}
+
+	// fill out the partial list
+	n := length.(*term.Integer).Value()
+	if !n.IsInt64() {
+		panic(term.ResourceError("memory"))
+	}
+	if n.Int64() < count {
+		return ForeignFail()
+	}
+	return ForeignUnify(t, newVarList(n.Int64()-count, term.NewAtom("[]")))
+}
+
+// nth0(?Index, ?List, ?Elem) is nondet.
+//
+// True if Elem is the Index-th element of List, counting from 0.
+func BuiltinNth03(m Machine, args []term.Term) ForeignReturn {
+	return nth(m, args, 0)
+}
+
+// nth1(?Index, ?List, ?Elem) is nondet.
+//
+// Like nth0/3 but counts from 1.
+func BuiltinNth13(m Machine, args []term.Term) ForeignReturn {
+	return nth(m, args, 1)
+}
+
+// nth implements nth0/3 and nth1/3.  base is the index of the first
+// element.  A bound index is found directly, extending List if it's a
+// partial list.  Otherwise, '$nth'/4 enumerates the elements.
+func nth(m Machine, args []term.Term, base int64) ForeignReturn {
+	index, list, elem := args[0], args[1], args[2]
+	if term.IsVariable(index) {
+		return m.PushConj(term.NewCallable("$nth", list, term.NewInt64(base), index, elem))
+	}
+	if !term.IsInteger(index) {
+		panic(term.TypeError("integer", index))
+	}
+	i := index.(*term.Integer).Value()
+	if !i.IsInt64() || i.Int64() < base {
+		return ForeignFail()
+	}
+
+	position := i.Int64() - base
+	t := list
+	for n := int64(0); ; n++ {
+		switch {
+		case term.IsVariable(t):
+			rest := term.NewCallable(".", elem, term.NewVar("_"))
+			return ForeignUnify(t, newVarList(position-n, rest))
+		case !isListCell(t):
+			return ForeignFail()
+		case n == position:
+			return ForeignUnify(elem, t.(term.Callable).Arguments()[0])
+		}
+		t = t.(term.Callable).Arguments()[1]
+	}
+}
+
+// reverse(?List, ?Reversed) is semidet.
+//
+// Reversed has the same elements as List but in the opposite order.
+func BuiltinReverse2(m Machine, args []term.Term) ForeignReturn {
+	var reversed term.Term = term.NewAtom("[]")
+	t := args[0]
+	for isListCell(t) {
+		cell := t.(term.Callable).Arguments()
+		reversed = term.NewCallable(".", cell[0], reversed)
+		t = cell[1]
+	}
+	switch {
+	case term.IsEmptyList(t):
+		return ForeignUnify(args[1], reversed)
+	case term.IsVariable(t):
+		goal := term.NewCallable("$reverse", args[0], term.NewAtom("[]"), args[1], args[1])
+		return m.PushConj(goal)
+	}
+	return ForeignFail()
+}
+
+// sum_list(+List, -Sum) is det.
+//
+// Sum is the sum of the numbers in List.
+func BuiltinSumList2(m Machine, args []term.Term) ForeignReturn {
+	flags := m.(*machine).arithmeticFlags()
+	var sum term.Number = term.NewInt64(0)
+	for _, x := range mustProperList(args[0]) {
+		n, err := term.ArithmeticEvalWithFlags(x, flags)
+		MaybePanic(err)
+		sum, err = term.ArithmeticAdd(sum, n)
+		MaybePanic(err)
+	}
+	return ForeignUnify(args[1], sum)
+}
+
+// max_list(+List, -Max) is semidet.
+//
+// Max is the largest number in List.  Fails if List is empty.
+func BuiltinMaxList2(m Machine, args []term.Term) ForeignReturn {
+	return extremeOfList(m, args, 1)
+}
+
+// min_list(+List, -Min) is semidet.
+//
+// Min is the smallest number in List.  Fails if List is empty.
+func BuiltinMinList2(m Machine, args []term.Term) ForeignReturn {
+	return extremeOfList(m, args, -1)
+}
+
+// extremeOfList implements max_list/2 (sign 1) and min_list/2 (sign -1)
+func extremeOfList(m Machine, args []term.Term, sign int) ForeignReturn {
+	flags := m.(*machine).arithmeticFlags()
+	var best term.Number
+	for _, x := range mustProperList(args[0]) {
+		n, err := term.ArithmeticEvalWithFlags(x, flags)
+		MaybePanic(err)
+		if best == nil || sign*term.NumberCmp(n, best) > 0 {
+			best = n
+		}
+	}
+	if best == nil {
+		return ForeignFail()
+	}
+	return ForeignUnify(args[1], best)
+}
+
+// numlist(+Low, +High, -List) is semidet.
+//
+// List holds the integers from Low to High, inclusive.  Fails if High
+// is less than Low.
+func BuiltinNumlist3(m Machine, args []term.Term) ForeignReturn {
+	for _, t := range args[:2] {
+		if term.IsVariable(t) {
+			panic(term.InstantiationError())
+		}
+		if !term.IsInteger(t) {
+			panic(term.TypeError("integer", t))
+		}
+	}
+	low := args[0].(*term.Integer).Value()
+	high := args[1].(*term.Integer).Value()
+	if high.Cmp(low) < 0 {
+		return ForeignFail()
+	}
+
+	var list term.Term = term.NewAtom("[]")
+	one := big.NewInt(1)
+	for i := new(big.Int).Set(high); i.Cmp(low) >= 0; i.Sub(i, one) {
+		list = term.NewCallable(".", term.NewBigInt(new(big.Int).Set(i)), list)
+	}
+	return ForeignUnify(args[2], list)
+}
+
+// list_to_set(+List, -Set) is det.
+//
+// Set holds the elements of List without duplicates.  Elements are
+// duplicates if they're identical (==).  The first occurrence of each
+// element is kept, so Set retains the order of List.
+func BuiltinListToSet2(m Machine, args []term.Term) ForeignReturn {
+	elements := mustProperList(args[0])
+
+	// a stable sort puts the first occurrence of each element first
+	indices := make([]int, len(elements))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return term.Compare(elements[indices[i]], elements[indices[j]]) < 0
+	})
+	keep := make([]bool, len(elements))
+	for n, i := range indices {
+		if n == 0 || term.Compare(elements[indices[n-1]], elements[i]) != 0 {
+			keep[i] = true
+		}
+	}
+
+	set := make([]term.Term, 0, len(elements))
+	for i, x := range elements {
+		if keep[i] {
+			set = append(set, x)
+		}
+	}
+	return ForeignUnify(args[1], term.NewTermList(set))
+}
+
+// is_ordset(@Term) is semidet.
+//
+// True if Term is a proper list whose elements are in strictly
+// ascending standard order.
+func BuiltinIsOrdset1(m Machine, args []term.Term) ForeignReturn {
+	var prev term.Term
+	ordering := m.(*machine).standardOrder()
+	t := args[0]
+	for isListCell(t) {
+		cell := t.(term.Callable).Arguments()
+		if prev != nil && ordering.Compare(prev, cell[0]) >= 0 {
+			return ForeignFail()
+		}
+		prev = cell[0]
+		t = cell[1]
+	}
+	if term.IsEmptyList(t) {
+		return ForeignTrue()
+	}
+	return ForeignFail()
+}
+
+// ord_memberchk(+Elem, +Set) is semidet.
+//
+// True if Elem is identical to an element of the ordered set Set.
+func BuiltinOrdMemberchk2(m Machine, args []term.Term) ForeignReturn {
+	ordering := m.(*machine).standardOrder()
+	for _, x := range mustProperList(args[1]) {
+		switch ordering.Compare(args[0], x) {
+		case 0:
+			return ForeignTrue()
+		case -1:
+			return ForeignFail()
+		}
+	}
+	return ForeignFail()
+}
+
+// ord_subset(+Sub, +Set) is semidet.
+//
+// True if every element of the ordered set Sub is in Set.
+func BuiltinOrdSubset2(m Machine, args []term.Term) ForeignReturn {
+	sub := mustProperList(args[0])
+	set := mustProperList(args[1])
+	if len(ordMerge(m, sub, set, true, false, false)) == 0 {
+		return ForeignTrue()
+	}
+	return ForeignFail()
+}
+
+// ord_union(+Set1, +Set2, -Union) is det.
+//
+// Union holds the elements of both ordered sets.
+func BuiltinOrdUnion3(m Machine, args []term.Term) ForeignReturn {
+	return ordSetOperation(m, args, true, true, true)
+}
+
+// ord_intersection(+Set1, +Set2, -Intersection) is det.
+//
+// Intersection holds the elements which are in both ordered sets.
+func BuiltinOrdIntersection3(m Machine, args []term.Term) ForeignReturn {
+	return ordSetOperation(m, args, false, true, false)
+}
+
+// ord_subtract(+Set1, +Set2, -Difference) is det.
+//
+// Difference holds the elements of Set1 which aren't in Set2.
+func BuiltinOrdSubtract3(m Machine, args []term.Term) ForeignReturn {
+	return ordSetOperation(m, args, true, false, false)
+}
+
+// ord_symdiff(+Set1, +Set2, -Difference) is det.
+//
+// Difference holds the elements which are in exactly one of the
+// ordered sets.
+func BuiltinOrdSymdiff3(m Machine, args []term.Term) ForeignReturn {
+	return ordSetOperation(m, args, true, false, true)
+}
+
+// ordSetOperation unifies args[2] with the result of merging the
+// ordered sets in args[0] and args[1].  See ordMerge.
+func ordSetOperation(m Machine, args []term.Term, onlyA, both, onlyB bool) ForeignReturn {
+	a := mustProperList(args[0])
+	b := mustProperList(args[1])
+	return ForeignUnify(args[2], term.NewTermList(ordMerge(m, a, b, onlyA, both, onlyB)))
+}
+
+// ordMerge merges two ordered sets.  It keeps elements found only in a,
+// in both sets or only in b according to the flags.
+func ordMerge(m Machine, a, b []term.Term, onlyA, both, onlyB bool) []term.Term {
+	ordering := m.(*machine).standardOrder()
+	var merged []term.Term
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch ordering.Compare(a[i], b[j]) {
+		case -1:
+			if onlyA {
+				merged = append(merged, a[i])
+			}
+			i++
+		case 0:
+			if both {
+				merged = append(merged, a[i])
+			}
+			i++
+			j++
+		case 1:
+			if onlyB {
+				merged = append(merged, b[j])
+			}
+			j++
+		}
+	}
+	if onlyA {
+		merged = append(merged, a[i:]...)
+	}
+	if onlyB {
+		merged = append(merged, b[j:]...)
+	}
+	return merged
+}
+
+// is_dict(@Term) is semidet.
+//
+// True if Term is a dict.
+func BuiltinIsDict1(m Machine, args []term.Term) ForeignReturn {
+	if term.IsDict(args[0]) {
+		return ForeignTrue()
+	}
+	return ForeignFail()
+}
+
+// is_dict(@Term, -Tag) is semidet.
+//
+// True if Term is a dict whose tag unifies with Tag.
+func BuiltinIsDict2(m Machine, args []term.Term) ForeignReturn {
+	if !term.IsDict(args[0]) {
+		return ForeignFail()
+	}
+	return ForeignUnify(args[1], args[0].(*term.Dict).Tag())
+}
+
+// get_dict(?Key, +Dict, ?Value) is nondet.
+//
+// True if Key in Dict is associated with Value.  If Key is unbound,
+// enumerates each pair on backtracking in standard order of keys.
+func BuiltinGetDict3(m Machine, args []term.Term) ForeignReturn {
+	d := mustDict(args[1])
+	key := args[0]
+	if term.IsVariable(key) {
+		keys := d.Keys()
+		alternatives := make([][]term.Term, len(keys))
+		for i, k := range keys {
+			v, _ := d.Get(k)
+			alternatives[i] = []term.Term{key, k, args[2], v}
+		}
+		return unifyAlternatives(m, alternatives)
+	}
+
+	mustDictKey(key)
+	if value, ok := d.Get(key); ok {
+		return ForeignUnify(args[2], value)
+	}
+	return ForeignFail()
+}
+
+// put_dict(+New, +DictIn, -DictOut) is det.
+//
+// DictOut is DictIn with the pairs from New added or replaced.  New is
+// either a dict or a list of pairs as accepted by dict_create/3.
+func BuiltinPutDict3(m Machine, args []term.Term) ForeignReturn {
+	d := mustDict(args[1])
+	if term.IsDict(args[0]) {
+		n := args[0].(*term.Dict)
+		for _, key := range n.Keys() {
+			value, _ := n.Get(key)
+			d = d.Put(key, value)
+		}
+	} else {
+		keys, values := mustDictPairs(args[0])
+		for i, key := range keys {
+			d = d.Put(key, values[i])
+		}
+	}
+	return ForeignUnify(args[2], d)
+}
+
+// put_dict(+Key, +DictIn, +Value, -DictOut) is det.
+//
+// DictOut is DictIn with Key associated with Value.
+func BuiltinPutDict4(m Machine, args []term.Term) ForeignReturn {
+	d := mustDict(args[1])
+	mustDictKey(args[0])
+	return ForeignUnify(args[3], d.Put(args[0], args[2]))
+}
+
+// del_dict(+Key, +DictIn, ?Value, -DictOut) is semidet.
+//
+// DictOut is DictIn without Key, whose value unifies with Value.
+// Fails if Key isn't in DictIn.
+func BuiltinDelDict4(m Machine, args []term.Term) ForeignReturn {
+	d := mustDict(args[1])
+	mustDictKey(args[0])
+	value, ok := d.Get(args[0])
+	if !ok {
+		return ForeignFail()
+	}
+	return ForeignUnify(args[2], value, args[3], d.Delete(args[0]))
+}
+
+// dict_pairs(?Dict, ?Tag, ?Pairs) is det.
+//
+// Converts between a dict and its tag plus a list of Key-Value pairs
+// ordered by key.
+func BuiltinDictPairs3(m Machine, args []term.Term) ForeignReturn {
+	if term.IsVariable(args[0]) {
+		return ForeignUnify(args[0], newDict(args[1], args[2]))
+	}
+
+	d := mustDict(args[0])
+	keys := d.Keys()
+	pairs := make([]term.Term, len(keys))
+	for i, key := range keys {
+		value, _ := d.Get(key)
+		pairs[i] = term.NewCallable("-", key, value)
+	}
+	return ForeignUnify(args[1], d.Tag(), args[2], term.NewTermList(pairs))
+}
+
+// dict_create(-Dict, +Tag, +Pairs) is det.
+//
+// Dict has tag Tag and the pairs in Pairs, which may be written as
+// Key-Value, Key=Value or Key(Value).
+func BuiltinDictCreate3(m Machine, args []term.Term) ForeignReturn {
+	return ForeignUnify(args[0], newDict(args[1], args[2]))
+}
+
+// newDict builds a dict from a tag and a list of pairs.  Panics with a
+// duplicate_key error if a key appears more than once.
+func newDict(tag, pairs term.Term) *term.Dict {
+	d := term.NewDict(tag)
+	keys, values := mustDictPairs(pairs)
+	for i, key := range keys {
+		if _, ok := d.Get(key); ok {
+			panic(term.DuplicateKeyError(key))
+		}
+		d = d.Put(key, values[i])
+	}
+	return d
+}
+
+// mustDict returns t as a dict.  Panics with an ISO error if it isn't
+// one.
+func mustDict(t term.Term) *term.Dict {
+	if term.IsVariable(t) {
+		panic(term.InstantiationError())
+	}
+	if !term.IsDict(t) {
+		panic(term.TypeError("dict", t))
+	}
+	return t.(*term.Dict)
+}
+
+// mustDictKey panics with an ISO error unless t is a valid dict key
+func mustDictKey(t term.Term) {
+	if term.IsVariable(t) {
+		panic(term.InstantiationError())
+	}
+	if !term.IsDictKey(t) {
+		panic(term.TypeError("dict_key", t))
+	}
+}
+
+// mustDictPairs returns the keys and values from a list of pairs
+// written as Key-Value, Key=Value or Key(Value)
+func mustDictPairs(list term.Term) ([]term.Term, []term.Term) {
+	elements := mustProperList(list)
+	keys := make([]term.Term, len(elements))
+	values := make([]term.Term, len(elements))
+	for i, pair := range elements {
+		if term.IsVariable(pair) {
+			panic(term.InstantiationError())
+		}
+		if !term.IsCompound(pair) {
+			panic(term.TypeError("pair", pair))
+		}
+		c := pair.(*term.Compound)
+		switch {
+		case c.Arity() == 2 && (c.Name() == "-" || c.Name() == "="):
+			keys[i], values[i] = c.Arguments()[0], c.Arguments()[1]
+		case c.Arity() == 1:
+			keys[i], values[i] = term.NewAtom(c.Name()), c.Arguments()[0]
+		default:
+			panic(term.TypeError("pair", pair))
+		}
+		mustDictKey(keys[i])
+	}
+	return keys, values
+}
+
+// isListCell returns true if t is a compound term like [_|_]
+func isListCell(t term.Term) bool {
+	return term.IsCompound(t) && t.(term.Callable).Indicator() == "./2"
+}
+
+// newVarList returns a list of n fresh variables followed by tail
+func newVarList(n int64, tail term.Term) term.Term {
+	list := tail
+	for i := int64(0); i < n; i++ {
+		list = term.NewCallable(".", term.NewVar("_"), list)
+	}
+	return list
+}
+
+// mustProperList returns the elements of a proper list.  Panics with
+// an instantiation error for partial lists and a type error for
+// anything else which isn't a list.
+func mustProperList(list term.Term) []term.Term {
+	var elements []term.Term
+	t := list
+	for {
+		switch {
+		case term.IsVariable(t):
+			panic(term.InstantiationError())
+		case term.IsEmptyList(t):
+			return elements
+		case term.IsCompound(t) && t.(term.Callable).Indicator() == "./2":
+			args := t.(term.Callable).Arguments()
+			elements = append(elements, args[0])
+			t = args[1]
+		default:
+			panic(term.TypeError("list", list))
+		}
+	}
+}
+
+// mustPartialList panics with a type error unless list is a list or
+// a partial list
+func mustPartialList(list term.Term) {
+	t := list
+	for {
+		switch {
+		case term.IsVariable(t), term.IsEmptyList(t):
+			return
+		case term.IsCompound(t) && t.(term.Callable).Indicator() == "./2":
+			t = t.(term.Callable).Arguments()[1]
+		default:
+			panic(term.TypeError("list", list))
+		}
+	}
+}
+
+// compare(?Order, @A, @B) see ISO §8.4.2
+//
+// Order is one of <, = or > depending on whether A precedes, is
+// identical to or follows B in the standard order of terms.
+func BuiltinCompare3(m Machine, args []term.Term) ForeignReturn {
+	order := args[0]
+	if !term.IsVariable(order) {
+		if !term.IsAtom(order) {
+			panic(term.TypeError("atom", order))
+		}
+		switch order.(*term.Atom).Name() {
+		case "<", "=", ">":
+		default:
+			panic(term.DomainError("order", order))
+		}
+	}
+
+	var o string
+	switch m.(*machine).standardOrder().Compare(args[1], args[2]) {
+	case -1:
+		o = "<"
+	case 0:
+		o = "="
+	case 1:
+		o = ">"
+	}
+	return ForeignUnify(order, term.NewAtom(o))
+}
+
+// succ(?A:integer, ?B:integer) is det.
+//
+// True if B is one greater than A and A >= 0.
+func BuiltinSucc2(m Machine, args []term.Term) ForeignReturn {
+	x := args[0]
+	y := args[1]
+	zero := big.NewInt(0)
+
+	if term.IsInteger(x) {
+		a := x.(*term.Integer)
+		if a.Value().Cmp(zero) < 0 {
+			panic("succ/2: first argument must be 0 or greater")
+		}
+		result := new(big.Int).Add(a.Value(), big.NewInt(1))
+		return ForeignUnify(y, term.NewBigInt(result))
+	} else if term.IsInteger(y) {
+		b := y.(*term.Integer)
+		result := new(big.Int).Add(b.Value(), big.NewInt(-1))
+		if result.Cmp(zero) < 0 {
+			panic("succ/2: first argument must be 0 or greater")
+		}
+		return ForeignUnify(x, term.NewBigInt(result))
+	}
+
+	panic("succ/2: one argument must be an integer")
+}
+
+// throw(+Ball) see ISO §7.8.10
+//
+// Raises an exception which can be caught by catch/3
+func BuiltinThrow1(m Machine, args []term.Term) ForeignReturn {
+	if term.IsVariable(args[0]) {
+		panic(term.InstantiationError())
+	}
+	panic(term.NewException(args[0]))
+}
+
+// var(?X) is semidet.
+//
+// True if X is a variable.
+func BuiltinVar1(m Machine, args []term.Term) ForeignReturn {
+	x := args[0]
+
+	if term.IsVariable(x) {
+		return ForeignTrue()
+	}
+	return ForeignFail()
+}
+
+// functor(?Term, ?Name, ?Arity) see ISO §8.5.1
+//
+// True if Term has the given Name and Arity.  If Term is a variable,
+// it's unified with a new term whose arguments are all fresh variables.
+func BuiltinFunctor3(m Machine, args []term.Term) ForeignReturn {
+	t, name, arity := args[0], args[1], args[2]
+	switch {
+	case term.IsCompound(t):
+		x := t.(*term.Compound)
+		n := term.NewInt64(int64(x.Arity()))
+		return ForeignUnify(name, term.NewAtom(x.Name()), arity, n)
+	case !term.IsVariable(t):
+		return ForeignUnify(name, t, arity, term.NewInt64(0))
+	}
+
+	// construct a new term
+	if term.IsVariable(name) || term.IsVariable(arity) {
+		panic(term.InstantiationError())
+	}
+	if !term.IsInteger(arity) {
+		panic(term.TypeError("integer", arity))
+	}
+	a := arity.(*term.Integer).Value()
+	if a.Sign() < 0 {
+		panic(term.DomainError("not_less_than_zero", arity))
+	}
+	if !term.IsAtomic(name) {
+		panic(term.TypeError("atomic", name))
+	}
+	if a.Sign() == 0 {
+		return ForeignUnify(t, name)
+	}
+	if !term.IsAtom(name) {
+		panic(term.TypeError("atom", name))
+	}
+	if !a.IsInt64() || a.Int64() > maxArity {
+		panic(term.RepresentationError("max_arity"))
+	}
+	vars := make([]term.Term, a.Int64())
+	for i := range vars {
+		vars[i] = term.NewVar("_")
+	}
+	f := term.NewCallable(name.(*term.Atom).Name(), vars...)
+	return ForeignUnify(t, f)
+}
+
+// the largest arity functor/3 and =../2 will construct
+const maxArity = 1 << 20
+
+// arg(?N, +Term, ?Arg) see ISO §8.5.2
+//
+// True if Arg is the N-th argument of Term, counting from 1.  If N
+// is unbound, enumerates each argument on backtracking.
+func BuiltinArg3(m Machine, args []term.Term) ForeignReturn {
+	n, t, arg := args[0], args[1], args[2]
+	if term.IsVariable(t) {
+		panic(term.InstantiationError())
+	}
+	if !term.IsCompound(t) {
+		panic(term.TypeError("compound", t))
+	}
+	targs := t.(*term.Compound).Arguments()
+
+	if term.IsVariable(n) {
+		alternatives := make([][]term.Term, len(targs))
+		for i, a := range targs {
+			alternatives[i] = []term.Term{n, term.NewInt64(int64(i + 1)), arg, a}
+		}
+		return unifyAlternatives(m, alternatives)
+	}
+	if !term.IsInteger(n) {
+		panic(term.TypeError("integer", n))
+	}
+	i := n.(*term.Integer).Value()
+	if i.Sign() <= 0 || !i.IsInt64() || i.Int64() > int64(len(targs)) {
+		return ForeignFail()
+	}
+	return ForeignUnify(arg, targs[i.Int64()-1])
+}
+
+// =..(?Term, ?List) see ISO §8.5.3
+//
+// True if List is a list whose head is the name of Term and whose
+// tail is Term's arguments.
+func BuiltinUniv2(m Machine, args []term.Term) ForeignReturn {
+	t, list := args[0], args[1]
+	switch {
+	case term.IsCompound(t):
+		univ := t.(*term.Compound).Univ()
+		return ForeignUnify(list, term.NewTermList(univ))
+	case !term.IsVariable(t):
+		return ForeignUnify(list, term.NewTermList([]term.Term{t}))
+	}
+
+	// construct a term from the list
+	parts := mustProperList(list)
+	if len(parts) == 0 {
+		panic(term.DomainError("non_empty_list", list))
+	}
+	name := parts[0]
+	if term.IsVariable(name) {
+		panic(term.InstantiationError())
+	}
+	if len(parts) == 1 {
+		if !term.IsAtomic(name) {
+			panic(term.TypeError("atomic", name))
+		}
+		return ForeignUnify(t, name)
+	}
+	if term.IsCompound(name) {
+		panic(term.TypeError("atomic", name))
+	}
+	if !term.IsAtom(name) {
+		panic(term.TypeError("atom", name))
+	}
+	if len(parts)-1 > maxArity {
+		panic(term.RepresentationError("max_arity"))
+	}
+	f := term.NewCallable(name.(*term.Atom).Name(), parts[1:]...)
+	return ForeignUnify(t, f)
+}
+
+// copy_term(+Term, -Copy) see ISO §8.5.4
+//
+// Copy is a renamed copy of Term with fresh variables.
+func BuiltinCopyTerm2(m Machine, args []term.Term) ForeignReturn {
+	return ForeignUnify(args[1], term.CopyTerm(args[0]))
+}
+
+// term_variables(+Term, -Vars) see ISO §8.5.5
+//
+// Vars is a list of the distinct variables in Term, in depth-first,
+// left-to-right order.
+func BuiltinTermVariables2(m Machine, args []term.Term) ForeignReturn {
+	mustPartialList(args[1])
+	vars := term.TermVariables(args[0])
+	ts := make([]term.Term, len(vars))
+	for i, v := range vars {
+		ts[i] = v
+	}
+	return ForeignUnify(args[1], term.NewTermList(ts))
+}
+
+// setarg(+N, +Term, +Value) is semidet.
+//
+// Replaces the N-th argument of Term with Value.  The change is kept in
+// the machine's bindings, like a variable binding, so backtracking
+// undoes it.  Term itself isn't modified, so solutions found before the
+// change keep the old argument.  Fails if Term has no N-th argument.
+func BuiltinSetarg3(m Machine, args []term.Term) ForeignReturn {
+	c, i := setargTarget(m, args)
+	if c == nil {
+		return ForeignFail()
+	}
+	return m.SetBindings(m.Bindings().SetArg(c, i, args[2]))
+}
+
+// nb_setarg(+N, +Term, +Value) is semidet.
+//
+// Like setarg/3 but the change survives backtracking.  Term's N-th
+// argument becomes a copy of Value.
+func BuiltinNbSetarg3(m Machine, args []term.Term) ForeignReturn {
+	c, i := setargTarget(m, args)
+	if c == nil {
+		return ForeignFail()
+	}
+	value := args[2].ReplaceVariables(m.Bindings())
+	c.SetArg(i, term.CopyTerm(value))
 	return ForeignTrue()
 }
 
-// msort(+Unsorted:list, -Sorted:list) is det.
+// setargTarget checks the arguments of setarg/3 and nb_setarg/3.  It
+// returns the compound term to change and the index of the argument
+// being changed.  Returns nil if there's no such argument.
 //
-// True if Sorted is a sorted version of Unsorted.  Duplicates are
-// not removed.
+// These predicates receive dereferenced arguments so that they find the
+// same compound term to which the caller's variable is bound, not a
+// copy of it.
+func setargTarget(m Machine, args []term.Term) (*term.Compound, int) {
+	n := args[0].ReplaceVariables(m.Bindings())
+	t := args[1]
+	if term.IsVariable(n) || term.IsVariable(t) {
+		panic(term.InstantiationError())
+	}
+	if !term.IsInteger(n) {
+		panic(term.TypeError("integer", n))
+	}
+	if !term.IsCompound(t) {
+		panic(term.TypeError("compound", t))
+	}
+
+	c := t.(*term.Compound)
+	i := n.(*term.Integer).Value()
+	if i.Sign() <= 0 || !i.IsInt64() || i.Int64() > int64(c.Arity()) {
+		return nil, 0
+	}
+	return c, int(i.Int64()) - 1
+}
+
+// b_setval(+Name, +Value) is det.
 //
-// This is currently implemented using Go's sort.Sort.
-// The exact implementation is subject to change.  I make no
-// guarantees about sort stability.
-func BuiltinMsort2(m Machine, args []term.Term) ForeignReturn {
-	terms := term.ProperListToTermSlice(args[0])
-	sort.Sort((*term.TermSlice)(&terms))
-	list := term.NewTermList(terms)
-	return ForeignUnify(args[1], list)
-}
-
-// A temporary hack for debugging.  This will disappear once Golog has
-// proper support for format/2
-func BuiltinPrintf(m Machine, args []term.Term) ForeignReturn {
-	template := args[0].(*term.Atom).Name()
-	template = strings.Replace(template, "~n", "\n", -1)
-	if len(args) == 1 {
-		fmt.Printf(template)
-	} else if len(args) == 2 {
-		fmt.Printf(template, args[1])
+// Associates Value with the global variable Name.  The association is
+// undone on backtracking.
+func BuiltinBSetval2(m Machine, args []term.Term) ForeignReturn {
+	name := mustAtom(args[0])
+	return m.(*machine).setGlobal(name, args[1], true)
+}
+
+// b_getval(+Name, -Value) is det.
+//
+// Value is the current value of the global variable Name.  Raises an
+// existence error if Name has no value.
+func BuiltinBGetval2(m Machine, args []term.Term) ForeignReturn {
+	name := mustAtom(args[0])
+	value, ok := m.(*machine).global(name)
+	if !ok {
+		panic(term.ExistenceError("variable", args[0]))
+	}
+	return ForeignUnify(args[1], value)
+}
+
+// nb_setval(+Name, +Value) is det.
+//
+// Associates a copy of Value with the global variable Name.  The
+// association survives backtracking.
+func BuiltinNbSetval2(m Machine, args []term.Term) ForeignReturn {
+	name := mustAtom(args[0])
+	return m.(*machine).setGlobal(name, term.CopyTerm(args[1]), false)
+}
+
+// nb_getval(+Name, -Value) is det.
+//
+// Same as b_getval/2.
+func BuiltinNbGetval2(m Machine, args []term.Term) ForeignReturn {
+	return BuiltinBGetval2(m, args)
+}
+
+// $distinct/2
+//
+// An internal system predicate which might be removed at any time
+// in the future.  $distinct(State, Witness) succeeds the first time
+// a variant of Witness is seen.  State is a compound term whose first
+// argument holds the witnesses seen so far.  Like nb_setarg/3, the
+// change to State survives backtracking.
+func BuiltinDistinct2(m Machine, args []term.Term) ForeignReturn {
+	state, ok := args[0].(*term.Compound)
+	if !ok || state.Arity() < 1 {
+		panic(term.TypeError("compound", args[0]))
 	}
+	witness := term.CopyTerm(resolveIn(m.Bindings(), args[1]))
+
+	// witnesses are grouped by VariantKey in a dict whose values are
+	// lists of the witnesses which share a key
+	seen, ok := state.Arguments()[0].(*term.Dict)
+	if !ok {
+		seen = term.NewDict(term.NewAtom("seen"))
+	}
+	key := term.NewAtom(term.VariantKey(witness))
+	var same term.Term = term.NewAtom("[]")
+	if ws, ok := seen.Get(key); ok {
+		for _, w := range term.ProperListToTermSlice(ws) {
+			if term.IsVariant(w, witness) {
+				return ForeignFail()
+			}
+		}
+		same = ws
+	}
+	state.SetArg(0, seen.Put(key, term.NewCallable(".", witness, same)))
 	return ForeignTrue()
 }
 
-// succ(?A:integer, ?B:integer) is det.
+// gensym(+Base, -Unique) is det.
 //
-// True if B is one greater than A and A >= 0.
-func BuiltinSucc2(m Machine, args []term.Term) ForeignReturn {
-	x := args[0]
-	y := args[1]
-	zero := big.NewInt(0)
+// Unique is a new atom made of Base followed by a number.  Each call
+// with the same Base uses the next number, starting from 1.  The
+// numbers aren't reused on backtracking.
+func BuiltinGensym2(m Machine, args []term.Term) ForeignReturn {
+	base := mustAtom(args[0])
+	n, m1 := m.(*machine).gensym(base)
+	unique := term.NewAtom(base + strconv.FormatInt(n, 10))
+	return m1.PushConj(term.NewCallable("=", args[1], unique))
+}
 
-	if term.IsInteger(x) {
-		a := x.(*term.Integer)
-		if a.Value().Cmp(zero) < 0 {
-			panic("succ/2: first argument must be 0 or greater")
+// reset_gensym(+Base) is det.
+//
+// Restarts the numbering of gensym/2 for Base.
+func BuiltinResetGensym1(m Machine, args []term.Term) ForeignReturn {
+	base := mustAtom(args[0])
+	return m.(*machine).resetGensym(base)
+}
+
+// open(+SourceSink, +Mode, -Stream) see ISO §8.11.5
+//
+// Like open/4 with no options.
+func BuiltinOpen3(m Machine, args []term.Term) ForeignReturn {
+	return BuiltinOpen4(m, append(args, term.NewAtom("[]")))
+}
+
+// open(+SourceSink, +Mode, -Stream, +Options) see ISO §8.11.5
+//
+// Opens the file SourceSink for reading, writing or appending depending
+// on Mode.  Stream is unified with a term representing the new stream.
+// Options are type(text|binary), alias(A), eof_action(error|eof_code|reset)
+// and reposition(false).
+func BuiltinOpen4(m Machine, args []term.Term) ForeignReturn {
+	source, mode, stream, options := args[0], args[1], args[2], args[3]
+	if term.IsVariable(source) || term.IsVariable(mode) {
+		panic(term.InstantiationError())
+	}
+	if !term.IsAtom(mode) {
+		panic(term.TypeError("atom", mode))
+	}
+	if !term.IsVariable(stream) {
+		panic(term.UninstantiationError(stream))
+	}
+	var name string
+	switch {
+	case term.IsAtom(source):
+		name = source.(*term.Atom).Name()
+	case term.IsString(source):
+		name = source.(*term.String).Text()
+	default:
+		panic(term.DomainError("source_sink", source))
+	}
+
+	var binary bool
+	var alias string
+	eofAction := "error"
+	for _, option := range mustProperList(options) {
+		if term.IsVariable(option) {
+			panic(term.InstantiationError())
 		}
-		result := new(big.Int).Add(a.Value(), big.NewInt(1))
-		return ForeignUnify(y, term.NewBigInt(result))
-	} else if term.IsInteger(y) {
-		b := y.(*term.Integer)
-		result := new(big.Int).Add(b.Value(), big.NewInt(-1))
-		if result.Cmp(zero) < 0 {
-			panic("succ/2: first argument must be 0 or greater")
+		var value term.Term
+		if term.IsCompound(option) && option.(*term.Compound).Arity() == 1 {
+			value = option.(*term.Compound).Arguments()[0]
+			if term.IsVariable(value) {
+				panic(term.InstantiationError())
+			}
+		}
+		switch {
+		case option.Indicator() == "type/1" && isAtomIn(value, "text", "binary"):
+			binary = value.(*term.Atom).Name() == "binary"
+		case option.Indicator() == "alias/1" && term.IsAtom(value):
+			alias = value.(*term.Atom).Name()
+			if _, ok := m.(*machine).aliases.Lookup(alias); ok {
+				panic(term.PermissionError("open", "source_sink", option))
+			}
+		case option.Indicator() == "eof_action/1" && isAtomIn(value, "error", "eof_code", "reset"):
+			eofAction = value.(*term.Atom).Name()
+		case option.Indicator() == "reposition/1" && isAtomIn(value, "false"):
+			// streams can't be repositioned
+		case option.Indicator() == "reposition/1" && isAtomIn(value, "true"):
+			panic(term.PermissionError("open", "source_sink", option))
+		default:
+			panic(term.DomainError("stream_option", option))
 		}
-		return ForeignUnify(x, term.NewBigInt(result))
 	}
 
-	panic("succ/2: one argument must be an integer")
+	s := openFile(source, name, mode)
+	s.binary = binary
+	s.eofAction = eofAction
+	m1 := m.(*machine).addStream(s, alias)
+	return m1.PushConj(term.NewCallable("=", stream, s.Term()))
 }
 
-// var(?X) is semidet.
+// isAtomIn returns true if t is an atom with one of the given names
+func isAtomIn(t term.Term, names ...string) bool {
+	if !term.IsAtom(t) {
+		return false
+	}
+	for _, name := range names {
+		if t.(*term.Atom).Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// close(+Stream) see ISO §8.11.6
 //
-// True if X is a variable.
-func BuiltinVar1(m Machine, args []term.Term) ForeignReturn {
-	x := args[0]
+// Like close/2 with no options.
+func BuiltinClose1(m Machine, args []term.Term) ForeignReturn {
+	return BuiltinClose2(m, append(args, term.NewAtom("[]")))
+}
 
-	if term.IsVariable(x) {
+// close(+Stream, +Options) see ISO §8.11.6
+//
+// Closes Stream.  Closing a standard stream does nothing.  If Stream
+// was the current input or output, user_input or user_output takes
+// its place.  The only option is force(Boolean).  With force(true),
+// errors from the underlying file are ignored.
+func BuiltinClose2(m Machine, args []term.Term) ForeignReturn {
+	s := mustStream(m, args[0])
+	force := false
+	for _, option := range mustProperList(args[1]) {
+		if term.IsVariable(option) {
+			panic(term.InstantiationError())
+		}
+		if !term.IsCompound(option) || option.Indicator() != "force/1" {
+			panic(term.DomainError("close_option", option))
+		}
+		value := option.(*term.Compound).Arguments()[0]
+		if term.IsVariable(value) {
+			panic(term.InstantiationError())
+		}
+		if !isAtomIn(value, "true", "false") {
+			panic(term.DomainError("close_option", option))
+		}
+		force = value.(*term.Atom).Name() == "true"
+	}
+
+	if s.isStandard() {
+		return ForeignTrue()
+	}
+	m1 := m.(*machine).removeStream(s)
+	if s.closer != nil {
+		if err := s.closer.Close(); err != nil && !force {
+			panic(term.IOError("close", s.Term()))
+		}
+	}
+	return m1
+}
+
+// current_input(?Stream) see ISO §8.11.1
+//
+// True if Stream is the current input stream.
+func BuiltinCurrentInput1(m Machine, args []term.Term) ForeignReturn {
+	mustStreamOrVariable(args[0])
+	return ForeignUnify(args[0], m.(*machine).input.Term())
+}
+
+// current_output(?Stream) see ISO §8.11.2
+//
+// True if Stream is the current output stream.
+func BuiltinCurrentOutput1(m Machine, args []term.Term) ForeignReturn {
+	mustStreamOrVariable(args[0])
+	return ForeignUnify(args[0], m.(*machine).output.Term())
+}
+
+// mustStreamOrVariable panics with a domain error unless t is a
+// variable or a stream term
+func mustStreamOrVariable(t term.Term) {
+	if !term.IsVariable(t) && t.Indicator() != "$stream/1" {
+		panic(term.DomainError("stream", t))
+	}
+}
+
+// set_input(+Stream) see ISO §8.11.3
+//
+// Makes Stream the current input stream.
+func BuiltinSetInput1(m Machine, args []term.Term) ForeignReturn {
+	s := mustStream(m, args[0])
+	if !s.isInput() {
+		panic(term.PermissionError("input", "stream", args[0]))
+	}
+	m1 := m.(*machine).clone()
+	m1.input = s
+	return m1
+}
+
+// set_output(+Stream) see ISO §8.11.4
+//
+// Makes Stream the current output stream.
+func BuiltinSetOutput1(m Machine, args []term.Term) ForeignReturn {
+	s := mustStream(m, args[0])
+	if !s.isOutput() {
+		panic(term.PermissionError("output", "stream", args[0]))
+	}
+	m1 := m.(*machine).clone()
+	m1.output = s
+	return m1
+}
+
+// stream_property(?Stream, ?Property) see ISO §8.11.8
+//
+// True if Stream is an open stream with Property.  Properties are
+// file_name(F), mode(M), input, output, alias(A), end_of_stream(E),
+// eof_action(A), reposition(false) and type(T).
+func BuiltinStreamProperty2(m Machine, args []term.Term) ForeignReturn {
+	stream, prop := args[0], args[1]
+	mustStreamOrVariable(stream)
+	if !term.IsVariable(prop) && !isStreamProperty(prop) {
+		panic(term.DomainError("stream_property", prop))
+	}
+
+	m1 := m.(*machine)
+	streams := m1.openStreams()
+	if !term.IsVariable(stream) {
+		streams = []*Stream{mustStream(m, stream)}
+	}
+	var alternatives [][]term.Term
+	for _, s := range streams {
+		for _, p := range s.properties(m1.streamAliases(s)) {
+			alternatives = append(alternatives, []term.Term{stream, s.Term(), prop, p})
+		}
+	}
+	return unifyAlternatives(m, alternatives)
+}
+
+// isStreamProperty returns true if t might be a stream property
+func isStreamProperty(t term.Term) bool {
+	switch t.Indicator() {
+	case "input/0", "output/0", "file_name/1", "mode/1", "alias/1",
+		"end_of_stream/1", "eof_action/1", "reposition/1", "type/1":
+		return true
+	}
+	return false
+}
+
+// get_char(?Char) see ISO §8.12.1
+//
+// Like get_char/2 with the current input stream.
+func BuiltinGetChar1(m Machine, args []term.Term) ForeignReturn {
+	return BuiltinGetChar2(m, []term.Term{m.(*machine).input.Term(), args[0]})
+}
+
+// get_char(+Stream, ?Char) see ISO §8.12.1
+//
+// Reads the next character from a text stream.  Char is end_of_file at
+// the end of the stream.
+func BuiltinGetChar2(m Machine, args []term.Term) ForeignReturn {
+	return getChar(m, args, false)
+}
+
+// peek_char(?Char) see ISO §8.12.2
+//
+// Like peek_char/2 with the current input stream.
+func BuiltinPeekChar1(m Machine, args []term.Term) ForeignReturn {
+	return BuiltinPeekChar2(m, []term.Term{m.(*machine).input.Term(), args[0]})
+}
+
+// peek_char(+Stream, ?Char) see ISO §8.12.2
+//
+// Like get_char/2 but leaves the character on the stream.
+func BuiltinPeekChar2(m Machine, args []term.Term) ForeignReturn {
+	return getChar(m, args, true)
+}
+
+// getChar implements get_char/2 and peek_char/2
+func getChar(m Machine, args []term.Term, peek bool) ForeignReturn {
+	char := args[1]
+	if !term.IsVariable(char) && !isAtomIn(char, "end_of_file") {
+		if !term.IsAtom(char) || utf8.RuneCountInString(char.(*term.Atom).Name()) != 1 {
+			panic(term.TypeError("in_character", char))
+		}
+	}
+	s := mustInputStream(m, args[0], false)
+	c, ok := s.readRune(peek)
+	if !ok {
+		return ForeignUnify(char, term.NewAtom("end_of_file"))
+	}
+	return ForeignUnify(char, term.NewAtom(string(c)))
+}
+
+// put_char(+Char) see ISO §8.12.3
+//
+// Like put_char/2 with the current output stream.
+func BuiltinPutChar1(m Machine, args []term.Term) ForeignReturn {
+	return BuiltinPutChar2(m, []term.Term{m.(*machine).output.Term(), args[0]})
+}
+
+// put_char(+Stream, +Char) see ISO §8.12.3
+//
+// Writes a character to a text stream.
+func BuiltinPutChar2(m Machine, args []term.Term) ForeignReturn {
+	if term.IsVariable(args[1]) {
+		panic(term.InstantiationError())
+	}
+	s := mustOutputStream(m, args[0], false)
+	c := mustCharacter(args[1])
+	s.write([]byte(string(c)))
+	return ForeignTrue()
+}
+
+// get_byte(?Byte) see ISO §8.13.1
+//
+// Like get_byte/2 with the current input stream.
+func BuiltinGetByte1(m Machine, args []term.Term) ForeignReturn {
+	return BuiltinGetByte2(m, []term.Term{m.(*machine).input.Term(), args[0]})
+}
+
+// get_byte(+Stream, ?Byte) see ISO §8.13.1
+//
+// Reads the next byte from a binary stream.  Byte is -1 at the end of
+// the stream.
+func BuiltinGetByte2(m Machine, args []term.Term) ForeignReturn {
+	b := args[1]
+	if !term.IsVariable(b) && !isByte(b) && !isMinusOne(b) {
+		panic(term.TypeError("in_byte", b))
+	}
+	s := mustInputStream(m, args[0], true)
+	c, ok := s.readByte(false)
+	if !ok {
+		return ForeignUnify(b, term.NewInt64(-1))
+	}
+	return ForeignUnify(b, term.NewInt64(int64(c)))
+}
+
+// put_byte(+Byte) see ISO §8.13.3
+//
+// Like put_byte/2 with the current output stream.
+func BuiltinPutByte1(m Machine, args []term.Term) ForeignReturn {
+	return BuiltinPutByte2(m, []term.Term{m.(*machine).output.Term(), args[0]})
+}
+
+// put_byte(+Stream, +Byte) see ISO §8.13.3
+//
+// Writes a byte to a binary stream.
+func BuiltinPutByte2(m Machine, args []term.Term) ForeignReturn {
+	b := args[1]
+	if term.IsVariable(b) {
+		panic(term.InstantiationError())
+	}
+	s := mustOutputStream(m, args[0], true)
+	if !isByte(b) {
+		panic(term.TypeError("byte", b))
+	}
+	s.write([]byte{byte(b.(*term.Integer).Value().Int64())})
+	return ForeignTrue()
+}
+
+// isByte returns true if t is an integer between 0 and 255
+func isByte(t term.Term) bool {
+	if !term.IsInteger(t) {
+		return false
+	}
+	n := t.(*term.Integer).Value()
+	return n.IsInt64() && n.Int64() >= 0 && n.Int64() <= 255
+}
+
+// isMinusOne returns true if t is the integer -1
+func isMinusOne(t term.Term) bool {
+	return term.IsInteger(t) && t.(*term.Integer).Value().Cmp(big.NewInt(-1)) == 0
+}
+
+// nl see ISO §8.12.3
+//
+// Like nl/1 with the current output stream.
+func BuiltinNl0(m Machine, args []term.Term) ForeignReturn {
+	return BuiltinNl1(m, []term.Term{m.(*machine).output.Term()})
+}
+
+// nl(+Stream) see ISO §8.12.3
+//
+// Writes a newline to a text stream.
+func BuiltinNl1(m Machine, args []term.Term) ForeignReturn {
+	mustOutputStream(m, args[0], false).write([]byte("\n"))
+	return ForeignTrue()
+}
+
+// write(+Term) see ISO §8.14.2
+//
+// Like write/2 with the current output stream.
+func BuiltinWrite1(m Machine, args []term.Term) ForeignReturn {
+	return BuiltinWrite2(m, []term.Term{m.(*machine).output.Term(), args[0]})
+}
+
+// write(+Stream, +Term) see ISO §8.14.2
+//
+// Writes Term without quotes, using operators and writing '$VAR'(N)
+// terms as variable names.
+func BuiltinWrite2(m Machine, args []term.Term) ForeignReturn {
+	return writeWith(m, args[0], args[1], writeOptions{numbervars: true})
+}
+
+// writeq(+Term) see ISO §8.14.2
+//
+// Like writeq/2 with the current output stream.
+func BuiltinWriteq1(m Machine, args []term.Term) ForeignReturn {
+	return BuiltinWriteq2(m, []term.Term{m.(*machine).output.Term(), args[0]})
+}
+
+// writeq(+Stream, +Term) see ISO §8.14.2
+//
+// Like write/2 but quotes atoms and strings so that Term can be read
+// back.
+func BuiltinWriteq2(m Machine, args []term.Term) ForeignReturn {
+	return writeWith(m, args[0], args[1], writeOptions{quoted: true, numbervars: true})
+}
+
+// print(+Term) is det.
+//
+// Like print/2 with the current output stream.
+func BuiltinPrint1(m Machine, args []term.Term) ForeignReturn {
+	return BuiltinPrint2(m, []term.Term{m.(*machine).output.Term(), args[0]})
+}
+
+// print(+Stream, +Term) is det.
+//
+// Like writeq/2 but the user's portray/1 predicate may write any
+// subterm first.
+func BuiltinPrint2(m Machine, args []term.Term) ForeignReturn {
+	opts := writeOptions{quoted: true, numbervars: true, portray: true}
+	return writeWith(m, args[0], args[1], opts)
+}
+
+// write_canonical(+Term) see ISO §8.14.2
+//
+// Like write_canonical/2 with the current output stream.
+func BuiltinWriteCanonical1(m Machine, args []term.Term) ForeignReturn {
+	return BuiltinWriteCanonical2(m, []term.Term{m.(*machine).output.Term(), args[0]})
+}
+
+// write_canonical(+Stream, +Term) see ISO §8.14.2
+//
+// Writes Term quoted and in functional notation, ignoring operators.
+func BuiltinWriteCanonical2(m Machine, args []term.Term) ForeignReturn {
+	return writeWith(m, args[0], args[1], writeOptions{quoted: true, ignoreOps: true})
+}
+
+// write_term(+Term, +Options) see ISO §8.14.2
+//
+// Like write_term/3 with the current output stream.
+func BuiltinWriteTerm2(m Machine, args []term.Term) ForeignReturn {
+	return BuiltinWriteTerm3(m, []term.Term{m.(*machine).output.Term(), args[0], args[1]})
+}
+
+// write_term(+Stream, +Term, +Options) see ISO §8.14.2
+//
+// Writes Term as controlled by Options: quoted(Bool), ignore_ops(Bool),
+// numbervars(Bool), portray(Bool), max_depth(N) and
+// spacing(standard|next_argument).
+func BuiltinWriteTerm3(m Machine, args []term.Term) ForeignReturn {
+	s := mustOutputStream(m, args[0], false)
+	opts := mustWriteOptions(args[2])
+	m.(*machine).writeTerm(s, args[1], opts)
+	return ForeignTrue()
+}
+
+// writeWith writes t to a stream with the given options
+func writeWith(m Machine, stream, t term.Term, opts writeOptions) ForeignReturn {
+	s := mustOutputStream(m, stream, false)
+	m.(*machine).writeTerm(s, t, opts)
+	return ForeignTrue()
+}
+
+// numbervars(+Term, +Start, -End) is det.
+//
+// Binds each variable in Term to '$VAR'(N), numbering from Start in
+// depth-first, left-to-right order.  End is one more than the last
+// number used.
+func BuiltinNumbervars3(m Machine, args []term.Term) ForeignReturn {
+	start := args[1]
+	if term.IsVariable(start) {
+		panic(term.InstantiationError())
+	}
+	if !term.IsInteger(start) {
+		panic(term.TypeError("integer", start))
+	}
+	vars := term.TermVariables(args[0])
+	pairs := make([]term.Term, 0, 2*len(vars)+2)
+	n := new(big.Int).Set(start.(*term.Integer).Value())
+	for _, v := range vars {
+		pairs = append(pairs, v, term.NewCallable("$VAR", term.NewBigInt(new(big.Int).Set(n))))
+		n.Add(n, big.NewInt(1))
+	}
+	pairs = append(pairs, args[2], term.NewBigInt(n))
+	return ForeignUnify(pairs...)
+}
+
+// at_end_of_stream see ISO §8.11.8.5
+//
+// Like at_end_of_stream/1 with the current input stream.
+func BuiltinAtEndOfStream0(m Machine, args []term.Term) ForeignReturn {
+	return BuiltinAtEndOfStream1(m, []term.Term{m.(*machine).input.Term()})
+}
+
+// at_end_of_stream(+Stream) see ISO §8.11.8.5
+//
+// True if there's nothing more to read from Stream.  This may wait
+// for input to arrive.
+func BuiltinAtEndOfStream1(m Machine, args []term.Term) ForeignReturn {
+	s := mustStream(m, args[0])
+	if !s.isInput() {
+		panic(term.PermissionError("input", "stream", args[0]))
+	}
+	if s.pastEnd {
+		return ForeignTrue()
+	}
+	if _, err := s.reader.Peek(1); err == io.EOF {
 		return ForeignTrue()
 	}
 	return ForeignFail()
 }
+
+// format(+Format) is det.
+//
+// Like format/2 with no arguments.
+func BuiltinFormat1(m Machine, args []term.Term) ForeignReturn {
+	return BuiltinFormat2(m, append(args, term.NewAtom("[]")))
+}
+
+// format(+Format, :Arguments) is det.
+//
+// Writes Format to the current output with each directive replaced.
+// Directives are ~w (write the next argument), ~a (write the next
+// atomic argument), ~n (a newline) and ~~ (a tilde).
+func BuiltinFormat2(m Machine, args []term.Term) ForeignReturn {
+	out := m.(*machine).output.Term()
+	return BuiltinFormat3(m, []term.Term{out, args[0], args[1]})
+}
+
+// format(+Output, +Format, :Arguments) is det.
+//
+// Like format/2 but writes to Output.  Output is a stream or one of
+// the sinks accepted by with_output_to/2.
+func BuiltinFormat3(m Machine, args []term.Term) ForeignReturn {
+	output := args[0]
+	if kind, ok := outputSink(output); ok {
+		text := formatText(m.(*machine), args[1], args[2])
+		return ForeignUnify(output.(*term.Compound).Arguments()[0], sinkText(kind, text))
+	}
+	s := mustOutputStream(m, output, false)
+	s.write([]byte(formatText(m.(*machine), args[1], args[2])))
+	return ForeignTrue()
+}
+
+// with_output_to(+Sink, :Goal) is semidet.
+//
+// Proves Goal once with the current output collected into Sink.  Sink
+// is one of atom(A), string(S), codes(Cs) or chars(Cs).  The current
+// output is restored afterwards, even if Goal fails or raises an
+// exception.
+func BuiltinWithOutputTo2(m Machine, args []term.Term) ForeignReturn {
+	sink, goal := args[0], args[1]
+	if term.IsVariable(sink) {
+		panic(term.InstantiationError())
+	}
+	kind, ok := outputSink(sink)
+	if !ok {
+		panic(term.DomainError("output_sink", sink))
+	}
+
+	var buf bytes.Buffer
+	s := newOutputStream(&buf, "write")
+	sub := m.(*machine).addStream(s, "")
+	sub.output = s
+	var answer term.Bindings
+	sub = forEachSolution(sub, goal, func(env term.Bindings) bool {
+		answer = env
+		return false
+	}).(*machine)
+
+	// put back the original output stream
+	sub = sub.removeStream(s)
+	if _, ok := sub.streams.Lookup(strconv.FormatInt(m.(*machine).output.id, 10)); ok {
+		sub.output = m.(*machine).output
+	}
+	if answer == nil {
+		return continueOn(m, sub, ForeignFail())
+	}
+	// keep the solution's bindings, including any setarg/3 changes
+	text := sinkText(kind, buf.String())
+	value := sink.(*term.Compound).Arguments()[0]
+	env, err := value.Unify(answer, text)
+	if err == term.CantUnify {
+		return continueOn(m, sub, ForeignFail())
+	}
+	MaybePanic(err)
+	return sub.carryGlobals(m.SetBindings(env))
+}
+
+// deref follows variable bindings until it finds a term that's not a
+// bound variable.  Unlike Bindings.Resolve, it doesn't replace the
+// variables inside that term.
+func deref(env term.Bindings, t term.Term) term.Term {
+	for term.IsVariable(t) {
+		value, err := env.Value(t.(*term.Variable))
+		if err == term.NotBound {
+			return t
+		}
+		MaybePanic(err)
+		t = value
+	}
+	return t
+}
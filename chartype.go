@@ -0,0 +1,208 @@
+package golog
+
+// Character classification for char_type/2 and code_type/2.  The type
+// names follow SWI-Prolog.  Classification uses Go's Unicode tables.
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mndrix/golog/term"
+)
+
+// charType describes a single character type like alpha or digit(W)
+type charType struct {
+	name  string
+	arity int // 0 or 1
+
+	// test reports whether a character has this type.  For types with
+	// an argument, it also returns the argument's value.  asCode says
+	// whether character arguments should be represented as codes.
+	test func(c rune, asCode bool) (term.Term, bool)
+
+	// byCase is true if the argument is always a case variant of the
+	// character, as in upper(Lower)
+	byCase bool
+}
+
+// charTypes lists every character type in enumeration order
+var charTypes = []charType{
+	{"alnum", 0, is(isAlnum), false},
+	{"alpha", 0, is(func(c rune) bool { return isAlnum(c) || c == '_' }), false},
+	{"csym", 0, is(func(c rune) bool { return isAlnum(c) || c == '_' }), false},
+	{"csymf", 0, is(func(c rune) bool { return unicode.IsLetter(c) || c == '_' }), false},
+	{"ascii", 0, is(func(c rune) bool { return c < 128 }), false},
+	{"white", 0, is(func(c rune) bool { return c == ' ' || c == '\t' }), false},
+	{"cntrl", 0, is(unicode.IsControl), false},
+	{"digit", 1, digitWeight, false},
+	{"space", 0, is(unicode.IsSpace), false},
+	{"end_of_line", 0, is(func(c rune) bool { return ('\n' <= c && c <= '\r') || c == endOfFile }), false},
+	{"lower", 0, is(unicode.IsLower), false},
+	{"lower", 1, caseOf(unicode.IsLower, unicode.ToUpper), true},
+	{"upper", 0, is(unicode.IsUpper), false},
+	{"upper", 1, caseOf(unicode.IsUpper, unicode.ToLower), true},
+	{"punct", 0, is(func(c rune) bool { return isGraph(c) && !isAlnum(c) }), false},
+	{"graph", 0, is(isGraph), false},
+	{"print", 0, is(unicode.IsPrint), false},
+	{"period", 0, is(func(c rune) bool { return strings.ContainsRune(".!?", c) }), false},
+	{"quote", 0, is(func(c rune) bool { return strings.ContainsRune("'\"`", c) }), false},
+	{"paren", 0, is(func(c rune) bool { return c == '(' || c == ')' }), false},
+	{"code", 1, func(c rune, asCode bool) (term.Term, bool) {
+		return term.NewCode(c), true
+	}, false},
+	{"to_lower", 1, caseOf(func(rune) bool { return true }, unicode.ToLower), true},
+	{"to_upper", 1, caseOf(func(rune) bool { return true }, unicode.ToUpper), true},
+	{"xdigit", 1, hexWeight, false},
+}
+
+// endOfFile stands for the end of input, which is code -1 or the atom
+// end_of_file.  Its only type is end_of_line.
+const endOfFile rune = -1
+
+// is builds a test for a character type without arguments
+func is(f func(rune) bool) func(rune, bool) (term.Term, bool) {
+	return func(c rune, asCode bool) (term.Term, bool) {
+		return nil, f(c)
+	}
+}
+
+// caseOf builds a test for types like upper(Lower) whose argument is
+// the character converted to another case
+func caseOf(f func(rune) bool, convert func(rune) rune) func(rune, bool) (term.Term, bool) {
+	return func(c rune, asCode bool) (term.Term, bool) {
+		if !f(c) {
+			return nil, false
+		}
+		return charTerm(convert(c), asCode), true
+	}
+}
+
+// charTerm represents a character as a code or as a one character atom
+func charTerm(c rune, asCode bool) term.Term {
+	if asCode {
+		return term.NewCode(c)
+	}
+	return term.NewAtom(string(c))
+}
+
+func isAlnum(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+func isGraph(c rune) bool {
+	return unicode.IsGraphic(c) && !unicode.IsSpace(c)
+}
+
+// digitWeight gives the value of a decimal digit from any script.
+// Unicode arranges decimal digits in runs starting with zero.
+func digitWeight(c rune, asCode bool) (term.Term, bool) {
+	if !unicode.IsDigit(c) {
+		return nil, false
+	}
+	start := c
+	for start > 0 && unicode.IsDigit(start-1) {
+		start--
+	}
+	return term.NewInt64(int64(c-start) % 10), true
+}
+
+// hexWeight gives the value of a hexadecimal digit
+func hexWeight(c rune, asCode bool) (term.Term, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return term.NewInt64(int64(c - '0')), true
+	case 'a' <= c && c <= 'f':
+		return term.NewInt64(int64(c-'a') + 10), true
+	case 'A' <= c && c <= 'F':
+		return term.NewInt64(int64(c-'A') + 10), true
+	}
+	return nil, false
+}
+
+// matchingCharTypes returns the character types which typ could name.
+// If typ is unbound, that's all of them.
+func matchingCharTypes(typ term.Term) []charType {
+	if term.IsVariable(typ) {
+		return charTypes
+	}
+	t := typ.(term.Callable)
+	var matches []charType
+	for _, ct := range charTypes {
+		if t.Name() == ct.name && t.Arity() == ct.arity {
+			matches = append(matches, ct)
+		}
+	}
+	return matches
+}
+
+// caseCandidates returns the only characters which can have a type
+// like upper(Lower) when its argument is already known.  They're the
+// case variants of that argument, in order.  Returns false if the
+// candidates aren't limited in this way.
+func caseCandidates(typ term.Term, candidates []charType) ([]rune, bool) {
+	if term.IsVariable(typ) {
+		return nil, false
+	}
+	for _, ct := range candidates {
+		if !ct.byCase {
+			return nil, false
+		}
+	}
+	arg := typ.(term.Callable).Arguments()[0]
+	var c rune
+	switch {
+	case term.IsInteger(arg):
+		c = arg.(*term.Integer).Code()
+	case term.IsAtom(arg):
+		name := []rune(arg.(*term.Atom).Name())
+		if len(name) != 1 {
+			return nil, true
+		}
+		c = name[0]
+	default:
+		return nil, false
+	}
+
+	variants := []rune{c}
+	for v := unicode.SimpleFold(c); v != c; v = unicode.SimpleFold(v) {
+		variants = append(variants, v)
+	}
+	sort.Slice(variants, func(i, j int) bool { return variants[i] < variants[j] })
+	return variants, true
+}
+
+// charTypeAlternatives returns the unifications which make character c
+// have type typ, considering only the given candidate types
+func charTypeAlternatives(c rune, typ term.Term, candidates []charType, asCode bool) [][]term.Term {
+	var alternatives [][]term.Term
+	for _, ct := range candidates {
+		if c == endOfFile && ct.name != "end_of_line" {
+			continue
+		}
+		arg, ok := ct.test(c, asCode)
+		if !ok {
+			continue
+		}
+		var instance term.Term = term.NewAtom(ct.name)
+		if ct.arity == 1 {
+			instance = term.NewCallable(ct.name, arg)
+		}
+		alternatives = append(alternatives, []term.Term{typ, instance})
+	}
+	return alternatives
+}
+
+// mustCharType returns the character types which typ could name.
+// Panics with an ISO error unless typ is unbound or the name of a known
+// character type.
+func mustCharType(typ term.Term) []charType {
+	if !term.IsVariable(typ) && !term.IsCallable(typ) {
+		panic(term.TypeError("callable", typ))
+	}
+	candidates := matchingCharTypes(typ)
+	if len(candidates) == 0 {
+		panic(term.DomainError("char_type", typ))
+	}
+	return candidates
+}
@@ -111,6 +111,103 @@ func (cp *barrierCP) String() string {
 	return fmt.Sprintf("cut barrier %d", cp.id)
 }
 
+// a choice point which marks an active catch/3 call
+var catchID int64 = 0 // thread unsafe counter variable. fix when needed
+type catchCP struct {
+	machine  Machine
+	id       int64
+	catcher  term.Term
+	recovery term.Term
+}
+
+// NewCatchChoicePoint creates a special choice point which records
+// the catcher and recovery goal of a catch/3 call.  When an exception
+// is thrown, the machine searches its disjunction stack for one of these
+// choice points.  If the ball unifies with the catcher, execution
+// resumes from machine m by calling the recovery goal.  Like a cut
+// barrier, following a catch choice point during backtracking fails.
+func NewCatchChoicePoint(m Machine, catcher, recovery term.Term) ChoicePoint {
+	catchID++
+	return &catchCP{
+		machine:  m,
+		id:       catchID,
+		catcher:  catcher,
+		recovery: recovery,
+	}
+}
+func (cp *catchCP) Follow() (Machine, error) {
+	return nil, CutBarrierFails
+}
+func (cp *catchCP) String() string {
+	return fmt.Sprintf("catch %d %s", cp.id, cp.catcher)
+}
+
+// a choice point which tries the remaining solutions of a foreign
+// predicate, computing each one only when backtracking reaches it
+type unifyCP struct {
+	machine Machine
+	next    func(int) ([]term.Term, int, bool)
+	i       int
+}
+
+// newUnifyChoicePoint creates a choice point which unifies the terms of
+// a foreign predicate's solutions, like the arguments to ForeignUnify.
+// next(i) returns the first solution at or after position i and the
+// position which follows it.  It returns false when no solutions remain.
+func newUnifyChoicePoint(m Machine, next func(int) ([]term.Term, int, bool), i int) ChoicePoint {
+	return &unifyCP{machine: m, next: next, i: i}
+}
+func (cp *unifyCP) Follow() (Machine, error) {
+	for i := cp.i; ; {
+		ts, after, ok := cp.next(i)
+		if !ok {
+			return nil, term.CantUnify
+		}
+		i = after
+
+		env := cp.machine.Bindings()
+		var err error
+		for j := 0; j < len(ts) && err == nil; j += 2 {
+			env, err = ts[j].Unify(env, ts[j+1])
+		}
+		if err == term.CantUnify {
+			continue
+		}
+		MaybePanic(err)
+
+		// leave a choice point only if more solutions might follow
+		m := cp.machine
+		if _, _, more := cp.next(after); more {
+			m = m.PushDisj(newUnifyChoicePoint(cp.machine, cp.next, after))
+		}
+		return m.SetBindings(env), nil
+	}
+}
+func (cp *unifyCP) String() string {
+	return fmt.Sprintf("unify solutions from %d", cp.i)
+}
+
+// a choice point which resumes a foreign predicate's computation
+type resumeCP struct {
+	machine Machine
+	resume  func(Machine) Machine
+}
+
+// newResumeChoicePoint creates a choice point for foreign predicates
+// which find their solutions lazily.  m is the machine which called the
+// predicate.  On backtracking, resume is given the machine whose
+// non-backtrackable state should be kept (see carryGlobals) and produces
+// the machine for the next solution.
+func newResumeChoicePoint(m Machine, resume func(Machine) Machine) ChoicePoint {
+	return &resumeCP{machine: m, resume: resume}
+}
+func (cp *resumeCP) Follow() (Machine, error) {
+	return cp.resume(cp.machine), nil
+}
+func (cp *resumeCP) String() string {
+	return "resume foreign predicate"
+}
+
 // If cp is a cut barrier choice point, BarrierId returns an identifier
 // unique to this cut barrier and true.  If cp is not a cut barrier,
 // the second return value is false.  BarrierId is mostly useful for
@@ -92,7 +92,11 @@ func initMachine() golog.Machine {
 			warnf("Can't open file: %s\n", err)
 			os.Exit(1)
 		}
-		m = m.Consult(file)
+		m, err = m.Load(file)
+		if err != nil {
+			warnf("Can't consult %s: %s\n", filename, err)
+			os.Exit(1)
+		}
 	}
 
 	return m
@@ -6,6 +6,7 @@ import . "github.com/mndrix/golog/util"
 
 import (
 	"bytes"
+	"sort"
 
 	"github.com/mndrix/ps"
 )
@@ -17,6 +18,10 @@ import (
 // interact with databases directly.  One usually calls methods on Machine
 // instead.
 type Database interface {
+	// Abolish removes every clause of the predicate with the given
+	// indicator, like foo/3.
+	Abolish(string) Database
+
 	// Asserta adds a term to the database at the start of any existing
 	// terms with the same name and arity.
 	Asserta(Term) Database
@@ -26,8 +31,8 @@ type Database interface {
 	Assertz(Term) Database
 
 	// Candidates() returns a list of clauses that might unify with a term.
-	// Returns error if no predicate with appropriate
-	// name and arity has been defined.
+	// Returns an existence_error exception if no predicate with
+	// appropriate name and arity has been defined.
 	Candidates(Term) ([]Term, error)
 
 	// Candidates_() is like Candidates() but panics on error.
@@ -36,6 +41,20 @@ type Database interface {
 	// ClauseCount returns the number of clauses in the database
 	ClauseCount() int
 
+	// IsDynamic returns true if the predicate with the given indicator
+	// has been declared dynamic
+	IsDynamic(string) bool
+
+	// Predicates returns the indicator of each predicate in the
+	// database, in sorted order.  Dynamic predicates without clauses
+	// are included.
+	Predicates() []string
+
+	// Dynamic declares that the predicate with the given indicator, like
+	// foo/3, is dynamic.  A dynamic predicate is defined even if it has
+	// no clauses, so calling it fails rather than raising an error.
+	Dynamic(string) Database
+
 	// String returns a string representation of the entire database
 	String() string
 }
@@ -45,12 +64,27 @@ func NewDatabase() Database {
 	var db mapDb
 	db.clauseCount = 0
 	db.predicates = ps.NewMap()
+	db.dynamic = ps.NewMap()
 	return &db
 }
 
 type mapDb struct {
 	clauseCount int    // number of clauses in the database
 	predicates  ps.Map // term indicator => *clauses
+	dynamic     ps.Map // term indicator => true, for dynamic predicates
+}
+
+func (self *mapDb) Abolish(indicator string) Database {
+	cs, ok := self.predicates.Lookup(indicator)
+	if !ok {
+		return self
+	}
+
+	var newMapDb mapDb
+	newMapDb.clauseCount = self.clauseCount - int(cs.(*clauses).count())
+	newMapDb.predicates = self.predicates.Delete(indicator)
+	newMapDb.dynamic = self.dynamic.Delete(indicator)
+	return &newMapDb
 }
 
 func (self *mapDb) Asserta(term Term) Database {
@@ -86,6 +120,7 @@ func (self *mapDb) assert(side rune, term Term) Database {
 
 	newMapDb.clauseCount = self.clauseCount + 1
 	newMapDb.predicates = self.predicates.Set(indicator, cs)
+	newMapDb.dynamic = self.dynamic
 	return &newMapDb
 }
 
@@ -101,7 +136,9 @@ func (self *mapDb) Candidates(t Term) ([]Term, error) {
 	indicator := t.Indicator()
 	cs, ok := self.predicates.Lookup(indicator)
 	if !ok { // this predicate hasn't been defined
-		return nil, Errorf("Undefined predicate: %s", indicator)
+		x := t.(Callable)
+		pi := predicateIndicator(x.Name(), x.Arity())
+		return nil, ExistenceError("procedure", pi)
 	}
 
 	// quick return for an atom term
@@ -133,6 +170,26 @@ func (self *mapDb) ClauseCount() int {
 	return self.clauseCount
 }
 
+func (self *mapDb) Dynamic(indicator string) Database {
+	newMapDb := *self
+	newMapDb.dynamic = self.dynamic.Set(indicator, true)
+	if _, ok := self.predicates.Lookup(indicator); !ok {
+		newMapDb.predicates = self.predicates.Set(indicator, newClauses())
+	}
+	return &newMapDb
+}
+
+func (self *mapDb) IsDynamic(indicator string) bool {
+	_, ok := self.dynamic.Lookup(indicator)
+	return ok
+}
+
+func (self *mapDb) Predicates() []string {
+	indicators := self.predicates.Keys()
+	sort.Strings(indicators)
+	return indicators
+}
+
 func (self *mapDb) String() string {
 	var buf bytes.Buffer
 
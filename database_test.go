@@ -4,6 +4,7 @@ import . "github.com/mndrix/golog/term"
 
 import "github.com/mndrix/golog/read"
 
+import "strings"
 import "testing"
 
 func TestAsserta(t *testing.T) {
@@ -62,3 +63,42 @@ func TestAsserta(t *testing.T) {
 		t.Errorf("db3: can't find foo/2")
 	}
 }
+
+func TestDynamic(t *testing.T) {
+	db0 := NewDatabase()
+	db1 := db0.Dynamic("foo/1")
+	term := read.Term_(`foo(one).`)
+
+	// an undefined predicate raises an existence error
+	_, err := db0.Candidates(term)
+	if ex, ok := err.(*Exception); !ok || !strings.HasPrefix(ex.Ball().String(), "error(existence_error(procedure, /(foo, 1)), ") {
+		t.Errorf("db0: wrong error for foo/1: %v", err)
+	}
+
+	// a dynamic predicate without clauses has no candidates
+	cs, err := db1.Candidates(term)
+	if err != nil || len(cs) != 0 {
+		t.Errorf("db1: wrong candidates for foo/1: %v, %s", cs, err)
+	}
+	if db1.ClauseCount() != 0 {
+		t.Errorf("db1: wrong number of clauses: %d", db1.ClauseCount())
+	}
+	if !db1.IsDynamic("foo/1") || db0.IsDynamic("foo/1") {
+		t.Errorf("Wrong dynamic declaration for foo/1")
+	}
+	if ps := db1.Assertz(NewAtom("bar")).Predicates(); len(ps) != 2 || ps[0] != "bar/0" || ps[1] != "foo/1" {
+		t.Errorf("Wrong predicates: %v", ps)
+	}
+
+	// declaring a predicate dynamic keeps its clauses
+	db2 := db0.Assertz(term).Dynamic("foo/1")
+	if cs := db2.Candidates_(term); len(cs) != 1 {
+		t.Errorf("db2: can't find foo/1")
+	}
+
+	// abolishing a dynamic predicate makes it undefined
+	db3 := db1.Abolish("foo/1")
+	if _, err := db3.Candidates(term); err == nil {
+		t.Errorf("db3: shouldn't have found foo/1")
+	}
+}
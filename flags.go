@@ -0,0 +1,151 @@
+package golog
+
+// Prolog flags adjust the behavior of a Golog machine.  Each machine
+// carries its own flag values.  Like nb_setval/2 values, they survive
+// backtracking.  See ISO §7.11
+
+import (
+	"math"
+	"sort"
+
+	"github.com/mndrix/golog/read"
+	"github.com/mndrix/golog/term"
+)
+
+// prologFlag describes a single Prolog flag.  A flag without a domain
+// is read only.
+type prologFlag struct {
+	value  term.Term // default value
+	domain []string  // atoms which are acceptable values
+}
+
+var prologFlags = map[string]prologFlag{
+	// bounded is false because integers have arbitrary precision
+	"bounded": {term.NewAtom("false"), nil},
+
+	// char_conversion enables the character conversion table.  Golog
+	// has no char_conversion/2, so the table is always the identity.
+	"char_conversion": {term.NewAtom("off"), []string{"on", "off"}},
+
+	// debug is on when debugging.  Golog has no debugger yet.
+	"debug": {term.NewAtom("off"), []string{"on", "off"}},
+
+	// double_quotes chooses the term which represents double quoted text
+	"double_quotes": {term.NewAtom("codes"), []string{"codes", "chars", "atom", "string"}},
+
+	// integer_rounding_function describes how // rounds its result
+	"integer_rounding_function": {term.NewAtom("toward_zero"), nil},
+
+	// max_integer and min_integer have no meaning when bounded is false.
+	// Like SWI-Prolog, they describe the range of 64-bit integers.
+	"max_integer": {term.NewInt64(math.MaxInt64), nil},
+	"min_integer": {term.NewInt64(math.MinInt64), nil},
+
+	// number_order is iso to put all floats before all integers in the
+	// standard order of terms.  By default, numbers are compared by value.
+	// See Note_1 in the term package
+	"number_order": {term.NewAtom("value"), []string{"value", "iso"}},
+
+	// occurs_check decides whether unification checks that a variable
+	// isn't being bound to a term which contains it
+	"occurs_check": {term.NewAtom("false"), []string{"false", "true", "error"}},
+
+	// prefer_rationals makes integer division produce exact rationals
+	"prefer_rationals": {term.NewAtom("false"), []string{"true", "false"}},
+
+	// unknown decides what happens when calling an unknown procedure
+	"unknown": {term.NewAtom("error"), []string{"error", "fail", "warning"}},
+}
+
+// flagNames returns the name of each known flag, in sorted order
+func flagNames() []string {
+	names := make([]string, 0, len(prologFlags))
+	for name := range prologFlags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validFlagValue returns true if value is acceptable for the named flag
+func validFlagValue(name string, value term.Term) bool {
+	if !term.IsAtom(value) {
+		return false
+	}
+	for _, v := range prologFlags[name].domain {
+		if v == value.(*term.Atom).Name() {
+			return true
+		}
+	}
+	return false
+}
+
+// flag returns the current value of the named flag
+func (m *machine) flag(name string) term.Term {
+	if value, ok := m.flags.Lookup(name); ok {
+		return value.(term.Term)
+	}
+	return nil
+}
+
+// setFlag returns a machine like this one but with a new flag value
+func (m *machine) setFlag(name string, value term.Term) *machine {
+	m1 := m.clone()
+	m1.flags = m.flags.Set(name, value)
+	if name == "occurs_check" {
+		m1.env = term.WithOccursCheck(m.env, m1.occursCheck())
+	}
+	return m1
+}
+
+func (m *machine) PrologFlag(name string) (term.Term, bool) {
+	value := m.flag(name)
+	return value, value != nil
+}
+
+func (m *machine) SetPrologFlag(name string, value term.Term) (Machine, error) {
+	flag, ok := prologFlags[name]
+	if !ok {
+		return nil, term.DomainError("prolog_flag", term.NewAtom(name))
+	}
+	if flag.domain == nil {
+		return nil, term.PermissionError("modify", "flag", term.NewAtom(name))
+	}
+	if !validFlagValue(name, value) {
+		culprit := term.NewCallable("+", term.NewAtom(name), value)
+		return nil, term.DomainError("flag_value", culprit)
+	}
+	return m.setFlag(name, value), nil
+}
+
+// arithmeticFlags describes how this machine evaluates arithmetic
+func (m *machine) arithmeticFlags() term.ArithmeticFlags {
+	return term.ArithmeticFlags{
+		PreferRationals: m.flag("prefer_rationals").(*term.Atom).Name() == "true",
+	}
+}
+
+// occursCheck describes how this machine's unification handles a
+// variable which occurs in the term to which it's being bound
+func (m *machine) occursCheck() term.OccursCheck {
+	switch m.flag("occurs_check").(*term.Atom).Name() {
+	case "true":
+		return term.OccursCheckTrue
+	case "error":
+		return term.OccursCheckError
+	}
+	return term.OccursCheckFalse
+}
+
+// standardOrder describes how this machine compares terms
+func (m *machine) standardOrder() term.StandardOrder {
+	return term.StandardOrder{
+		IsoNumbers: m.flag("number_order").(*term.Atom).Name() == "iso",
+	}
+}
+
+// doubleQuotes describes how this machine reads double quoted text
+func (m *machine) doubleQuotes() read.DoubleQuotes {
+	dq, _ := read.NewDoubleQuotes(m.flag("double_quotes").(*term.Atom).Name())
+	return dq
+}
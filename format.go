@@ -0,0 +1,394 @@
+package golog
+
+// format/2 writes text described by a template.  Most characters in the
+// template are written as they are.  A tilde starts a directive which
+// usually consumes one of the arguments.  Between the tilde and the
+// directive's letter there may be a numeric argument: digits, a
+// backquote followed by a character (standing for its code) or * which
+// takes the number from the next argument.
+//
+// Column stops (~| and ~+) pad the text written since the previous stop
+// to reach a column.  The padding goes at the fill points marked with ~t
+// or, if there are none, after the text.
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mndrix/golog/term"
+)
+
+// formatter holds the state of a single format/2 call
+type formatter struct {
+	m       *machine
+	values  []term.Term  // arguments not yet consumed
+	done    bytes.Buffer // text before the last column stop
+	pending bytes.Buffer // text since the last column stop
+	fills   []fillPoint  // fill points in pending
+	stop    int          // column of the last column stop
+}
+
+// fillPoint is a place where a column stop may insert padding
+type fillPoint struct {
+	at   int  // byte offset in pending
+	char rune // padding character
+}
+
+// formatText returns the text which format/2 writes for a template and
+// its arguments.  args is a list of arguments or a single argument.
+func formatText(m *machine, template, args term.Term) string {
+	text := mustText(template)
+	f := &formatter{m: m}
+	if term.IsList(args) {
+		f.values = mustProperList(args)
+	} else {
+		f.values = []term.Term{args}
+	}
+
+	for i := 0; i < len(text); {
+		c, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+		if c != '~' {
+			f.pending.WriteRune(c)
+			continue
+		}
+
+		// optional numeric argument
+		n, hasN := 0, false
+		switch {
+		case i < len(text) && text[i] == '*':
+			i++
+			arg := f.next()
+			if !term.IsInteger(arg) || arg.(*term.Integer).Value().Sign() < 0 ||
+				!arg.(*term.Integer).Value().IsInt64() {
+				panic(term.FormatError("no or negative integer for `*' argument"))
+			}
+			n, hasN = int(arg.(*term.Integer).Value().Int64()), true
+		case i < len(text) && text[i] == '`':
+			if i+1 == len(text) {
+				panic(term.FormatError("truncated format specification"))
+			}
+			c, size := utf8.DecodeRuneInString(text[i+1:])
+			i += 1 + size
+			n, hasN = int(c), true
+		default:
+			for i < len(text) && '0' <= text[i] && text[i] <= '9' {
+				n = 10*n + int(text[i]-'0')
+				hasN = true
+				i++
+			}
+		}
+
+		if i == len(text) {
+			panic(term.FormatError("truncated format specification"))
+		}
+		d, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+		f.directive(d, n, hasN)
+	}
+	if len(f.values) > 0 {
+		panic(term.FormatError("too many arguments"))
+	}
+	f.done.Write(f.pending.Bytes())
+	return f.done.String()
+}
+
+// next consumes the next argument
+func (f *formatter) next() term.Term {
+	if len(f.values) == 0 {
+		panic(term.FormatError("not enough arguments"))
+	}
+	value := f.values[0]
+	f.values = f.values[1:]
+	return value
+}
+
+// directive handles a single directive, d, with its numeric argument
+func (f *formatter) directive(d rune, n int, hasN bool) {
+	switch d {
+	case '~':
+		f.pending.WriteRune('~')
+	case 'a':
+		f.pending.WriteString(mustAtomic(f.next()))
+	case 'c':
+		code := f.next()
+		if !term.IsInteger(code) {
+			panic(term.FormatError("~c expects a character code"))
+		}
+		c := mustCharacterCode(code)
+		f.pending.WriteString(strings.Repeat(string(c), repeatCount(n, hasN)))
+	case 'd', 'D':
+		x := f.integer(d)
+		f.pending.WriteString(formatInteger(x, n, d == 'D'))
+	case 'e', 'f', 'g':
+		digits := 6
+		if hasN {
+			digits = n
+		}
+		f.pending.WriteString(formatNumber(f.number(d), d, digits))
+	case 'i':
+		f.next()
+	case 'n':
+		f.pending.WriteString(strings.Repeat("\n", repeatCount(n, hasN)))
+	case 'p':
+		f.write(writeOptions{quoted: true, numbervars: true, portray: true})
+	case 'q':
+		f.write(writeOptions{quoted: true, numbervars: true})
+	case 'r', 'R':
+		if !hasN || n < 2 || n > 36 {
+			panic(term.FormatError("~r requires a radix between 2 and 36"))
+		}
+		digits := f.integer(d).Text(n)
+		if d == 'R' {
+			digits = strings.ToUpper(digits)
+		}
+		f.pending.WriteString(digits)
+	case 's':
+		arg := f.next()
+		if !term.IsString(arg) && !term.IsList(arg) {
+			panic(term.FormatError("~s expects a string or a list of codes"))
+		}
+		if !term.IsEmptyList(arg) {
+			f.pending.WriteString(mustText(arg))
+		}
+	case 't':
+		fill := ' '
+		if hasN {
+			fill = rune(n)
+		}
+		f.fills = append(f.fills, fillPoint{f.pending.Len(), fill})
+	case 'w':
+		f.write(writeOptions{numbervars: true})
+	case '|':
+		column := f.column()
+		if hasN {
+			column = n
+		}
+		f.columnStop(column)
+	case '+':
+		if !hasN {
+			n = 8
+		}
+		f.columnStop(f.stop + n)
+	default:
+		panic(term.FormatError("unknown directive: ~" + string(d)))
+	}
+}
+
+// repeatCount returns a directive's repeat count, which defaults to 1
+func repeatCount(n int, hasN bool) int {
+	if hasN {
+		return n
+	}
+	return 1
+}
+
+// write writes the next argument with the given options
+func (f *formatter) write(opts writeOptions) {
+	f.pending.WriteString(f.m.termText(f.next(), opts))
+}
+
+// integer consumes the next argument, which must be an integer
+func (f *formatter) integer(d rune) *big.Int {
+	arg := f.next()
+	if !term.IsInteger(arg) {
+		panic(term.FormatError("~" + string(d) + " expects an integer argument"))
+	}
+	return arg.(*term.Integer).Value()
+}
+
+// number consumes the next argument, which must be a number
+func (f *formatter) number(d rune) term.Number {
+	arg := f.next()
+	if !term.IsNumber(arg) {
+		panic(term.FormatError("~" + string(d) + " expects a numeric argument"))
+	}
+	return arg.(term.Number)
+}
+
+// column returns the column at which the next character will be
+// written, counting from 0
+func (f *formatter) column() int {
+	text := f.done.String() + f.pending.String()
+	if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+		text = text[i+1:]
+	}
+	return utf8.RuneCountInString(text)
+}
+
+// columnStop pads the text since the previous column stop so that the
+// next character is written at column target.  Padding is spread evenly
+// over the fill points.
+func (f *formatter) columnStop(target int) {
+	column := f.column()
+	if pad := target - column; pad > 0 {
+		if len(f.fills) == 0 {
+			f.fills = []fillPoint{{f.pending.Len(), ' '}}
+		}
+		text := f.pending.String()
+		prev := 0
+		for i, fill := range f.fills {
+			width := pad / len(f.fills)
+			if i < pad%len(f.fills) {
+				width++
+			}
+			f.done.WriteString(text[prev:fill.at])
+			f.done.WriteString(strings.Repeat(string(fill.char), width))
+			prev = fill.at
+		}
+		f.done.WriteString(text[prev:])
+		column = target
+	} else {
+		f.done.Write(f.pending.Bytes())
+	}
+	f.pending.Reset()
+	f.fills = nil
+	f.stop = column
+}
+
+// formatInteger writes x for ~Nd.  If places is positive, a decimal
+// point is inserted that many digits from the right.  If group is true,
+// digits left of the decimal point are grouped in threes, as ~D does.
+func formatInteger(x *big.Int, places int, group bool) string {
+	digits := new(big.Int).Abs(x).String()
+	if len(digits) <= places {
+		digits = strings.Repeat("0", places-len(digits)+1) + digits
+	}
+	whole, fraction := digits[:len(digits)-places], digits[len(digits)-places:]
+	if group {
+		var buf bytes.Buffer
+		for i, c := range whole {
+			if i > 0 && (len(whole)-i)%3 == 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteRune(c)
+		}
+		whole = buf.String()
+	}
+
+	text := whole
+	if places > 0 {
+		text += "." + fraction
+	}
+	if x.Sign() < 0 {
+		text = "-" + text
+	}
+	return text
+}
+
+// formatNumber writes a number for ~Ne, ~Nf or ~Ng like C's printf.
+// The number's exact value is used, so large integers and rationals
+// don't lose precision.
+func formatNumber(x term.Number, d rune, digits int) string {
+	var r *big.Rat
+	switch x := x.(type) {
+	case *term.Integer:
+		r = new(big.Rat).SetInt(x.Value())
+	case *term.Rational:
+		r = x.Value()
+	case *term.Float:
+		f := x.Value()
+		switch {
+		case math.IsNaN(f):
+			return "nan"
+		case math.IsInf(f, 1):
+			return "inf"
+		case math.IsInf(f, -1):
+			return "-inf"
+		}
+		r = new(big.Rat).SetFloat64(f)
+	}
+
+	switch d {
+	case 'e':
+		mantissa, exp := scientific(r, digits)
+		return mantissa + exponentText(exp)
+	case 'f':
+		return r.FloatString(digits)
+	}
+
+	// ~g uses ~e or ~f, whichever suits the exponent, without trailing
+	// zeros
+	if digits == 0 {
+		digits = 1
+	}
+	mantissa, exp := scientific(r, digits-1)
+	if exp < -4 || exp >= digits {
+		return trimZeros(mantissa) + exponentText(exp)
+	}
+	return trimZeros(r.FloatString(digits - 1 - exp))
+}
+
+// scientific returns r's mantissa, rounded to digits places after the
+// decimal point, and its decimal exponent
+func scientific(r *big.Rat, digits int) (string, int) {
+	if r.Sign() == 0 {
+		return new(big.Rat).FloatString(digits), 0
+	}
+	abs := new(big.Rat).Abs(r)
+	exp := len(abs.Num().String()) - len(abs.Denom().String())
+	ten := big.NewRat(10, 1)
+	one := big.NewRat(1, 1)
+	m := new(big.Rat).Quo(abs, pow10(exp))
+	for m.Cmp(ten) >= 0 {
+		exp++
+		m.Quo(abs, pow10(exp))
+	}
+	for m.Cmp(one) < 0 {
+		exp--
+		m.Quo(abs, pow10(exp))
+	}
+
+	mantissa := m.FloatString(digits)
+	if strings.HasPrefix(mantissa, "10") { // rounding carried into a new digit
+		exp++
+		mantissa = new(big.Rat).Quo(abs, pow10(exp)).FloatString(digits)
+	}
+	if r.Sign() < 0 {
+		mantissa = "-" + mantissa
+	}
+	return mantissa, exp
+}
+
+// pow10 returns 10 raised to the power n
+func pow10(n int) *big.Rat {
+	p := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(absInt(n))), nil)
+	if n < 0 {
+		return new(big.Rat).SetFrac(big.NewInt(1), p)
+	}
+	return new(big.Rat).SetInt(p)
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// exponentText writes a decimal exponent like C's printf: e+05, e-12
+func exponentText(exp int) string {
+	sign := "+"
+	if exp < 0 {
+		sign = "-"
+	}
+	text := strconv.Itoa(absInt(exp))
+	if len(text) < 2 {
+		text = "0" + text
+	}
+	return "e" + sign + text
+}
+
+// trimZeros removes trailing zeros after a decimal point, and the
+// decimal point itself if nothing follows it
+func trimZeros(text string) string {
+	if !strings.Contains(text, ".") {
+		return text
+	}
+	text = strings.TrimRight(text, "0")
+	return strings.TrimSuffix(text, ".")
+}
@@ -0,0 +1,88 @@
+package golog
+
+// Global variables associate a value with an atom for the duration of a
+// computation.  Values set with b_setval/2 live on the machine, so
+// backtracking restores an older value just like it restores variable
+// bindings.  Other state survives backtracking: values set with
+// nb_setval/2, gensym/2 counters, Prolog flags, the operator table,
+// streams and their aliases, and the current input and output.
+// Whenever the machine resumes from an earlier machine (a choice point
+// or a goal proven in a separate machine) it carries that state
+// forward.  See carryGlobals.
+
+import (
+	"github.com/mndrix/golog/term"
+)
+
+// global returns the current value of a global variable
+func (m *machine) global(name string) (term.Term, bool) {
+	if value, ok := m.globals.Lookup(name); ok {
+		return value.(term.Term), true
+	}
+	return nil, false
+}
+
+// setGlobal returns a machine like this one but with a new value for a
+// global variable.  Unless backtrackable is true, the value survives
+// backtracking.
+func (m *machine) setGlobal(name string, value term.Term, backtrackable bool) *machine {
+	m1 := m.clone()
+	m1.globals = m.globals.Set(name, value)
+	if !backtrackable {
+		m1.nbGlobals = m.nbGlobals.Set(name, value)
+	}
+	return m1
+}
+
+// gensym returns the next number for the given gensym/2 base along
+// with a machine which remembers that number was used
+func (m *machine) gensym(base string) (int64, *machine) {
+	var n int64 = 1
+	if last, ok := m.gensyms.Lookup(base); ok {
+		n = last.(int64) + 1
+	}
+	m1 := m.clone()
+	m1.gensyms = m.gensyms.Set(base, n)
+	return n, m1
+}
+
+// resetGensym returns a machine whose next gensym/2 number for base
+// is 1
+func (m *machine) resetGensym(base string) *machine {
+	m1 := m.clone()
+	m1.gensyms = m.gensyms.Delete(base)
+	return m1
+}
+
+// carryGlobals returns the earlier machine updated with m's
+// non-backtrackable state: nb_setval/2 values, gensym/2 counters, Prolog
+// flags, the operator table, streams and their aliases, and the current
+// input and output.  An nb_setval/2 value replaces the earlier machine's
+// value only if it was set after the earlier machine was created.  That
+// way, a b_setval/2 made before the earlier machine isn't clobbered by an
+// older nb_setval/2.
+func (m *machine) carryGlobals(earlier Machine) Machine {
+	e, ok := earlier.(*machine)
+	if !ok || (m.nbGlobals == e.nbGlobals && m.gensyms == e.gensyms &&
+		m.flags == e.flags && m.ops == e.ops && m.streams == e.streams &&
+		m.aliases == e.aliases && m.input == e.input && m.output == e.output) {
+		return earlier
+	}
+
+	e1 := e.clone()
+	m.nbGlobals.ForEach(func(name string, value interface{}) {
+		if old, ok := e.nbGlobals.Lookup(name); !ok || old != value {
+			e1.globals = e1.globals.Set(name, value)
+		}
+	})
+	e1.nbGlobals = m.nbGlobals
+	e1.gensyms = m.gensyms
+	if m.flags != e.flags {
+		e1.flags = m.flags
+		e1.env = term.WithOccursCheck(e.env, e1.occursCheck())
+	}
+	e1.ops = m.ops
+	e1.streams, e1.aliases = m.streams, m.aliases
+	e1.input, e1.output = m.input, m.output
+	return e1
+}
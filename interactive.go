@@ -55,48 +55,276 @@ func builtInHelp() map[string]string {
 		"help/1": `Prints the usage of the given predicate.`,
 		"apropos/1": `Looks up the database for the predicates 
 matching regexp and prints them.`,
-		"!/0":    `Cut operator, prevents backtracking beyond this point.`,
-		",/2":    `Conjunction operator.`,
-		"->/2":   `Implication operator.`,
-		";/2":    `Disjunction operator.`,
-		"=/2":    `Unification operator.`,
-		"=:=/2":  `Numeric equality operator.`,
-		"==/2":   `Equality operator.`,
-		"\\==/2": `Equality negation operator.`,
-		"@</2":   `Less than operator.`,
-		"@=</2":  `Less than or equal operator`,
-		"@>/2":   `Greater than operator.`,
-		"@>=/2":  `Greater than or equal operator.`,
-		`\+/1`:   `Negation operator.`,
+		"!/0":     `Cut operator, prevents backtracking beyond this point.`,
+		",/2":     `Conjunction operator.`,
+		"->/2":    `Implication operator.`,
+		";/2":     `Disjunction operator.`,
+		"=../2":   `True if the second argument is a list of the name and arguments of the first argument.`,
+		"=/2":     `Unification operator.`,
+		"</2":     `Numeric less than operator.`,
+		"=</2":    `Numeric less than or equal operator.`,
+		"=:=/2":   `Numeric equality operator.`,
+		"=\\=/2":  `Numeric inequality operator.`,
+		">/2":     `Numeric greater than operator.`,
+		">=/2":    `Numeric greater than or equal operator.`,
+		"==/2":    `Equality operator.`,
+		"\\==/2":  `Equality negation operator.`,
+		"=@=/2":   `True if both arguments are variants of each other.`,
+		"\\=@=/2": `True if the arguments aren't variants of each other.`,
+		"@</2":    `Less than operator.`,
+		"@=</2":   `Less than or equal operator`,
+		"@>/2":    `Greater than operator.`,
+		"@>=/2":   `Greater than or equal operator.`,
+		`\+/1`:    `Negation operator.`,
+		"aggregate_all/3": `Aggregates the solutions of the goal in the second
+argument according to the first argument: count, sum(E), max(E), min(E),
+max(E,W), min(E,W), bag(T) or set(T).`,
+		"arg/3": `Third argument is the argument of the second argument at the
+position given by the first argument.  Enumerates positions if unbound.`,
+		"at_end_of_stream/0": `True if there's nothing more to read from the current input.`,
+		"at_end_of_stream/1": `True if there's nothing more to read from the stream in the
+first argument.`,
+		"atom_chars/2": `Second argument is the list containing the characters
+of the name of the first argument.`,
 		"atom_codes/2": `Second argument is the list containing the character
 codes of the name of the first argument.`,
+		"atom_concat/3": `Third argument is the concatenation of the first two
+arguments.  Enumerates each split of the third argument if the others are unbound.`,
+		"atom_length/2": `Second argument is the number of characters in the
+first argument.`,
 		"atom_number/2": `Second argument is the number represented by the name
 of the first argument.`,
+		"atomic_list_concat/2": `Second argument is the concatenation of the
+atomic terms in the list given as first argument.`,
+		"atomic_list_concat/3": `Like atomic_list_concat/2, but with the separator
+in the second argument.  Splits the third argument if the list is unbound.`,
+		"b_getval/2": `Second argument is the value of the global variable named
+in the first argument.`,
+		"b_setval/2": `Sets the global variable named in the first argument to
+the second argument.  Undone on backtracking.`,
+		"bagof/3": `Like findall/3, but fails without solutions and groups
+solutions by the bindings of free variables.`,
 		"call/1": `Evaluates its argument.`,
 		"call/2": `Constructs term from its arguments and evaluates it.`,
 		"call/3": `Constructs term from its arguments and evaluates it.`,
 		"call/4": `Constructs term from its arguments and evaluates it.`,
 		"call/5": `Constructs term from its arguments and evaluates it.`,
 		"call/6": `Constructs term from its arguments and evaluates it.`,
+		"call/7": `Constructs term from its arguments and evaluates it.`,
+		"call/8": `Constructs term from its arguments and evaluates it.`,
+		"catch/3": `Proves the goal in the first argument.  If it throws an
+exception which unifies with the second argument, proves the third argument.`,
+		"char_code/2": `Second argument is the character code of the character
+in the first argument.`,
+		"char_type/2": `True if the character in the first argument has the type
+in the second argument, like alpha, digit(Weight) or upper(Lower).`,
+		"clause/2": `True if the first argument is the head of a clause in the
+database and the second argument is its body.`,
+		"close/1": `Closes the stream in the first argument.`,
+		"close/2": `Closes the stream in the first argument with the options in the
+second argument, like force(true).`,
+		"code_type/2": `Like char_type/2, but for character codes.`,
+		"compare/3": `Unifies the first argument with <, = or > depending on
+the standard order of the second and third arguments.`,
+		"copy_term/2": `Second argument is a copy of the first argument with
+fresh variables.`,
+		"current_input/1": `Unifies the first argument with the current input stream.`,
+		"current_op/3": `True if the third argument is an operator with the
+priority and specifier in the first two arguments.`,
+		"current_output/1": `Unifies the first argument with the current output stream.`,
+		"current_predicate/1": `True if the argument is the indicator, like foo/2,
+of a defined predicate.`,
+		"current_predicate/2": `True if the second argument is the most general
+head of a defined predicate named by the first argument.`,
+		"current_prolog_flag/2": `True if the flag in the first argument has the
+value in the second argument.`,
+		"del_dict/4": `Fourth argument is the dict in the second argument without
+the key in the first argument, whose value unifies with the third argument.`,
+		"dict_create/3": `First argument is a dict with the tag in the second
+argument and the Key-Value pairs in the third argument.`,
+		"dict_pairs/3": `Converts between the dict in the first argument and its
+tag and list of Key-Value pairs in the second and third arguments.`,
 		"downcase_atom/2": `Second argument is the atom with the name made up of
 all the same characters of the first atom, just in lower case`,
+		"dynamic/1": `Declares each predicate indicator in the argument dynamic.
+Calling a dynamic predicate without clauses fails instead of raising an error.`,
 		"fail/0": `Fail unconditionaly.`,
 		"findall/3": `Generate variables from template (first argument),
 bind them in the second argument, then collect the bindings in the third argument.`,
+		"findall/4": `Like findall/3, but the list ends with the fourth argument.`,
+		"format/1":  `Writes the format in the first argument to the current output.`,
+		"format/2": `Writes the format in the first argument to the current output,
+replacing directives like ~w with the arguments in the second argument.`,
+		"format/3": `Like format/2 but writes to the stream or sink, like atom(A), in the
+first argument.`,
+		"format_rational/4": `Rounds the number in the first argument to the number
+of decimal places in the second argument using the rounding mode in the third
+argument.  The fourth argument is an atom showing all those decimal places.`,
+		"functor/3": `True if the first argument is a term with the name and
+arity given in the second and third arguments.`,
+		"gensym/2": `Second argument is a new atom made of the first argument
+followed by a number.`,
+		"get_byte/1": `Reads a byte from the current input.  -1 at the end of the stream.`,
+		"get_byte/2": `Reads a byte from the binary stream in the first argument.`,
+		"get_char/1": `Reads a character from the current input.  end_of_file at the
+end of the stream.`,
+		"get_char/2": `Reads a character from the text stream in the first argument.`,
+		"get_dict/3": `Third argument is the value of the key in the first
+argument in the dict in the second argument.  Enumerates keys if unbound.`,
 		"ground/1": `Succeeds if the argument is ground.`,
 		"is/2": `Succeeds if the numerical expressions on both sides
 evaluate to the same number.`,
+		"is_dict/1": `True if the argument is a dict.`,
+		"is_dict/2": `True if the first argument is a dict with the tag in the
+second argument.`,
+		"is_ordset/1": `True if the argument is a list in strictly ascending
+standard order.`,
+		"keysort/2": `Stable sort of a list of Key-Value pairs by Key.`,
+		"length/2": `Second argument is the number of elements in the list in
+the first argument.  Generates longer and longer lists if both are unbound.`,
+		"list_to_set/2": `Second argument is the list in the first argument
+without duplicates, keeping the first occurrence of each element.`,
 		"listing/0": `Prints all predicates known to this interpreter.`,
-		"msort/2":   `Sorts list.`,
-		"printf/1":  `Prints its first argument.`,
-		"printf/2": `Populates the template in the first argument with
-the printable representations of its second argument (which must be a list)
-and prints it.`,
-		"printf/3": `Same as printf/2, but prints into a stream given
+		"max_list/2": `Second argument is the largest number in the list in the
+first argument.`,
+		"min_list/2": `Second argument is the smallest number in the list in the
+first argument.`,
+		"msort/2": `Sorts list.`,
+		"must_be/2": `Raises an error unless the second argument has the type
+in the first argument.`,
+		"nb_getval/2": `Same as b_getval/2.`,
+		"nb_setarg/3": `Like setarg/3, but the change survives backtracking.`,
+		"nl/0":        `Writes a newline to the current output.`,
+		"nl/1":        `Writes a newline to the stream in the first argument.`,
+		"nth0/3": `Third argument is the element of the list in the second
+argument at the position in the first argument, counting from 0.`,
+		"nth1/3": `Like nth0/3, but counting from 1.`,
+		"nb_setval/2": `Sets the global variable named in the first argument to
+a copy of the second argument.  Survives backtracking.`,
+		"number_chars/2": `Second argument is the list of characters
+representing the number in the first argument.`,
+		"number_codes/2": `Second argument is the list of character codes
+representing the number in the first argument.`,
+		"number_string/2": `Second argument is the string representing the number
 in the first argument.`,
+		"numbervars/3": `Binds each variable in the first argument to '$VAR'(N), counting
+from the second argument.  The third argument is the next unused number.`,
+		"numlist/3": `Third argument is the list of integers from the first
+argument to the second argument.`,
+		"op/3": `Adds operators named by the third argument, an atom or a list
+of atoms, with the priority and specifier in the first two arguments.
+A priority of 0 removes the operators.`,
+		"open/3": `Opens the file in the first argument with the mode (read, write
+or append) in the second argument.  Third argument is the new stream.`,
+		"open/4": `Like open/3 with a list of options in the fourth argument, like
+type(binary) or alias(A).`,
+		"ord_intersection/3": `Third argument is the intersection of the ordered
+sets in the first two arguments.`,
+		"ord_memberchk/2": `True if the first argument is an element of the
+ordered set in the second argument.`,
+		"ord_subset/2": `True if every element of the ordered set in the first
+argument is in the second argument.`,
+		"ord_subtract/3": `Third argument holds the elements of the first ordered
+set which aren't in the second.`,
+		"ord_symdiff/3": `Third argument holds the elements which are in exactly
+one of the ordered sets in the first two arguments.`,
+		"ord_union/3": `Third argument is the union of the ordered sets in the
+first two arguments.`,
+		"peek_char/1": `Like get_char/1 but leaves the character on the stream.`,
+		"peek_char/2": `Like get_char/2 but leaves the character on the stream.`,
+		"predicate_property/2": `True if the predicate of the head in the first
+argument has the property in the second argument.`,
+		"print/1":    `Like writeq/1 but lets the user's portray/1 predicate write terms first.`,
+		"print/2":    `Like print/1 but writes to the stream in the first argument.`,
+		"put_byte/1": `Writes a byte to the current output.`,
+		"put_byte/2": `Writes a byte to the binary stream in the first argument.`,
+		"put_char/1": `Writes a character to the current output.`,
+		"put_char/2": `Writes a character to the text stream in the first argument.`,
+		"put_dict/3": `Third argument is the dict in the second argument updated
+with the pairs in the first argument (a dict or a list of pairs).`,
+		"put_dict/4": `Fourth argument is the dict in the second argument with
+the key in the first argument set to the value in the third argument.`,
+		"rational/1": `True if its argument is an integer or a rational number.`,
+		"rational/3": `True if the first argument is a rational number with
+the numerator and denominator given in the second and third arguments.`,
+		"read/1": `Reads the next term from the current input.  The term is
+end_of_file at the end of the stream.`,
+		"read/2": `Like read/1 but reads from the stream in the first argument.`,
+		"read_term/2": `Like read/1 with options like variable_names(Vs) in the second
+argument.`,
+		"read_term/3": `Like read_term/2 but reads from the stream in the first argument.`,
+		"read_term_from_atom/3": `Parses the atom in the first argument as a term, using the
+read_term/2 options in the third argument.`,
+		"reset_gensym/1": `Restarts gensym/2 numbering for the base in the first
+argument.`,
+		"reverse/2": `Second argument is the list in the first argument in
+reverse order.`,
+		"round_rational/4": `Rounds the number in the first argument to the number
+of decimal places in the second argument using the rounding mode in the third
+argument (half_up, half_even, half_down, up, down, ceiling or floor).`,
+		"set_input/1":  `Makes the stream in the first argument the current input.`,
+		"set_output/1": `Makes the stream in the first argument the current output.`,
+		"set_prolog_flag/2": `Sets the flag in the first argument to the value
+in the second argument.`,
+		"setarg/3": `Destructively replaces an argument of the second argument.
+The change is undone on backtracking.`,
+		"setof/3": `Like bagof/3, but each list is sorted without duplicates.`,
+		"sort/2":  `Sorts list removing duplicates.`,
+		"sort/4": `Sorts the list in the third argument on the key given
+in the first argument (0 for the whole element) in the order given in the
+second argument (@<, @=<, @> or @>=).`,
+		"split_string/4": `Splits the string in the first argument at each
+separator character in the second argument, then removes pad characters in
+the third argument.  The fourth argument is the list of substrings.`,
+		"stream_property/2": `True if the stream in the first argument has the property in
+the second argument, like alias(user_output) or mode(read).`,
+		"string/1": `True if its argument is a string.`,
+		"string_chars/2": `Second argument is the list containing the characters
+of the string in the first argument.`,
+		"string_code/3": `Third argument is the character code at the position
+in the first argument (counting from 1) of the string in the second argument.`,
+		"string_codes/2": `Second argument is the list containing the character
+codes of the string in the first argument.`,
+		"string_concat/3": `Like atom_concat/3, but produces strings.`,
+		"string_length/2": `Second argument is the number of characters in the
+first argument.`,
+		"string_lower/2": `Second argument is the string in the first argument
+in lower case.`,
+		"string_to_atom/2": `Second argument is the atom with the same characters
+as the string in the first argument.`,
+		"string_upper/2": `Second argument is the string in the first argument
+in upper case.`,
+		"sub_atom/5": `True if the fifth argument is a sub atom of the first
+argument with the given number of characters before, in and after it.`,
+		"sub_string/5": `Like sub_atom/5, but produces strings.`,
 		"succ/2": `True if its second argument is one greater than its
 first argument.`,
+		"sum_list/2": `Second argument is the sum of the numbers in the list in
+the first argument.`,
+		"term_string/2": `Like term_to_atom/2 but the text in the second argument
+is a string.`,
+		"term_to_atom/2": `Second argument is the text of the term in the first
+argument.  Parses the second argument if it's bound.`,
+		"term_variables/2": `Second argument is a list of the distinct variables
+in the first argument.`,
+		"throw/1": `Throws its argument as an exception.  See catch/3.`,
+		"upcase_atom/2": `Second argument is the atom with the name made up of
+all the same characters of the first atom, just in upper case`,
 		"var/1": `True if its argument is a variable.`,
+		"with_output_to/2": `Proves the goal in the second argument once, collecting its
+output in the first argument: atom(A), string(S), codes(Cs) or chars(Cs).`,
+		"write/1": `Writes a term to the current output using operators, without quotes.`,
+		"write/2": `Like write/1 but writes to the stream in the first argument.`,
+		"write_canonical/1": `Writes a term to the current output, quoted and ignoring
+operators.`,
+		"write_canonical/2": `Like write_canonical/1 but writes to the stream in the first
+argument.`,
+		"write_term/2": `Writes the term in the first argument to the current output using
+the options in the second argument.`,
+		"write_term/3": `Like write_term/2 but writes to the stream in the first argument.`,
+		"writeq/1": `Like write/1 but quotes atoms and strings so the term can be read
+back.`,
+		"writeq/2": `Like writeq/1 but writes to the stream in the first argument.`,
 	}
 }
 
@@ -115,7 +343,7 @@ func InteractiveHelp1(m Machine, args []term.Term) ForeignReturn {
 	var subj string
 	if term.IsAtom(args[0]) {
 		subj = args[0].(*term.Atom).Name()
-	} else if term.IsString(args[0]) {
+	} else if term.IsString(args[0]) || term.IsCodeList(args[0]) {
 		subj = term.RawString(args[0])
 	} else {
 		panic(fmt.Sprintf("Illegal argument to help/1: %s", args[0]))
@@ -139,7 +367,7 @@ func InteractiveApropos1(m Machine, args []term.Term) ForeignReturn {
 	var subj string
 	if term.IsAtom(args[0]) {
 		subj = args[0].(*term.Atom).Name()
-	} else if term.IsString(args[0]) {
+	} else if term.IsString(args[0]) || term.IsCodeList(args[0]) {
 		subj = term.RawString(args[0])
 	} else {
 		panic(fmt.Sprintf("Illegal argument to apropos/1: %s", args[0]))
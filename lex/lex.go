@@ -40,16 +40,15 @@ func Scan(src io.Reader) <-chan *Eme {
 	ch := make(chan *Eme)
 	go func() {
 		s := new(Scanner).Init(src)
-		p := s.Pos()
 		tok := s.Scan()
 		for tok != EOF {
+			p := s.Position // where this token starts
 			l := &Eme{
 				Type:    tok,
 				Content: s.TokenText(),
 				Pos:     &p,
 			}
 			ch <- l
-			p = s.Pos()
 			tok = s.Scan()
 		}
 		close(ch)
@@ -92,6 +91,7 @@ const (
 	Functor                // an atom used as a predicate functor
 	FullStop               // "." ending a term
 	Int                    // an integer
+	Rational               // a rational number like 1r3
 	String                 // a double-quoted string
 	Variable               // a Prolog variable
 	Void                   // the special "_" variable
@@ -105,6 +105,7 @@ var tokenString = map[rune]string{
 	Functor:  "Functor",
 	FullStop: "FullStop",
 	Int:      "Int",
+	Rational: "Rational",
 	String:   "String",
 	Variable: "Variable",
 	Void:     "Void",
@@ -449,12 +450,25 @@ func (s *Scanner) scanNumber(ch rune) (rune, rune, rune) {
 				}
 				ch = s.next()
 			}
-			if ch == '.' || ch == 'e' || ch == 'E' {
+			if ch == 'e' || ch == 'E' {
 				// float
-				ch = s.scanFraction(ch)
 				ch = s.scanExponent(ch)
 				return Float, ch, 0
 			}
+			if ch == '.' {
+				ch = s.next()
+				if isDecimal(ch) {
+					// float
+					ch = s.scanMantissa(ch)
+					ch = s.scanExponent(ch)
+					return Float, ch, 0
+				}
+				// octal int followed by full stop
+				if has8or9 {
+					s.error("illegal octal number")
+				}
+				return Int, ch, FullStop
+			}
 			// octal int
 			if has8or9 {
 				s.error("illegal octal number")
@@ -462,8 +476,16 @@ func (s *Scanner) scanNumber(ch rune) (rune, rune, rune) {
 		}
 		return Int, ch, 0
 	}
-	// decimal int or float
+	// decimal int, float or rational
 	ch = s.scanMantissa(ch)
+	if ch == 'r' { // rational
+		ch = s.next()
+		if !isDecimal(ch) {
+			s.error("illegal rational number")
+		}
+		ch = s.scanMantissa(ch)
+		return Rational, ch, 0
+	}
 	if ch == 'e' || ch == 'E' { // float
 		ch = s.scanExponent(ch)
 		return Float, ch, 0
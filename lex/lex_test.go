@@ -167,6 +167,11 @@ var tokenList = []token{
 	{Float, "42E+10"},
 	{Float, "01234567890E-10"},
 
+	{Comment, "% rationals"},
+	{Rational, "1r3"},
+	{Rational, "22r7"},
+	{Rational, "1234567890r987654321"},
+
 	{Comment, "% character ints"},
 	{Int, `0'\s`}, // space character
 	{Int, `0'a`},
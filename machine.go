@@ -59,6 +59,7 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
@@ -105,6 +106,11 @@ type Machine interface {
 	Consult(interface{}) Machine
 	ProveAll(interface{}) []Bindings
 
+	// Load is like Consult but returns an error, rather than panicking,
+	// if the text can't be consulted.  The error is a *term.Exception
+	// describing a syntax error or a directive which raised an exception.
+	Load(interface{}) (Machine, error)
+
 	String() string
 
 	// Bindings returns the machine's most current variable bindings.
@@ -156,7 +162,9 @@ type Machine interface {
 	// PopDisj returns a machine with one fewer choice points on the
 	// disjunction stack and the choice point that was removed.  Returns
 	// err = EmptyDisjunctions if there are no more disjunctions on
-	// that stack
+	// that stack.  Following the choice point produces a machine from
+	// before any nb_setval/2 calls made since it was pushed.  Step
+	// carries those values forward; other callers may ignore them.
 	PopDisj() (ChoicePoint, Machine, error)
 
 	// RegisterForeign registers Go functions to implement Golog predicates.
@@ -166,10 +174,43 @@ type Machine interface {
 	// been registered replaces the predicate implementation.
 	RegisterForeign(map[string]ForeignPredicate) Machine
 
+	// RegisterInput returns a machine with a new text stream which reads
+	// from r.  Prolog code refers to the stream by alias.  Registering
+	// user_input also makes the new stream the current input.
+	RegisterInput(alias string, r io.Reader) Machine
+
+	// RegisterOutput returns a machine with a new text stream which
+	// writes to w.  Prolog code refers to the stream by alias.
+	// Registering user_output also makes the new stream the current
+	// output.
+	RegisterOutput(alias string, w io.Writer) Machine
+
+	// WithOutput returns a machine whose user_output, and current output,
+	// writes to w.  It's like RegisterOutput("user_output", w).
+	WithOutput(w io.Writer) Machine
+
+	// WithError returns a machine whose user_error writes to w.  Warnings
+	// are written there.
+	WithError(w io.Writer) Machine
+
+	// PrologFlag returns the current value of a Prolog flag, like
+	// current_prolog_flag/2.  Returns false if there's no such flag.
+	PrologFlag(string) (Term, bool)
+
+	// SetPrologFlag returns a machine like this one but with a new value
+	// for a Prolog flag, like set_prolog_flag/2.  The error is a
+	// *term.Exception describing an unknown flag, a read only flag or an
+	// unacceptable value.
+	SetPrologFlag(string, Term) (Machine, error)
+
 	// Step advances the machine one "step" (implementation dependent).
 	// It produces a new machine which can take the next step.  It might
 	// produce a proof by giving some variable bindings.  When the machine
 	// has done as much work as it can do, it returns err=MachineDone
+	// along with a machine which holds any state that survives
+	// backtracking, like nb_setval/2 values.
+	// An exception which isn't caught by catch/3 is returned as an error
+	// of type *term.Exception
 	Step() (Machine, Bindings, error)
 }
 
@@ -188,6 +229,22 @@ type machine struct {
 	smallForeign [smallThreshold]ps.Map // arity => functor => ForeignPredicate
 	largeForeign ps.Map                 // predicate indicator => ForeignPredicate
 
+	exitedCatches ps.Map // catch ID => true, for catch/3 goals that succeeded
+	flags         ps.Map // flag name => term.Term
+	library       ps.Map // predicate indicator => true, for library predicates
+	sources       ps.Map // predicate indicator => *source, for consulted predicates
+
+	ops *read.Operators // operator table, copied before each change by op/3
+
+	globals   ps.Map // name => term.Term, for b_setval/2 and nb_setval/2
+	nbGlobals ps.Map // name => term.Term, values which survive backtracking
+	gensyms   ps.Map // base => int64, the last number used by gensym/2
+
+	streams ps.Map  // stream id => *Stream, for open streams
+	aliases ps.Map  // alias => *Stream
+	input   *Stream // current input stream
+	output  *Stream // current output stream
+
 	help map[string]string
 }
 
@@ -197,44 +254,186 @@ func (*machine) IsaForeignReturn() {}
 // library already loaded and is typically the way one obtains
 // a machine.
 func NewMachine() Machine {
-	return NewBlankMachine().
-		Consult(prelude.Prelude).
+	m := NewBlankMachine().(*machine).
+		consult(prelude.Prelude, true).
 		RegisterForeign(map[string]ForeignPredicate{
-			"!/0":             BuiltinCut,
-			"$cut_to/1":       BuiltinCutTo,
-			",/2":             BuiltinComma,
-			"->/2":            BuiltinIfThen,
-			";/2":             BuiltinSemicolon,
-			"=/2":             BuiltinUnify,
-			"=:=/2":           BuiltinNumericEquals,
-			"==/2":            BuiltinTermEquals,
-			"\\==/2":          BuiltinTermNotEquals,
-			"@</2":            BuiltinTermLess,
-			"@=</2":           BuiltinTermLessEquals,
-			"@>/2":            BuiltinTermGreater,
-			"@>=/2":           BuiltinTermGreaterEquals,
-			`\+/1`:            BuiltinNot,
-			"atom_codes/2":    BuiltinAtomCodes2,
-			"atom_number/2":   BuiltinAtomNumber2,
-			"call/1":          BuiltinCall,
-			"call/2":          BuiltinCall,
-			"call/3":          BuiltinCall,
-			"call/4":          BuiltinCall,
-			"call/5":          BuiltinCall,
-			"call/6":          BuiltinCall,
-			"downcase_atom/2": BuiltinDowncaseAtom2,
-			"fail/0":          BuiltinFail,
-			"findall/3":       BuiltinFindall3,
-			"ground/1":        BuiltinGround,
-			"is/2":            BuiltinIs,
-			"listing/0":       BuiltinListing0,
-			"msort/2":         BuiltinMsort2,
-			"printf/1":        BuiltinPrintf,
-			"printf/2":        BuiltinPrintf,
-			"printf/3":        BuiltinPrintf,
-			"succ/2":          BuiltinSucc2,
-			"var/1":           BuiltinVar1,
+			"!/0":                   BuiltinCut,
+			"$catch_exit/1":         BuiltinCatchExit,
+			"$char_type/4":          BuiltinCharTypeFrom,
+			"$cut_to/1":             BuiltinCutTo,
+			"$distinct/2":           BuiltinDistinct2,
+			",/2":                   BuiltinComma,
+			"->/2":                  BuiltinIfThen,
+			";/2":                   BuiltinSemicolon,
+			"=../2":                 BuiltinUniv2,
+			"=/2":                   BuiltinUnify,
+			"</2":                   BuiltinNumericLess,
+			"=</2":                  BuiltinNumericLessEquals,
+			"=:=/2":                 BuiltinNumericEquals,
+			"=\\=/2":                BuiltinNumericNotEquals,
+			">/2":                   BuiltinNumericGreater,
+			">=/2":                  BuiltinNumericGreaterEquals,
+			"==/2":                  BuiltinTermEquals,
+			"\\==/2":                BuiltinTermNotEquals,
+			"=@=/2":                 BuiltinVariant2,
+			"\\=@=/2":               BuiltinNotVariant2,
+			"@</2":                  BuiltinTermLess,
+			"@=</2":                 BuiltinTermLessEquals,
+			"@>/2":                  BuiltinTermGreater,
+			"@>=/2":                 BuiltinTermGreaterEquals,
+			`\+/1`:                  BuiltinNot,
+			"aggregate_all/3":       BuiltinAggregateAll3,
+			"arg/3":                 BuiltinArg3,
+			"at_end_of_stream/0":    BuiltinAtEndOfStream0,
+			"at_end_of_stream/1":    BuiltinAtEndOfStream1,
+			"atom_chars/2":          BuiltinAtomChars2,
+			"atom_codes/2":          BuiltinAtomCodes2,
+			"atom_concat/3":         BuiltinAtomConcat3,
+			"atom_length/2":         BuiltinAtomLength2,
+			"atom_number/2":         BuiltinAtomNumber2,
+			"atomic_list_concat/2":  BuiltinAtomicListConcat2,
+			"atomic_list_concat/3":  BuiltinAtomicListConcat3,
+			"b_getval/2":            BuiltinBGetval2,
+			"b_setval/2":            BuiltinBSetval2,
+			"bagof/3":               BuiltinBagof3,
+			"call/1":                BuiltinCall,
+			"call/2":                BuiltinCall,
+			"call/3":                BuiltinCall,
+			"call/4":                BuiltinCall,
+			"call/5":                BuiltinCall,
+			"call/6":                BuiltinCall,
+			"call/7":                BuiltinCall,
+			"call/8":                BuiltinCall,
+			"catch/3":               BuiltinCatch3,
+			"char_code/2":           BuiltinCharCode2,
+			"char_type/2":           BuiltinCharType2,
+			"clause/2":              BuiltinClause2,
+			"close/1":               BuiltinClose1,
+			"close/2":               BuiltinClose2,
+			"code_type/2":           BuiltinCodeType2,
+			"compare/3":             BuiltinCompare3,
+			"copy_term/2":           BuiltinCopyTerm2,
+			"current_input/1":       BuiltinCurrentInput1,
+			"current_op/3":          BuiltinCurrentOp3,
+			"current_output/1":      BuiltinCurrentOutput1,
+			"current_predicate/1":   BuiltinCurrentPredicate1,
+			"current_predicate/2":   BuiltinCurrentPredicate2,
+			"current_prolog_flag/2": BuiltinCurrentPrologFlag2,
+			"del_dict/4":            BuiltinDelDict4,
+			"dict_create/3":         BuiltinDictCreate3,
+			"dict_pairs/3":          BuiltinDictPairs3,
+			"downcase_atom/2":       BuiltinDowncaseAtom2,
+			"dynamic/1":             BuiltinDynamic1,
+			"fail/0":                BuiltinFail,
+			"findall/3":             BuiltinFindall3,
+			"findall/4":             BuiltinFindall4,
+			"findnsols/4":           BuiltinFindnsols4,
+			"format/1":              BuiltinFormat1,
+			"format/2":              BuiltinFormat2,
+			"format/3":              BuiltinFormat3,
+			"format_rational/4":     BuiltinFormatRational4,
+			"functor/3":             BuiltinFunctor3,
+			"gensym/2":              BuiltinGensym2,
+			"get_byte/1":            BuiltinGetByte1,
+			"get_byte/2":            BuiltinGetByte2,
+			"get_char/1":            BuiltinGetChar1,
+			"get_char/2":            BuiltinGetChar2,
+			"get_dict/3":            BuiltinGetDict3,
+			"ground/1":              BuiltinGround,
+			"is/2":                  BuiltinIs,
+			"is_dict/1":             BuiltinIsDict1,
+			"is_dict/2":             BuiltinIsDict2,
+			"keysort/2":             BuiltinKeysort2,
+			"listing/0":             BuiltinListing0,
+			"msort/2":               BuiltinMsort2,
+			"must_be/2":             BuiltinMustBe2,
+			"nb_getval/2":           BuiltinNbGetval2,
+			"nb_setarg/3":           BuiltinNbSetarg3,
+			"nb_setval/2":           BuiltinNbSetval2,
+			"nl/0":                  BuiltinNl0,
+			"nl/1":                  BuiltinNl1,
+			"number_chars/2":        BuiltinNumberChars2,
+			"number_codes/2":        BuiltinNumberCodes2,
+			"number_string/2":       BuiltinNumberString2,
+			"numbervars/3":          BuiltinNumbervars3,
+			"op/3":                  BuiltinOp3,
+			"open/3":                BuiltinOpen3,
+			"open/4":                BuiltinOpen4,
+			"peek_char/1":           BuiltinPeekChar1,
+			"peek_char/2":           BuiltinPeekChar2,
+			"predicate_property/2":  BuiltinPredicateProperty2,
+			"print/1":               BuiltinPrint1,
+			"print/2":               BuiltinPrint2,
+			"put_byte/1":            BuiltinPutByte1,
+			"put_byte/2":            BuiltinPutByte2,
+			"put_char/1":            BuiltinPutChar1,
+			"put_char/2":            BuiltinPutChar2,
+			"put_dict/3":            BuiltinPutDict3,
+			"put_dict/4":            BuiltinPutDict4,
+			"read/1":                BuiltinRead1,
+			"read/2":                BuiltinRead2,
+			"read_term/2":           BuiltinReadTerm2,
+			"read_term/3":           BuiltinReadTerm3,
+			"read_term_from_atom/3": BuiltinReadTermFromAtom3,
+			"reset_gensym/1":        BuiltinResetGensym1,
+			"rational/1":            BuiltinRational1,
+			"rational/3":            BuiltinRational3,
+			"round_rational/4":      BuiltinRoundRational4,
+			"set_input/1":           BuiltinSetInput1,
+			"set_output/1":          BuiltinSetOutput1,
+			"set_prolog_flag/2":     BuiltinSetPrologFlag2,
+			"setarg/3":              BuiltinSetarg3,
+			"setof/3":               BuiltinSetof3,
+			"sort/2":                BuiltinSort2,
+			"sort/4":                BuiltinSort4,
+			"split_string/4":        BuiltinSplitString4,
+			"stream_property/2":     BuiltinStreamProperty2,
+			"string/1":              BuiltinString1,
+			"string_chars/2":        BuiltinStringChars2,
+			"string_code/3":         BuiltinStringCode3,
+			"string_codes/2":        BuiltinStringCodes2,
+			"string_concat/3":       BuiltinStringConcat3,
+			"string_length/2":       BuiltinStringLength2,
+			"string_lower/2":        BuiltinStringLower2,
+			"string_to_atom/2":      BuiltinStringToAtom2,
+			"string_upper/2":        BuiltinStringUpper2,
+			"sub_atom/5":            BuiltinSubAtom5,
+			"sub_string/5":          BuiltinSubString5,
+			"succ/2":                BuiltinSucc2,
+			"term_string/2":         BuiltinTermString2,
+			"term_to_atom/2":        BuiltinTermToAtom2,
+			"term_variables/2":      BuiltinTermVariables2,
+			"throw/1":               BuiltinThrow1,
+			"upcase_atom/2":         BuiltinUpcaseAtom2,
+			"var/1":                 BuiltinVar1,
+			"with_output_to/2":      BuiltinWithOutputTo2,
+			"write/1":               BuiltinWrite1,
+			"write/2":               BuiltinWrite2,
+			"write_canonical/1":     BuiltinWriteCanonical1,
+			"write_canonical/2":     BuiltinWriteCanonical2,
+			"write_term/2":          BuiltinWriteTerm2,
+			"write_term/3":          BuiltinWriteTerm3,
+			"writeq/1":              BuiltinWriteq1,
+			"writeq/2":              BuiltinWriteq2,
 		})
+	return m.(*machine).registerLibrary(map[string]ForeignPredicate{
+		"is_ordset/1":        BuiltinIsOrdset1,
+		"length/2":           BuiltinLength2,
+		"list_to_set/2":      BuiltinListToSet2,
+		"max_list/2":         BuiltinMaxList2,
+		"min_list/2":         BuiltinMinList2,
+		"nth0/3":             BuiltinNth03,
+		"nth1/3":             BuiltinNth13,
+		"numlist/3":          BuiltinNumlist3,
+		"ord_intersection/3": BuiltinOrdIntersection3,
+		"ord_memberchk/2":    BuiltinOrdMemberchk2,
+		"ord_subset/2":       BuiltinOrdSubset2,
+		"ord_subtract/3":     BuiltinOrdSubtract3,
+		"ord_symdiff/3":      BuiltinOrdSymdiff3,
+		"ord_union/3":        BuiltinOrdUnion3,
+		"reverse/2":          BuiltinReverse2,
+		"sum_list/2":         BuiltinSumList2,
+	})
 }
 
 // NewBlankMachine creates a new Golog machine without loading the
@@ -250,6 +449,19 @@ func NewBlankMachine() Machine {
 		m.smallForeign[i] = ps.NewMap()
 	}
 	m.largeForeign = ps.NewMap()
+	m.exitedCatches = ps.NewMap()
+	m.flags = ps.NewMap()
+	m.library = ps.NewMap()
+	m.sources = ps.NewMap()
+	m.globals = ps.NewMap()
+	m.nbGlobals = ps.NewMap()
+	m.gensyms = ps.NewMap()
+	m.streams, m.aliases = newStreamTable()
+	m.input, m.output = userInput, userOutput
+	m.ops = read.NewOperators()
+	for name, flag := range prologFlags {
+		m.flags = m.flags.Set(name, flag.value)
+	}
 	return (&m).DemandCutBarrier()
 }
 
@@ -259,18 +471,125 @@ func (m *machine) clone() *machine {
 }
 
 func (m *machine) Consult(text interface{}) Machine {
-	terms := read.TermAll_(text)
+	return m.consult(text, false)
+}
+
+func (m *machine) Load(text interface{}) (m1 Machine, err error) {
+	defer func() { // convert exceptions into errors
+		if x := recover(); x != nil {
+			ex, ok := x.(*Exception)
+			if !ok {
+				panic(x)
+			}
+			m1, err = nil, ex
+		}
+	}()
+	return m.consult(text, false), nil
+}
+
+// consult loads clauses from text.  If library is true, the clauses
+// define library predicates which later code may redefine.  Otherwise,
+// the first clause for a library predicate replaces its library
+// definition.
+func (m *machine) consult(text interface{}, library bool) *machine {
+	r := m.termReader(text)
+	file := sourceFile(text)
 	m1 := m.clone()
-	for _, t := range terms {
+	for {
+		r.SetOperators(m1.ops)
+		r.SetDoubleQuotes(m1.doubleQuotes())
+		t, err := r.Next()
+		if err == read.NoMoreTerms {
+			break
+		}
+		if err != nil {
+			panic(SyntaxError(err.Error()))
+		}
+
 		if IsDirective(t) {
-			// ignore all directives, for now
+			// directives may change flags and operators which
+			// affect later terms
+			m1 = m1.consultDirective(t.(Callable).Arguments()[0])
 			continue
 		}
+		head := t
+		if IsClause(t) {
+			head = Head(t)
+		}
+		if library {
+			m1.library = m1.library.Set(head.Indicator(), true)
+		} else {
+			m1.redefine(head.(Callable))
+		}
+		if _, ok := m1.sources.Lookup(head.Indicator()); !ok {
+			src := &source{file: file, line: r.Position().Line}
+			m1.sources = m1.sources.Set(head.Indicator(), src)
+		}
 		m1.db = m1.db.Assertz(t)
 	}
 	return m1
 }
 
+// redefine removes the library definition, if any, of the predicate
+// which head belongs to.  The library definition may be clauses or a
+// foreign predicate.
+func (m *machine) redefine(head Callable) {
+	indicator := head.Indicator()
+	if _, ok := m.library.Lookup(indicator); !ok {
+		return
+	}
+	m.db = m.db.Abolish(indicator)
+	if arity := head.Arity(); arity < smallThreshold {
+		m.smallForeign[arity] = m.smallForeign[arity].Delete(head.Name())
+	} else {
+		m.largeForeign = m.largeForeign.Delete(indicator)
+	}
+	m.library = m.library.Delete(indicator)
+	m.sources = m.sources.Delete(indicator)
+}
+
+// dynamic returns a machine in which the named predicate is dynamic.
+// Consulted code may declare a library predicate dynamic, which removes
+// the library definition.  Other foreign predicates can't be dynamic.
+func (m *machine) dynamic(name string, arity int) *machine {
+	head := predicateHead(name, arity)
+	indicator := head.Indicator()
+	m1 := m.clone()
+	if _, ok := m.lookupForeign(head); ok {
+		if _, ok := m.library.Lookup(indicator); !ok {
+			pi := predicateIndicator(name, arity)
+			panic(PermissionError("modify", "static_procedure", pi))
+		}
+		m1.redefine(head)
+	}
+	m1.db = m1.db.Dynamic(indicator)
+	return m1
+}
+
+// consultDirective returns a machine with the effects of a directive
+// encountered while consulting.  Other directives are proved once, in a
+// separate machine, keeping only their non-backtrackable effects like
+// op/3 calls.  Directives for undefined predicates, like use_module/1,
+// are ignored.
+func (m *machine) consultDirective(goal Term) *machine {
+	var m1 ForeignReturn
+	switch goal.Indicator() {
+	case "dynamic/1":
+		m1 = BuiltinDynamic1(m, goal.(Callable).Arguments())
+	case "set_prolog_flag/2":
+		m1 = BuiltinSetPrologFlag2(m, goal.(Callable).Arguments())
+	case "op/3":
+		m1 = BuiltinOp3(m, goal.(Callable).Arguments())
+	default:
+		if !IsCallable(goal) || !m.isDefined(goal.(Callable)) {
+			return m
+		}
+		once := func(Bindings) bool { return false }
+		m1 = forEachSolution(m, goal, once).(*machine).carryGlobals(m)
+	}
+	return m1.(*machine)
+}
+
 func (m *machine) RegisterForeign(fs map[string]ForeignPredicate) Machine {
 	m1 := m.clone()
 	for indicator, f := range fs {
@@ -288,6 +607,16 @@ func (m *machine) RegisterForeign(fs map[string]ForeignPredicate) Machine {
 	return m1
 }
 
+// registerLibrary is like RegisterForeign but the foreign predicates
+// are library predicates which consulted code may redefine
+func (m *machine) registerLibrary(fs map[string]ForeignPredicate) Machine {
+	m1 := m.RegisterForeign(fs).(*machine)
+	for indicator := range fs {
+		m1.library = m1.library.Set(indicator, true)
+	}
+	return m1
+}
+
 func (m *machine) String() string {
 	var buf bytes.Buffer
 	fmt.Fprintf(&buf, "disjs:\n")
@@ -348,12 +677,26 @@ func (self *machine) ProveAll(goal interface{}) []Bindings {
 // advance the Golog machine one step closer to proving the goal at hand.
 // at the end of each invocation, the top item on the conjunctions stack
 // is the goal we should next try to prove.
-func (self *machine) Step() (Machine, Bindings, error) {
+func (self *machine) Step() (mOut Machine, answer Bindings, errOut error) {
 	var m Machine = self
 	var goal Callable
 	var err error
 	var cp ChoicePoint
 
+	// exceptions raised while stepping look for a matching catch/3
+	defer func() {
+		if x := recover(); x != nil {
+			ex, ok := x.(*Exception)
+			if !ok {
+				panic(x)
+			}
+			if goal != nil {
+				ex = errorContext(ex, goal)
+			}
+			mOut, answer, errOut = m.(*machine).throw(ex)
+		}
+	}()
+
 	//Debugf("stepping...\n%s\n", self)
 	if false { // for debugging. commenting out needs import changes
 		_, _ = bufio.NewReader(os.Stdin).ReadString('\n')
@@ -380,7 +723,12 @@ func (self *machine) Step() (Machine, Bindings, error) {
 	// are we proving a foreign predicate?
 	f, ok := m.(*machine).lookupForeign(goal)
 	if ok { // foreign predicate
-		args := m.(*machine).resolveAllArguments(goal)
+		var args []Term
+		if wantsShallowArguments(goal) {
+			args = m.(*machine).derefAllArguments(goal)
+		} else {
+			args = m.(*machine).resolveAllArguments(goal)
+		}
 		Debugf("  running foreign predicate %s with %s\n", goal, args)
 		ret := f(m, args)
 		switch x := ret.(type) {
@@ -409,7 +757,9 @@ func (self *machine) Step() (Machine, Bindings, error) {
 		goal = goal.ReplaceVariables(m.Bindings()).(Callable)
 		Debugf("  running user-defined predicate %s\n", goal)
 		clauses, err := m.(*machine).db.Candidates(goal)
-		MaybePanic(err)
+		if err != nil {
+			clauses = m.(*machine).unknownProcedure(goal, err)
+		}
 		m = m.DemandCutBarrier()
 		for i := len(clauses) - 1; i >= 0; i-- {
 			clause := clauses[i]
@@ -420,20 +770,29 @@ func (self *machine) Step() (Machine, Bindings, error) {
 
 	// iterate disjunctions looking for one that succeeds
 	for {
-		cp, m, err = m.PopDisj()
+		var mTmp Machine
+		cp, mTmp, err = m.PopDisj()
 		if err == EmptyDisjunctions { // nothing left to do
 			Debugf("Stopping because of EmptyDisjunctions\n")
-			return nil, nil, MachineDone
+			return m, nil, MachineDone
 		}
 		MaybePanic(err)
+		m = mTmp
+
+		// a resumed foreign predicate starts from the current
+		// non-backtrackable state, so it carries that state itself
+		if r, ok := cp.(*resumeCP); ok {
+			Debugf("  resuming %s\n", cp)
+			return r.resume(m), nil, nil
+		}
 
 		// follow the next choice point
 		Debugf("  trying to follow CP %s\n", cp)
-		mTmp, err := cp.Follow()
+		mTmp, err = cp.Follow()
 		switch err {
 		case nil:
 			Debugf("  ... followed\n")
-			return mTmp, nil, nil
+			return m.(*machine).carryGlobals(mTmp), nil, nil
 		case CantUnify:
 			Debugf("  ... couldn't unify\n")
 			continue
@@ -445,6 +804,88 @@ func (self *machine) Step() (Machine, Bindings, error) {
 	}
 }
 
+// unknownProcedure decides what happens when goal calls a procedure
+// which has never been defined.  The unknown flag chooses between
+// raising err, printing a warning and failing, or silently failing.
+func (m *machine) unknownProcedure(goal Callable, err error) []Term {
+	switch m.flag("unknown").(*Atom).Name() {
+	case "fail":
+		return nil
+	case "warning":
+		name := QuoteFunctor(goal.Name())
+		msg := fmt.Sprintf("Warning: Unknown procedure: %s/%d\n", name, goal.Arity())
+		mustStream(m, NewAtom("user_error")).write([]byte(msg))
+		return nil
+	}
+	panic(err)
+}
+
+// throw unwinds the disjunction stack looking for an active catch/3
+// whose catcher unifies with the exception's ball.  If one is found,
+// the machine resumes from that catch/3 call by running its recovery
+// goal.  Otherwise, the exception is returned as an error.
+func (m *machine) throw(ex *Exception) (Machine, Bindings, error) {
+	ball := RenameVariables(ex.Ball())
+	Debugf("  throwing %s\n", ball)
+	for ds := m.disjs; !ds.IsNil(); ds = ds.Tail() {
+		cp, ok := ds.Head().(*catchCP)
+		if !ok {
+			continue
+		}
+		if _, exited := m.exitedCatches.Lookup(strconv.FormatInt(cp.id, 10)); exited {
+			continue
+		}
+
+		env, err := cp.catcher.Unify(cp.machine.Bindings(), ball)
+		if err == CantUnify {
+			continue
+		}
+		MaybePanic(err)
+		Debugf("  ... caught by %s\n", cp)
+		recovery := NewCallable("call", cp.recovery)
+		return m.carryGlobals(cp.machine.SetBindings(env).PushConj(recovery)), nil, nil
+	}
+	return nil, nil, ex
+}
+
+// exitCatch indicates that the goal of a catch/3 call has succeeded,
+// so its catcher should no longer intercept exceptions.
+func (m *machine) exitCatch(id int64) Machine {
+	// a deterministic goal leaves the catch choice point (and perhaps
+	// cut barriers) on top of the stack.  just remove them.
+	for ds := m.disjs; !ds.IsNil(); ds = ds.Tail() {
+		cp := ds.Head().(ChoicePoint)
+		if _, ok := BarrierId(cp); ok {
+			continue
+		}
+		if x, ok := cp.(*catchCP); ok && x.id == id {
+			m1 := m.clone()
+			m1.disjs = ds.Tail()
+			return m1
+		}
+		break
+	}
+
+	// the goal left choice points behind. backtracking into them
+	// reactivates the catcher, so just remember that we've exited
+	m1 := m.clone()
+	m1.exitedCatches = m.exitedCatches.Set(strconv.FormatInt(id, 10), true)
+	return m1
+}
+
+// errorContext fills in the context of an ISO error term,
+// error(Formal, Context), raised by a builtin.  Balls thrown by throw/1
+// are left alone.
+func errorContext(ex *Exception, goal Callable) *Exception {
+	ball, ok := ex.Ball().(*Compound)
+	if !ex.WantsContext() || !ok || ball.Indicator() != "error/2" || !IsVariable(ball.Args[1]) {
+		return ex
+	}
+	indicator := NewCallable("/", NewAtom(goal.Name()), NewInt64(int64(goal.Arity())))
+	context := NewCallable("context", indicator, NewVar("_"))
+	return NewException(NewCallable("error", ball.Args[0], context))
+}
+
 func (m *machine) lookupForeign(goal Callable) (ForeignPredicate, bool) {
 	var f interface{}
 	var ok bool
@@ -473,6 +914,40 @@ func (m *machine) toGoal(thing interface{}) Callable {
 	panic(msg)
 }
 
+// wantsShallowArguments returns true if goal is a foreign predicate
+// whose arguments should be dereferenced rather than fully resolved.
+// Such predicates see compound terms exactly as they're bound, rather
+// than copies with their variables replaced.  Control constructs don't
+// need resolved arguments.  setarg/3 and $distinct/2 must change the
+// original term.
+func wantsShallowArguments(goal Callable) bool {
+	switch goal.Arity() {
+	case 2:
+		switch goal.Name() {
+		case ",", ";", "->", "$distinct":
+			return true
+		}
+	case 3:
+		switch goal.Name() {
+		case "setarg", "nb_setarg":
+			return true
+		}
+	}
+	return false
+}
+
+// derefAllArguments follows the bindings of each of goal's arguments
+// that's a variable.  Compound terms are returned as is.
+func (m *machine) derefAllArguments(goal Callable) []Term {
+	env := m.Bindings()
+	args := goal.Arguments()
+	derefed := make([]Term, len(args))
+	for i, arg := range args {
+		derefed[i] = deref(env, arg)
+	}
+	return derefed
+}
+
 func (m *machine) resolveAllArguments(goal Callable) []Term {
 	Debugf("resolving all arguments: %s\n", goal)
 	env := m.Bindings()
@@ -485,7 +960,7 @@ func (m *machine) resolveAllArguments(goal Callable) []Term {
 				resolved[i] = a
 				continue
 			}
-		} else if IsCompound(arg) {
+		} else if IsCompound(arg) || IsDict(arg) {
 			resolved[i] = arg.ReplaceVariables(env)
 			continue
 		}
@@ -496,7 +971,9 @@ func (m *machine) resolveAllArguments(goal Callable) []Term {
 }
 
 func (m *machine) readTerm(src interface{}) Term {
-	return read.Term_(src)
+	t, err := m.termReader(src).Next()
+	MaybePanic(err)
+	return t
 }
 
 func (m *machine) Bindings() Bindings {
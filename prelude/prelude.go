@@ -1,6 +1,6 @@
-// Defines the Prolog standard library. All prelude predicates are
-// implemented in pure Prolog.  Each variable in this package is a predicate
-// definition.  At init time, they're combined into a single string
+// Defines the Prolog standard library. Prelude predicates are written
+// in Prolog, though some call builtins which are implemented in Go.  Each
+// variable in this package is a predicate definition.  At init time, they're combined into a single string
 // in the Prelude var.
 package prelude
 
@@ -13,15 +13,337 @@ var Prelude string
 
 func init() {
 	Prelude = strings.Join([]string{
+		Append2,
+		Append3,
+		AssocToKeys2,
+		AssocToList2,
+		AssocToValues2,
+		CallNth2,
+		Caret2,
+		Delete3,
+		Distinct1,
+		Distinct2,
+		EmptyAssoc1,
+		Exclude3,
+		Flatten2,
+		Foldl4,
+		Foldl5,
+		Foldl6,
+		Foldl7,
+		Forall2,
+		GenAssoc3,
+		GetAssoc3,
 		Ignore1,
+		Include3,
+		Last2,
 		Length2,
+		Limit2,
+		ListToAssoc2,
+		ListToOrdSet2,
+		MapListToPairs3,
+		Maplist2,
+		Maplist3,
+		Maplist4,
+		Maplist5,
+		Maplist6,
+		Maplist7,
+		MaxAssoc3,
+		MaxMember2,
+		Member2,
 		Memberchk2,
+		MinAssoc3,
+		MinMember2,
+		Nth,
+		Offset2,
+		OrdAddElement3,
+		OrdDelElement3,
+		OrdDisjoint2,
+		OrdEmpty1,
+		OrdIntersect2,
+		OrdUnion2,
+		OrderBy2,
+		PairsKeys2,
+		PairsKeysValues3,
+		PairsValues2,
+		Partition4,
+		Partition5,
+		Permutation2,
 		Phrase2,
 		Phrase3,
-		Sort2,
+		Predsort3,
+		PutAssoc4,
+		Reverse2,
+		Select3,
+		Selectchk3,
+		Subtract3,
+		TransposePairs2,
+		Yall,
 	}, "\n\n")
 }
 
+// append(+ListOfLists, ?List) is nondet.
+//
+// Concatenates a list of lists.
+var Append2 = `
+append(ListOfLists, List) :-
+    '$append'(ListOfLists, List).
+
+'$append'([], []).
+'$append'([L|Ls], As) :-
+    append(L, Ws, As),
+    '$append'(Ls, Ws).
+`
+
+// append(?List1, ?List2, ?List1AndList2) is nondet.
+//
+// List1AndList2 is the concatenation of List1 and List2.
+var Append3 = `
+append([], L, L).
+append([H|T], L, [H|R]) :-
+    append(T, L, R).
+`
+
+// assoc_to_keys(+Assoc, -Keys) is det.
+//
+// Keys holds the keys of Assoc in ascending order.
+var AssocToKeys2 = `
+assoc_to_keys(Assoc, Keys) :-
+    '$assoc_to_keys'(Assoc, Keys, []).
+
+'$assoc_to_keys'(t, Keys, Keys).
+'$assoc_to_keys'(t(K,_,_,L,R), Keys, Rest) :-
+    '$assoc_to_keys'(L, Keys, [K|More]),
+    '$assoc_to_keys'(R, More, Rest).
+`
+
+// assoc_to_list(+Assoc, -Pairs) is det.
+//
+// Pairs holds the Key-Value pairs of Assoc in ascending order of keys.
+var AssocToList2 = `
+assoc_to_list(Assoc, Pairs) :-
+    '$assoc_to_list'(Assoc, Pairs, []).
+
+'$assoc_to_list'(t, Pairs, Pairs).
+'$assoc_to_list'(t(K,V,_,L,R), Pairs, Rest) :-
+    '$assoc_to_list'(L, Pairs, [K-V|More]),
+    '$assoc_to_list'(R, More, Rest).
+`
+
+// assoc_to_values(+Assoc, -Values) is det.
+//
+// Values holds the values of Assoc in ascending order of their keys.
+var AssocToValues2 = `
+assoc_to_values(Assoc, Values) :-
+    '$assoc_to_values'(Assoc, Values, []).
+
+'$assoc_to_values'(t, Values, Values).
+'$assoc_to_values'(t(_,V,_,L,R), Values, Rest) :-
+    '$assoc_to_values'(L, Values, [V|More]),
+    '$assoc_to_values'(R, More, Rest).
+`
+
+// call_nth(:Goal, ?Nth) is nondet.
+//
+// True when Goal succeeded for the Nth time.  If Nth is bound, only
+// that solution is found and Goal is cut afterwards.
+var CallNth2 = `
+call_nth(Goal, Nth) :-
+    var(Nth),
+    !,
+    State = count(0),
+    call(Goal),
+    arg(1, State, N0),
+    Nth is N0 + 1,
+    nb_setarg(1, State, Nth).
+call_nth(Goal, Nth) :-
+    '$solution_count'(Nth, call_nth/2),
+    Nth > 0,
+    !,
+    (   call_nth(Goal, Sofar),
+        Sofar =:= Nth
+    ->  true
+    ).
+call_nth(_, Nth) :-
+    throw(error(domain_error(not_less_than_one, Nth), context(call_nth/2, _))).
+`
+
+// ^(+Var, :Goal) is nondet.
+//
+// Proves Goal.  The variables in Var are existentially quantified
+// by bagof/3 and setof/3.
+var Caret2 = `
+_ ^ Goal :-
+    call(Goal).
+`
+
+// delete(+List, @Elem, -Rest) is det.
+//
+// Removes every element of List which unifies with Elem.
+var Delete3 = `
+delete([], _, []).
+delete([Elem|Tail], Del, Result) :-
+    (   \+ \+ Elem = Del
+    ->  delete(Tail, Del, Result)
+    ;   Result = [Elem|Tail1],
+        delete(Tail, Del, Tail1)
+    ).
+`
+
+// distinct(:Goal) is nondet.
+//
+// Like Goal but removes solutions which are variants of an earlier
+// solution.
+var Distinct1 = `
+distinct(Goal) :-
+    distinct(Goal, Goal).
+`
+
+// distinct(?Witness, :Goal) is nondet.
+//
+// Like Goal but removes solutions whose Witness is a variant of an
+// earlier solution's Witness.
+var Distinct2 = `
+distinct(Witness, Goal) :-
+    State = seen([]),
+    call(Goal),
+    '$distinct'(State, Witness).
+`
+
+// empty_assoc(?Assoc) is semidet.
+//
+// Assoc is an empty association list.  Association lists are AVL trees
+// where t is the empty tree and t(Key,Value,Balance,Left,Right) is a
+// node.  Balance is <, = or > as Left is shorter than, as tall as or
+// taller than Right.
+var EmptyAssoc1 = `
+empty_assoc(t).
+`
+
+// exclude(:Goal, +List, -Excluded) is det.
+//
+// Excluded holds the elements of List for which call(Goal, Elem)
+// fails.
+var Exclude3 = `
+exclude(Goal, List, Excluded) :-
+    '$exclude'(List, Goal, Excluded).
+
+'$exclude'([], _, []).
+'$exclude'([L|Ls], Goal, Excluded) :-
+    (   call(Goal, L)
+    ->  Excluded = Excluded1
+    ;   Excluded = [L|Excluded1]
+    ),
+    '$exclude'(Ls, Goal, Excluded1).
+`
+
+// flatten(+NestedList, -FlatList) is det.
+//
+// Removes all nesting from NestedList.  Variables and other non-list
+// terms become elements of FlatList.
+var Flatten2 = `
+flatten(List, Flat) :-
+    '$flatten'(List, [], Flat0),
+    !,
+    Flat = Flat0.
+
+'$flatten'(Var, Tail, [Var|Tail]) :-
+    var(Var),
+    !.
+'$flatten'([], Tail, Tail) :- !.
+'$flatten'([H|T], Tail, List) :-
+    !,
+    '$flatten'(H, FlatHeadTail, List),
+    '$flatten'(T, Tail, FlatHeadTail).
+'$flatten'(NonList, Tail, [NonList|Tail]).
+`
+
+// foldl(:Goal, +List1, +V0, -V) is nondet.
+//
+// Folds List1 from the left.  Goal is called as call(Goal, Elem, V0, V1)
+// for each element, threading the value from V0 through to V.
+var Foldl4 = `
+foldl(Goal, L1, V0, V) :-
+    '$foldl'(L1, Goal, V0, V).
+
+'$foldl'([], _, V, V).
+'$foldl'([X1|Xs1], Goal, V0, V) :-
+    call(Goal, X1, V0, V1),
+    '$foldl'(Xs1, Goal, V1, V).
+`
+
+// foldl(:Goal, +List1, +List2, +V0, -V) is nondet.
+//
+// Like foldl/4 but Goal is called with an element from each list.
+var Foldl5 = `
+foldl(Goal, L1, L2, V0, V) :-
+    '$foldl'(L1, L2, Goal, V0, V).
+
+'$foldl'([], [], _, V, V).
+'$foldl'([X1|Xs1], [X2|Xs2], Goal, V0, V) :-
+    call(Goal, X1, X2, V0, V1),
+    '$foldl'(Xs1, Xs2, Goal, V1, V).
+`
+
+// foldl(:Goal, +List1, +List2, +List3, +V0, -V) is nondet.
+//
+// Like foldl/5 with three lists.
+var Foldl6 = `
+foldl(Goal, L1, L2, L3, V0, V) :-
+    '$foldl'(L1, L2, L3, Goal, V0, V).
+
+'$foldl'([], [], [], _, V, V).
+'$foldl'([X1|Xs1], [X2|Xs2], [X3|Xs3], Goal, V0, V) :-
+    call(Goal, X1, X2, X3, V0, V1),
+    '$foldl'(Xs1, Xs2, Xs3, Goal, V1, V).
+`
+
+// foldl(:Goal, +List1, +List2, +List3, +List4, +V0, -V) is nondet.
+//
+// Like foldl/5 with four lists.
+var Foldl7 = `
+foldl(Goal, L1, L2, L3, L4, V0, V) :-
+    '$foldl'(L1, L2, L3, L4, Goal, V0, V).
+
+'$foldl'([], [], [], [], _, V, V).
+'$foldl'([X1|Xs1], [X2|Xs2], [X3|Xs3], [X4|Xs4], Goal, V0, V) :-
+    call(Goal, X1, X2, X3, X4, V0, V1),
+    '$foldl'(Xs1, Xs2, Xs3, Xs4, Goal, V1, V).
+`
+
+// forall(:Cond, :Action) is semidet.
+//
+// True if Action succeeds for every solution of Cond.
+var Forall2 = `
+forall(Cond, Action) :-
+    \+ (Cond, \+ Action).
+`
+
+// gen_assoc(?Key, +Assoc, ?Value) is nondet.
+//
+// Enumerates the Key-Value pairs of Assoc in ascending order of keys.
+var GenAssoc3 = `
+gen_assoc(Key, t(_,_,_,L,_), Value) :-
+    gen_assoc(Key, L, Value).
+gen_assoc(Key, t(Key,Value,_,_,_), Value).
+gen_assoc(Key, t(_,_,_,_,R), Value) :-
+    gen_assoc(Key, R, Value).
+`
+
+// get_assoc(+Key, +Assoc, -Value) is semidet.
+//
+// Value is associated with Key in Assoc.
+var GetAssoc3 = `
+get_assoc(Key, t(K,V,_,L,R), Value) :-
+    compare(Order, Key, K),
+    '$get_assoc'(Order, Key, V, L, R, Value).
+
+'$get_assoc'(=, _, V, _, _, V).
+'$get_assoc'(<, Key, _, L, _, V) :-
+    get_assoc(Key, L, V).
+'$get_assoc'(>, Key, _, _, R, V) :-
+    get_assoc(Key, R, V).
+`
+
 var Ignore1 = `
 ignore(A) :-
 	call(A),
@@ -29,15 +351,227 @@ ignore(A) :-
 ignore(_).
 `
 
+// include(:Goal, +List, -Included) is det.
+//
+// Included holds the elements of List for which call(Goal, Elem)
+// succeeds.
+var Include3 = `
+include(Goal, List, Included) :-
+    '$include'(List, Goal, Included).
+
+'$include'([], _, []).
+'$include'([L|Ls], Goal, Included) :-
+    (   call(Goal, L)
+    ->  Included = [L|Included1]
+    ;   Included = Included1
+    ),
+    '$include'(Ls, Goal, Included1).
+`
+
+// last(?List, ?Last) is semidet.
+//
+// Last is the final element of List.
+var Last2 = `
+last([X|Xs], Last) :-
+    '$last'(Xs, X, Last).
+
+'$last'([], Last, Last).
+'$last'([X|Xs], _, Last) :-
+    '$last'(Xs, X, Last).
+`
+
+// '$length'(?List, +Length0, ?Length) is nondet.
+//
+// Enumerates ever longer lists for length/2, which is otherwise
+// implemented in Go.  Length0 counts elements already seen.
 var Length2 = `
-length(Xs, N) :-
-	length(Xs, 0, N).
+'$length'([], N, N).
+'$length'([_|T], N0, N) :-
+    N1 is N0 + 1,
+    '$length'(T, N1, N).
+`
+
+// limit(+Count, :Goal) is nondet.
+//
+// Like Goal but finds at most Count solutions.  Goal is cut after the
+// last one.  Count may be the atom infinite.
+var Limit2 = `
+limit(Count, Goal) :-
+    Count == infinite,
+    !,
+    call(Goal).
+limit(Count, Goal) :-
+    '$solution_count'(Count, limit/2),
+    Count > 0,
+    State = count(0),
+    call(Goal),
+    arg(1, State, N0),
+    N is N0 + 1,
+    (   N =:= Count
+    ->  !
+    ;   nb_setarg(1, State, N)
+    ).
+`
+
+// list_to_assoc(+Pairs, -Assoc) is det.
+//
+// Assoc holds the Key-Value pairs in Pairs.  Raises a domain error if
+// a key appears more than once.
+var ListToAssoc2 = `
+list_to_assoc(Pairs, Assoc) :-
+    must_be(list, Pairs),
+    '$list_to_assoc'(Pairs, t, Assoc).
+
+'$list_to_assoc'([], Assoc, Assoc).
+'$list_to_assoc'([K-V|Pairs], Assoc0, Assoc) :-
+    (   get_assoc(K, Assoc0, _)
+    ->  throw(error(domain_error(unique_key_pairs, [K-V|Pairs]), _))
+    ;   put_assoc(K, Assoc0, V, Assoc1),
+        '$list_to_assoc'(Pairs, Assoc1, Assoc)
+    ).
+`
+
+// list_to_ord_set(+List, -OrdSet) is det.
+//
+// OrdSet holds the elements of List as an ordered set.
+var ListToOrdSet2 = `
+list_to_ord_set(List, Set) :-
+    sort(List, Set).
+`
+
+// map_list_to_pairs(:Function, +List, -Keyed) is det.
+//
+// Keyed holds a Key-Elem pair for each element of List, where Key is
+// computed by call(Function, Elem, Key).
+var MapListToPairs3 = `
+map_list_to_pairs(Function, List, Pairs) :-
+    '$map_list_to_pairs'(List, Function, Pairs).
+
+'$map_list_to_pairs'([], _, []).
+'$map_list_to_pairs'([H|T0], Function, [K-H|T]) :-
+    call(Function, H, K),
+    '$map_list_to_pairs'(T0, Function, T).
+`
+
+// maplist(:Goal, ?List1) is nondet.
+//
+// True if call(Goal, Elem) succeeds for each element of List1.
+var Maplist2 = `
+maplist(Goal, L1) :-
+    '$maplist'(L1, Goal).
+
+'$maplist'([], _).
+'$maplist'([E1|Es1], Goal) :-
+    call(Goal, E1),
+    '$maplist'(Es1, Goal).
+`
+
+// maplist(:Goal, ?List1, ?List2) is nondet.
+//
+// Like maplist/2 but the lists have equal length and Goal is called
+// with an element from each list.
+var Maplist3 = `
+maplist(Goal, L1, L2) :-
+    '$maplist'(L1, L2, Goal).
+
+'$maplist'([], [], _).
+'$maplist'([E1|Es1], [E2|Es2], Goal) :-
+    call(Goal, E1, E2),
+    '$maplist'(Es1, Es2, Goal).
+`
+
+// maplist(:Goal, ?List1, ?List2, ?List3) is nondet.
+//
+// Like maplist/3 with three lists.
+var Maplist4 = `
+maplist(Goal, L1, L2, L3) :-
+    '$maplist'(L1, L2, L3, Goal).
+
+'$maplist'([], [], [], _).
+'$maplist'([E1|Es1], [E2|Es2], [E3|Es3], Goal) :-
+    call(Goal, E1, E2, E3),
+    '$maplist'(Es1, Es2, Es3, Goal).
+`
+
+// maplist(:Goal, ?List1, ?List2, ?List3, ?List4) is nondet.
+//
+// Like maplist/3 with four lists.
+var Maplist5 = `
+maplist(Goal, L1, L2, L3, L4) :-
+    '$maplist'(L1, L2, L3, L4, Goal).
+
+'$maplist'([], [], [], [], _).
+'$maplist'([E1|Es1], [E2|Es2], [E3|Es3], [E4|Es4], Goal) :-
+    call(Goal, E1, E2, E3, E4),
+    '$maplist'(Es1, Es2, Es3, Es4, Goal).
+`
+
+// maplist(:Goal, ?List1, ?List2, ?List3, ?List4, ?List5) is nondet.
+//
+// Like maplist/3 with five lists.
+var Maplist6 = `
+maplist(Goal, L1, L2, L3, L4, L5) :-
+    '$maplist'(L1, L2, L3, L4, L5, Goal).
+
+'$maplist'([], [], [], [], [], _).
+'$maplist'([E1|Es1], [E2|Es2], [E3|Es3], [E4|Es4], [E5|Es5], Goal) :-
+    call(Goal, E1, E2, E3, E4, E5),
+    '$maplist'(Es1, Es2, Es3, Es4, Es5, Goal).
+`
+
+// maplist(:Goal, ?List1, ?List2, ?List3, ?List4, ?List5, ?List6) is nondet.
+//
+// Like maplist/3 with six lists.
+var Maplist7 = `
+maplist(Goal, L1, L2, L3, L4, L5, L6) :-
+    '$maplist'(L1, L2, L3, L4, L5, L6, Goal).
 
-length([], N, N) :- !.
-length([_|T], N0, N) :-
-	% N0 \= N
-	succ(N0, N1),
-	length(T, N1, N).
+'$maplist'([], [], [], [], [], [], _).
+'$maplist'([E1|Es1], [E2|Es2], [E3|Es3], [E4|Es4], [E5|Es5], [E6|Es6], Goal) :-
+    call(Goal, E1, E2, E3, E4, E5, E6),
+    '$maplist'(Es1, Es2, Es3, Es4, Es5, Es6, Goal).
+`
+
+// max_assoc(+Assoc, -Key, -Value) is semidet.
+//
+// Key and Value are the pair in Assoc with the largest key.
+var MaxAssoc3 = `
+max_assoc(t(K,V,_,_,R), Key, Value) :-
+    '$max_assoc'(R, K, V, Key, Value).
+
+'$max_assoc'(t, K, V, K, V).
+'$max_assoc'(t(K,V,_,_,R), _, _, Key, Value) :-
+    '$max_assoc'(R, K, V, Key, Value).
+`
+
+// max_member(-Max, +List) is semidet.
+//
+// Max is the largest element of List in the standard order of terms.
+// Fails if List is empty.
+var MaxMember2 = `
+max_member(Max, [H|T]) :-
+    '$max_member'(T, H, Max).
+
+'$max_member'([], Max, Max).
+'$max_member'([H|T], Max0, Max) :-
+    (   H @=< Max0
+    ->  Max1 = Max0
+    ;   Max1 = H
+    ),
+    '$max_member'(T, Max1, Max).
+`
+
+// member(?Elem, ?List) is nondet.
+//
+// True if Elem is an element of List.  No choice point remains after
+// the last element.
+var Member2 = `
+member(X, [H|T]) :-
+    '$member'(T, X, H).
+
+'$member'(_, X, X).
+'$member'([H|T], X, _) :-
+    '$member'(T, X, H).
 `
 
 var Memberchk2 = `
@@ -46,6 +580,269 @@ memberchk(X,[_|T]) :-
     memberchk(X,T).
 `
 
+// min_assoc(+Assoc, -Key, -Value) is semidet.
+//
+// Key and Value are the pair in Assoc with the smallest key.
+var MinAssoc3 = `
+min_assoc(t(K,V,_,L,_), Key, Value) :-
+    '$min_assoc'(L, K, V, Key, Value).
+
+'$min_assoc'(t, K, V, K, V).
+'$min_assoc'(t(K,V,_,L,_), _, _, Key, Value) :-
+    '$min_assoc'(L, K, V, Key, Value).
+`
+
+// min_member(-Min, +List) is semidet.
+//
+// Min is the smallest element of List in the standard order of terms.
+// Fails if List is empty.
+var MinMember2 = `
+min_member(Min, [H|T]) :-
+    '$min_member'(T, H, Min).
+
+'$min_member'([], Min, Min).
+'$min_member'([H|T], Min0, Min) :-
+    (   H @>= Min0
+    ->  Min1 = Min0
+    ;   Min1 = H
+    ),
+    '$min_member'(T, Min1, Min).
+`
+
+// '$nth'(?List, +Index0, ?Index, ?Elem) is nondet.
+//
+// Enumerates the elements of List, and their positions, for nth0/3 and
+// nth1/3 when the index is unbound.  Index0 is the position of the first
+// element.
+var Nth = `
+'$nth'([Elem|_], Index, Index, Elem).
+'$nth'([_|Tail], Index0, Index, Elem) :-
+    Index1 is Index0 + 1,
+    '$nth'(Tail, Index1, Index, Elem).
+`
+
+// offset(+Count, :Goal) is nondet.
+//
+// Like Goal but ignores its first Count solutions.
+var Offset2 = `
+offset(Count, Goal) :-
+    '$solution_count'(Count, offset/2),
+    Count > 0,
+    !,
+    State = count(0),
+    call(Goal),
+    arg(1, State, N0),
+    (   N0 >= Count
+    ->  true
+    ;   N is N0 + 1,
+        nb_setarg(1, State, N),
+        fail
+    ).
+offset(Count, Goal) :-
+    Count =:= 0,
+    !,
+    call(Goal).
+offset(Count, _) :-
+    throw(error(domain_error(not_less_than_zero, Count), context(offset/2, _))).
+
+% Count must be an integer.  Errors come from the Context predicate
+'$solution_count'(Count, Context) :-
+    catch(must_be(integer, Count), error(E, _), throw(error(E, context(Context, _)))).
+`
+
+// ord_add_element(+Set1, +Elem, -Set2) is det.
+//
+// Set2 is Set1 with Elem added.
+var OrdAddElement3 = `
+ord_add_element(Set1, Elem, Set2) :-
+    ord_union(Set1, [Elem], Set2).
+`
+
+// ord_del_element(+Set1, +Elem, -Set2) is det.
+//
+// Set2 is Set1 without Elem.
+var OrdDelElement3 = `
+ord_del_element(Set1, Elem, Set2) :-
+    ord_subtract(Set1, [Elem], Set2).
+`
+
+// ord_disjoint(+Set1, +Set2) is semidet.
+//
+// True if Set1 and Set2 have no elements in common.
+var OrdDisjoint2 = `
+ord_disjoint(Set1, Set2) :-
+    ord_intersection(Set1, Set2, []).
+`
+
+// ord_empty(?Set) is semidet.
+//
+// True if Set is the empty ordered set.
+var OrdEmpty1 = `
+ord_empty([]).
+`
+
+// ord_intersect(+Set1, +Set2) is semidet.
+//
+// True if Set1 and Set2 have at least one element in common.
+var OrdIntersect2 = `
+ord_intersect(Set1, Set2) :-
+    ord_intersection(Set1, Set2, [_|_]).
+`
+
+// ord_union(+SetOfSets, -Set) is det.
+//
+// Set is the union of all the ordered sets in SetOfSets.
+var OrdUnion2 = `
+ord_union(Sets, Set) :-
+    append(Sets, Elems),
+    sort(Elems, Set).
+`
+
+// order_by(+Specs, :Goal) is nondet.
+//
+// Like Goal but finds solutions in the order given by Specs, a list of
+// asc(Key) or desc(Key) terms.  All solutions are found before the
+// first one is returned.
+var OrderBy2 = `
+order_by(Specs, _) :-
+    Specs == [],
+    !,
+    throw(error(domain_error(non_empty_list, []), context(order_by/2, _))).
+order_by(Specs, Goal) :-
+    '$order_by_specs'(Specs, Keys, Orders),
+    Template =.. [o, Goal|Keys],
+    findall(Template, Goal, Results0),
+    '$order_by_sort'(Orders, 2, Results0, Results),
+    '$order_by_result'(Template, Results).
+
+'$order_by_specs'(Specs, _, _) :-
+    var(Specs),
+    !,
+    throw(error(instantiation_error, context(order_by/2, _))).
+'$order_by_specs'([], [], []) :- !.
+'$order_by_specs'([Spec|Specs], [Key|Keys], [Order|Orders]) :-
+    !,
+    '$order_by_spec'(Spec, Key, Order),
+    '$order_by_specs'(Specs, Keys, Orders).
+'$order_by_specs'(Specs, _, _) :-
+    throw(error(type_error(list, Specs), context(order_by/2, _))).
+
+'$order_by_spec'(Spec, _, _) :-
+    var(Spec),
+    !,
+    throw(error(instantiation_error, context(order_by/2, _))).
+'$order_by_spec'(asc(Key), Key, @=<) :- !.
+'$order_by_spec'(desc(Key), Key, @>=) :- !.
+'$order_by_spec'(Spec, _, _) :-
+    throw(error(domain_error(order_specifier, Spec), context(order_by/2, _))).
+
+'$order_by_sort'([], _, Results, Results).
+'$order_by_sort'([Order|Orders], I, Results0, Results) :-
+    I1 is I + 1,
+    '$order_by_sort'(Orders, I1, Results0, Results1),
+    sort(I, Order, Results1, Results).
+
+'$order_by_result'(Template, [Template|_]).
+'$order_by_result'(Template, [_|Results]) :-
+    '$order_by_result'(Template, Results).
+`
+
+// pairs_keys(?Pairs, ?Keys) is det.
+//
+// Keys holds the keys of the Key-Value pairs in Pairs.
+var PairsKeys2 = `
+pairs_keys([], []).
+pairs_keys([K-_|T0], [K|T]) :-
+    pairs_keys(T0, T).
+`
+
+// pairs_keys_values(?Pairs, ?Keys, ?Values) is det.
+//
+// Pairs holds the Key-Value pairs built from corresponding elements of
+// Keys and Values.
+var PairsKeysValues3 = `
+pairs_keys_values([], [], []).
+pairs_keys_values([K-V|Pairs], [K|Keys], [V|Values]) :-
+    pairs_keys_values(Pairs, Keys, Values).
+`
+
+// pairs_values(?Pairs, ?Values) is det.
+//
+// Values holds the values of the Key-Value pairs in Pairs.
+var PairsValues2 = `
+pairs_values([], []).
+pairs_values([_-V|T0], [V|T]) :-
+    pairs_values(T0, T).
+`
+
+// partition(:Pred, +List, -Included, -Excluded) is det.
+//
+// Splits List into the elements for which call(Pred, Elem) succeeds
+// and those for which it fails.
+var Partition4 = `
+partition(Pred, List, Included, Excluded) :-
+    '$partition'(List, Pred, Included, Excluded).
+
+'$partition'([], _, [], []).
+'$partition'([H|T], Pred, Included, Excluded) :-
+    (   call(Pred, H)
+    ->  Included = [H|Included1],
+        Excluded = Excluded1
+    ;   Included = Included1,
+        Excluded = [H|Excluded1]
+    ),
+    '$partition'(T, Pred, Included1, Excluded1).
+`
+
+// partition(:Pred, +List, -Less, -Equal, -Greater) is semidet.
+//
+// Splits List in three using call(Pred, Elem, Order), where Order is
+// one of <, = or >.
+var Partition5 = `
+partition(Pred, List, Less, Equal, Greater) :-
+    '$partition'(List, Pred, Less, Equal, Greater).
+
+'$partition'([], _, [], [], []).
+'$partition'([H|T], Pred, L, E, G) :-
+    call(Pred, H, Order),
+    '$partition'(Order, H, Pred, T, L, E, G).
+
+'$partition'(Order, _, _, _, _, _, _) :-
+    var(Order),
+    !,
+    throw(error(instantiation_error, _)).
+'$partition'(<, H, Pred, T, [H|L], E, G) :-
+    !,
+    '$partition'(T, Pred, L, E, G).
+'$partition'(=, H, Pred, T, L, [H|E], G) :-
+    !,
+    '$partition'(T, Pred, L, E, G).
+'$partition'(>, H, Pred, T, L, E, [H|G]) :-
+    !,
+    '$partition'(T, Pred, L, E, G).
+'$partition'(Order, _, _, _, _, _, _) :-
+    throw(error(domain_error(order, Order), _)).
+`
+
+// permutation(?Xs, ?Ys) is nondet.
+//
+// Ys is a permutation of Xs.  If both are partial lists, permutations
+// of increasing length are enumerated.
+var Permutation2 = `
+permutation(Xs, Ys) :-
+    '$same_length'(Xs, Ys),
+    '$permutation'(Xs, Ys).
+
+'$same_length'([], []).
+'$same_length'([_|T1], [_|T2]) :-
+    '$same_length'(T1, T2).
+
+'$permutation'([], []).
+'$permutation'(List, [First|Perm]) :-
+    select(First, List, Rest),
+    '$permutation'(Rest, Perm).
+`
+
 // phrase(:DCGBody, ?List, ?Rest) is nondet.
 //
 // True when DCGBody applies to the difference List/Rest.
@@ -62,22 +859,270 @@ phrase(Dcg, List) :-
     call(Dcg, List, []).
 `
 
-// sort(+List, -Sorted) is det.
-//
-// Like msort/2 but removes duplicates.
-var Sort2 = `
-sort(List, Sorted) :-
-	msort(List, Duplicates),
-	'$consolidate'(Duplicates, Sorted).
-
-% helper predicate that removes adjacent duplicates from a list
-'$consolidate'([], []).
-'$consolidate'([X], [X]).
-'$consolidate'([X,Y|Rest], Result) :-
-	( X = Y ->
-		'$consolidate'([Y|Rest], Result)
-	; % otherwise ->
-		'$consolidate'([Y|Rest], Tail),
-		Result = [X|Tail]
-	).
+// predsort(:Pred, +List, -Sorted) is det.
+//
+// Sorts List using call(Pred, Order, A, B) to compare elements.  Order
+// is one of <, = or >.  Elements which compare as = are removed.
+var Predsort3 = `
+predsort(P, L, Sorted) :-
+	length(L, N),
+	predsort(P, N, L, _, Sorted1),
+	!,
+	Sorted = Sorted1.
+
+predsort(P, 2, [X1,X2|L], L, R) :-
+	!,
+	call(P, Delta, X1, X2),
+	'$predsort2'(Delta, X1, X2, R).
+predsort(_, 1, [X|L], L, [X]) :- !.
+predsort(_, 0, L, L, []) :- !.
+predsort(P, N, L1, L3, R) :-
+	N1 is N // 2,
+	N2 is N - N1,
+	predsort(P, N1, L1, L2, R1),
+	predsort(P, N2, L2, L3, R2),
+	'$predmerge'(P, R1, R2, R).
+
+'$predsort2'(<, X1, X2, [X1,X2]).
+'$predsort2'(=, X1, _,  [X1]).
+'$predsort2'(>, X1, X2, [X2,X1]).
+
+'$predmerge'(_, [], R, R) :- !.
+'$predmerge'(_, R, [], R) :- !.
+'$predmerge'(P, [H1|T1], [H2|T2], Result) :-
+	call(P, Delta, H1, H2),
+	!,
+	'$predmerge'(Delta, P, H1, H2, T1, T2, Result).
+
+'$predmerge'(<, P, H1, H2, T1, T2, [H1|R]) :-
+	'$predmerge'(P, T1, [H2|T2], R).
+'$predmerge'(=, P, H1, _, T1, T2, [H1|R]) :-
+	'$predmerge'(P, T1, T2, R).
+'$predmerge'(>, P, H1, H2, T1, T2, [H2|R]) :-
+	'$predmerge'(P, [H1|T1], T2, R).
+`
+
+// put_assoc(+Key, +Assoc0, +Value, -Assoc) is det.
+//
+// Assoc is Assoc0 with Key associated with Value, replacing any value
+// Key had before.  The tree is rebalanced as needed, so this takes
+// logarithmic time.
+var PutAssoc4 = `
+put_assoc(Key, Assoc0, Value, Assoc) :-
+    '$put_assoc'(Assoc0, Key, Value, Assoc, _).
+
+% the last argument says whether the tree grew taller
+'$put_assoc'(t, K, V, t(K,V,=,t,t), yes).
+'$put_assoc'(t(K0,V0,B,L,R), K, V, Assoc, Grew) :-
+    compare(Order, K, K0),
+    '$put_assoc'(Order, t(K0,V0,B,L,R), K, V, Assoc, Grew).
+
+'$put_assoc'(=, t(K0,_,B,L,R), _, V, t(K0,V,B,L,R), no).
+'$put_assoc'(<, t(K0,V0,B,L,R), K, V, Assoc, Grew) :-
+    '$put_assoc'(L, K, V, L1, LeftGrew),
+    '$assoc_left_grew'(LeftGrew, K0, V0, B, L1, R, Assoc, Grew).
+'$put_assoc'(>, t(K0,V0,B,L,R), K, V, Assoc, Grew) :-
+    '$put_assoc'(R, K, V, R1, RightGrew),
+    '$assoc_right_grew'(RightGrew, K0, V0, B, L, R1, Assoc, Grew).
+
+'$assoc_left_grew'(no, K, V, B, L, R, t(K,V,B,L,R), no).
+'$assoc_left_grew'(yes, K, V, <, L, R, t(K,V,=,L,R), no).
+'$assoc_left_grew'(yes, K, V, =, L, R, t(K,V,>,L,R), yes).
+'$assoc_left_grew'(yes, K, V, >, L, R, Assoc, no) :-
+    '$assoc_rotate_right'(K, V, L, R, Assoc).
+
+'$assoc_right_grew'(no, K, V, B, L, R, t(K,V,B,L,R), no).
+'$assoc_right_grew'(yes, K, V, >, L, R, t(K,V,=,L,R), no).
+'$assoc_right_grew'(yes, K, V, =, L, R, t(K,V,<,L,R), yes).
+'$assoc_right_grew'(yes, K, V, <, L, R, Assoc, no) :-
+    '$assoc_rotate_left'(K, V, L, R, Assoc).
+
+'$assoc_rotate_right'(K, V, t(LK,LV,>,LL,LR), R, t(LK,LV,=,LL,t(K,V,=,LR,R))).
+'$assoc_rotate_right'(K, V, t(LK,LV,<,LL,t(MK,MV,MB,ML,MR)), R,
+                      t(MK,MV,=,t(LK,LV,B1,LL,ML),t(K,V,B2,MR,R))) :-
+    '$assoc_double_rotation'(MB, B1, B2).
+
+'$assoc_rotate_left'(K, V, L, t(RK,RV,<,RL,RR), t(RK,RV,=,t(K,V,=,L,RL),RR)).
+'$assoc_rotate_left'(K, V, L, t(RK,RV,>,t(MK,MV,MB,ML,MR),RR),
+                     t(MK,MV,=,t(K,V,B1,L,ML),t(RK,RV,B2,MR,RR))) :-
+    '$assoc_double_rotation'(MB, B1, B2).
+
+% balance of the middle node before a double rotation determines
+% the balance of the two nodes beneath it afterwards
+'$assoc_double_rotation'(>, =, <).
+'$assoc_double_rotation'(=, =, =).
+'$assoc_double_rotation'(<, >, =).
+`
+
+// '$reverse'(?List, +Reversed0, ?Reversed, ?Bound) is nondet.
+//
+// Reverses a partial list for reverse/2, which handles proper lists
+// in Go.  Bound keeps the search from running past the length of
+// Reversed.
+var Reverse2 = `
+'$reverse'([], Ys, Ys, []).
+'$reverse'([X|Xs], Rs, Ys, [_|Bound]) :-
+    '$reverse'(Xs, [X|Rs], Ys, Bound).
+`
+
+// select(?Elem, ?List1, ?List2) is nondet.
+//
+// List2 is List1 with one occurrence of Elem removed.
+var Select3 = `
+select(X, [Head|Tail], Rest) :-
+    '$select'(Tail, Head, X, Rest).
+
+'$select'(Tail, Head, Head, Tail).
+'$select'([Head2|Tail], Head, X, [Head|Rest]) :-
+    '$select'(Tail, Head2, X, Rest).
+`
+
+// selectchk(+Elem, +List, -Rest) is semidet.
+//
+// Like select/3 but only finds the first solution.
+var Selectchk3 = `
+selectchk(Elem, List, Rest) :-
+    select(Elem, List, Rest0),
+    !,
+    Rest = Rest0.
+`
+
+// subtract(+Set, +Delete, -Result) is det.
+//
+// Result holds the elements of Set which aren't in Delete, as decided
+// by memberchk/2.
+var Subtract3 = `
+subtract([], _, []) :- !.
+subtract([E|T], D, R) :-
+    memberchk(E, D),
+    !,
+    subtract(T, D, R).
+subtract([H|T], D, [H|R]) :-
+    subtract(T, D, R).
+`
+
+// transpose_pairs(+Pairs, -Transposed) is det.
+//
+// Swaps the keys and values of Pairs, then sorts the result on its new
+// keys with keysort/2.
+var TransposePairs2 = `
+transpose_pairs(Pairs, Transposed) :-
+    '$flip_pairs'(Pairs, Flipped),
+    keysort(Flipped, Transposed).
+
+'$flip_pairs'([], []).
+'$flip_pairs'([K-V|Pairs], [V-K|Flipped]) :-
+    '$flip_pairs'(Pairs, Flipped).
+`
+
+// >>(+Params, :Lambda) is nondet.
+//
+// Calls Lambda with its parameters bound to extra arguments.
+// Free/[X1,...]>>Lambda and \X1^...^Lambda are called with extra
+// arguments which are bound to the parameters X1, ...  Arguments beyond
+// the parameters are appended to Lambda.  The lambda is copied before
+// each call, so its variables are local, except for those in Free.
+var Yall = `
+'>>'(Params, Lambda) :-
+    '$lambda'(Params>>Lambda, []).
+'>>'(Params, Lambda, A1) :-
+    '$lambda'(Params>>Lambda, [A1]).
+'>>'(Params, Lambda, A1, A2) :-
+    '$lambda'(Params>>Lambda, [A1, A2]).
+'>>'(Params, Lambda, A1, A2, A3) :-
+    '$lambda'(Params>>Lambda, [A1, A2, A3]).
+'>>'(Params, Lambda, A1, A2, A3, A4) :-
+    '$lambda'(Params>>Lambda, [A1, A2, A3, A4]).
+'>>'(Params, Lambda, A1, A2, A3, A4, A5) :-
+    '$lambda'(Params>>Lambda, [A1, A2, A3, A4, A5]).
+'>>'(Params, Lambda, A1, A2, A3, A4, A5, A6) :-
+    '$lambda'(Params>>Lambda, [A1, A2, A3, A4, A5, A6]).
+'>>'(Params, Lambda, A1, A2, A3, A4, A5, A6, A7) :-
+    '$lambda'(Params>>Lambda, [A1, A2, A3, A4, A5, A6, A7]).
+
+'/'(Free, Lambda) :-
+    '$lambda_free'(Free, Lambda, []).
+'/'(Free, Lambda, A1) :-
+    '$lambda_free'(Free, Lambda, [A1]).
+'/'(Free, Lambda, A1, A2) :-
+    '$lambda_free'(Free, Lambda, [A1, A2]).
+'/'(Free, Lambda, A1, A2, A3) :-
+    '$lambda_free'(Free, Lambda, [A1, A2, A3]).
+'/'(Free, Lambda, A1, A2, A3, A4) :-
+    '$lambda_free'(Free, Lambda, [A1, A2, A3, A4]).
+'/'(Free, Lambda, A1, A2, A3, A4, A5) :-
+    '$lambda_free'(Free, Lambda, [A1, A2, A3, A4, A5]).
+'/'(Free, Lambda, A1, A2, A3, A4, A5, A6) :-
+    '$lambda_free'(Free, Lambda, [A1, A2, A3, A4, A5, A6]).
+'/'(Free, Lambda, A1, A2, A3, A4, A5, A6, A7) :-
+    '$lambda_free'(Free, Lambda, [A1, A2, A3, A4, A5, A6, A7]).
+
+\(Lambda) :-
+    '$lambda'(\Lambda, []).
+\(Lambda, A1) :-
+    '$lambda'(\Lambda, [A1]).
+\(Lambda, A1, A2) :-
+    '$lambda'(\Lambda, [A1, A2]).
+\(Lambda, A1, A2, A3) :-
+    '$lambda'(\Lambda, [A1, A2, A3]).
+\(Lambda, A1, A2, A3, A4) :-
+    '$lambda'(\Lambda, [A1, A2, A3, A4]).
+\(Lambda, A1, A2, A3, A4, A5) :-
+    '$lambda'(\Lambda, [A1, A2, A3, A4, A5]).
+\(Lambda, A1, A2, A3, A4, A5, A6) :-
+    '$lambda'(\Lambda, [A1, A2, A3, A4, A5, A6]).
+\(Lambda, A1, A2, A3, A4, A5, A6, A7) :-
+    '$lambda'(\Lambda, [A1, A2, A3, A4, A5, A6, A7]).
+
+^(V1, Goal, A1) :-
+    V1 = A1,
+    call(Goal).
+^(V1, Goal, A1, A2) :-
+    V1 = A1,
+    call(Goal, A2).
+^(V1, Goal, A1, A2, A3) :-
+    V1 = A1,
+    call(Goal, A2, A3).
+^(V1, Goal, A1, A2, A3, A4) :-
+    V1 = A1,
+    call(Goal, A2, A3, A4).
+^(V1, Goal, A1, A2, A3, A4, A5) :-
+    V1 = A1,
+    call(Goal, A2, A3, A4, A5).
+^(V1, Goal, A1, A2, A3, A4, A5, A6) :-
+    V1 = A1,
+    call(Goal, A2, A3, A4, A5, A6).
+^(V1, Goal, A1, A2, A3, A4, A5, A6, A7) :-
+    V1 = A1,
+    call(Goal, A2, A3, A4, A5, A6, A7).
+
+'$lambda'(Free/Params>>Lambda, Args) :-
+    !,
+    '$lambda_free'(Free, Params>>Lambda, Args).
+'$lambda'(Lambda, Args) :-
+    copy_term(Lambda, Copy),
+    '$lambda_call'(Copy, Args).
+
+'$lambda_free'(Free, Lambda, Args) :-
+    copy_term(Free/Lambda, Free/Copy),
+    '$lambda_call'(Copy, Args).
+
+'$lambda_call'(Params>>Lambda, Args) :-
+    !,
+    '$lambda_bind'(Params, Args, Rest),
+    Goal =.. [call, Lambda|Rest],
+    call(Goal).
+'$lambda_call'(\Lambda, Args) :-
+    !,
+    Goal =.. [call, Lambda|Args],
+    call(Goal).
+'$lambda_call'(Lambda, Args) :-
+    Goal =.. [call, Lambda|Args],
+    call(Goal).
+
+'$lambda_bind'([P|Ps], [A|As], Rest) :-
+    !,
+    P = A,
+    '$lambda_bind'(Ps, As, Rest).
+'$lambda_bind'(_, Rest, Rest).
 `
@@ -11,6 +11,39 @@ import "github.com/mndrix/golog/read"
 import "github.com/mndrix/golog/term"
 import . "github.com/mndrix/golog/util"
 
+// readTests reads all terms in a test file.  Operator directives
+// affect later terms, as they do when the file is consulted.
+func readTests(f *os.File) []term.Term {
+	ops := read.NewOperators()
+	r, err := read.NewTermReader(f, read.WithOperators(ops))
+	MaybePanic(err)
+	var terms []term.Term
+	for {
+		t, err := r.Next()
+		if err == read.NoMoreTerms {
+			return terms
+		}
+		MaybePanic(err)
+		terms = append(terms, t)
+
+		if term.IsDirective(t) {
+			goal := t.(term.Callable).Arguments()[0]
+			if goal.Indicator() == "op/3" {
+				args := goal.(term.Callable).Arguments()
+				p := int(args[0].(*term.Integer).Value().Int64())
+				names := []term.Term{args[2]}
+				if term.IsList(args[2]) {
+					names = term.ProperListToTermSlice(args[2])
+				}
+				for _, name := range names {
+					err := ops.Add(p, args[1].(*term.Atom).Name(), name.(*term.Atom).Name())
+					MaybePanic(err)
+				}
+			}
+		}
+	}
+}
+
 func TestPureProlog(t *testing.T) {
 	// find all t/*.pl files
 	file, err := os.Open("t")
@@ -35,8 +68,7 @@ func TestPureProlog(t *testing.T) {
 		// which tests does the file have?
 		pastUseModule := false
 		tests := make([]term.Term, 0)
-		terms := read.TermAll_(openTest())
-		for _, s := range terms {
+		for _, s := range readTests(openTest()) {
 			x := s.(term.Callable)
 			if pastUseModule {
 				if x.Arity() == 2 && x.Name() == ":-" {
@@ -59,6 +91,13 @@ func TestPureProlog(t *testing.T) {
 		for _, test := range tests {
 			x := test.(term.Callable)
 			//t.Logf("proving: %s", test)
+			if x.Arity() > 0 && x.Arguments()[0].Indicator() == "throws/1" {
+				expected := x.Arguments()[0].(term.Callable).Arguments()[0]
+				if !m.CanProve(throws(test, expected)) {
+					t.Errorf("%s: %s should throw %s", name, test, expected)
+				}
+				continue
+			}
 			canProve := m.CanProve(test)
 			if x.Arity() > 0 && x.Arguments()[0].String() == "fail" {
 				if canProve {
@@ -72,3 +111,17 @@ func TestPureProlog(t *testing.T) {
 		}
 	}
 }
+
+// throws builds a goal which succeeds if test raises an exception
+// matching expected.  ISO errors can be abbreviated by their formal term,
+// so throws(instantiation_error) matches error(instantiation_error, _)
+func throws(test, expected term.Term) term.Term {
+	ball := term.NewVar("Ball")
+	catch := term.NewCallable("catch", test, ball, term.NewAtom("true"))
+	thrown := term.NewCallable(`\+`, term.NewCallable("var", ball))
+	matches := term.NewCallable(";",
+		term.NewCallable("=", ball, expected),
+		term.NewCallable("=", ball, term.NewCallable("error", expected, term.NewVar("_"))),
+	)
+	return term.NewCallable(",", catch, term.NewCallable(",", thrown, matches))
+}
@@ -1,6 +1,8 @@
 package golog
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/mndrix/golog/term"
@@ -176,6 +178,150 @@ func TestAppend(t *testing.T) {
 	}
 }
 
+func TestRedefineLibrary(t *testing.T) {
+	// user clauses replace library definitions, even native ones
+	m := NewMachine().Consult(`
+        member(X, [X]).
+        reverse(X, X).
+    `).Consult(`
+        member(X, [_, X]).
+    `)
+
+	proofs := m.ProveAll(`member(X, [a, b, c]).`)
+	if len(proofs) != 0 {
+		t.Errorf("Wrong number of answers: %d vs 0", len(proofs))
+	}
+	proofs = m.ProveAll(`member(X, [a, b]).`)
+	if len(proofs) != 1 {
+		t.Errorf("Wrong number of answers: %d vs 1", len(proofs))
+	}
+	if x := proofs[0].ByName_("X").String(); x != "b" {
+		t.Errorf("Wrong solution: %s vs b", x)
+	}
+
+	proofs = m.ProveAll(`reverse([a, b], X).`)
+	if x := proofs[0].ByName_("X").String(); x != "[a,b]" {
+		t.Errorf("Wrong solution: %s vs [a,b]", x)
+	}
+
+	// other machines still see the library definitions
+	if !NewMachine().CanProve(`reverse([a, b], [b, a]).`) {
+		t.Errorf("Library reverse/2 was changed")
+	}
+}
+
+func TestPrologFlags(t *testing.T) {
+	m := NewMachine()
+	if v, ok := m.PrologFlag("unknown"); !ok || v.String() != "error" {
+		t.Errorf("Wrong unknown flag: %v", v)
+	}
+	if _, ok := m.PrologFlag("no_such_flag"); ok {
+		t.Errorf("Found a flag which doesn't exist")
+	}
+
+	m1, err := m.SetPrologFlag("occurs_check", term.NewAtom("true"))
+	if err != nil {
+		t.Fatalf("Can't set occurs_check: %s", err)
+	}
+	if m1.CanProve(`X = f(X).`) {
+		t.Errorf("Occurs check didn't happen")
+	}
+	if !m.CanProve(`X = f(X).`) {
+		t.Errorf("Original machine's flag was changed")
+	}
+
+	if _, err := m.SetPrologFlag("bounded", term.NewAtom("true")); err == nil {
+		t.Errorf("Changed a read only flag")
+	}
+	if _, err := m.SetPrologFlag("occurs_check", term.NewAtom("maybe")); err == nil {
+		t.Errorf("Set a flag to an unacceptable value")
+	}
+}
+
+func TestConsultDirectives(t *testing.T) {
+	m, err := NewMachine().Load(`
+		:- forall(member(O, [===>, <===]), op(700, xfx, O)).
+		t(a ===> b).
+		t(c <=== d).
+	`)
+	if err != nil {
+		t.Fatalf("Can't consult: %s", err)
+	}
+	if !m.CanProve(`t(X), X = ===>(a, b).`) {
+		t.Errorf("Operator from forall/2 directive wasn't used")
+	}
+
+	// an unknown operator is a syntax error
+	_, err = NewMachine().Load(`t(a ===> b).`)
+	ex, ok := err.(*term.Exception)
+	if !ok {
+		t.Fatalf("Wrong error for bad syntax: %v", err)
+	}
+	if s := ex.Ball().String(); !strings.HasPrefix(s, "error(syntax_error(") {
+		t.Errorf("Wrong exception: %s", s)
+	}
+}
+
+func TestSetarg(t *testing.T) {
+	m := NewMachine()
+
+	// each solution keeps its own version of the term
+	proofs := m.ProveAll(`X = f(a), (setarg(1, X, b) ; true).`)
+	if len(proofs) != 2 {
+		t.Fatalf("Wrong number of answers: %d vs 2", len(proofs))
+	}
+	if x := proofs[0].ByName_("X").String(); x != "f(b)" {
+		t.Errorf("Wrong first solution: %s vs f(b)", x)
+	}
+	if x := proofs[1].ByName_("X").String(); x != "f(a)" {
+		t.Errorf("Wrong second solution: %s vs f(a)", x)
+	}
+
+	// a variable argument is bound after setarg
+	proofs = m.ProveAll(`X = f(a), setarg(1, X, Y), Y = 3.`)
+	if len(proofs) != 1 {
+		t.Fatalf("Wrong number of answers: %d vs 1", len(proofs))
+	}
+	if x := proofs[0].ByName_("X").String(); x != "f(3)" {
+		t.Errorf("Wrong solution: %s vs f(3)", x)
+	}
+
+	// findall collects the changed term
+	proofs = m.ProveAll(`Z = f(a), findall(Y, (setarg(1, Z, b), Y = Z), L).`)
+	if len(proofs) != 1 {
+		t.Fatalf("Wrong number of answers: %d vs 1", len(proofs))
+	}
+	if x := proofs[0].ByName_("L").String(); x != "[f(b)]" {
+		t.Errorf("Wrong solution: %s vs [f(b)]", x)
+	}
+}
+
+func TestUnknownProcedure(t *testing.T) {
+	m := NewMachine().PushConj(term.NewCallable("nope", term.NewInt64(1)))
+	_, _, err := m.Step()
+	ex, ok := err.(*term.Exception)
+	if !ok {
+		t.Fatalf("Wrong error for unknown procedure: %v", err)
+	}
+	if s := ex.Ball().String(); !strings.HasPrefix(s, "error(existence_error(procedure, /(nope, 1)), ") {
+		t.Errorf("Wrong exception: %s", s)
+	}
+
+	// warnings go to user_error
+	m, err = m.SetPrologFlag("unknown", term.NewAtom("warning"))
+	if err != nil {
+		t.Fatalf("Can't set unknown: %s", err)
+	}
+	var warning bytes.Buffer
+	m = m.RegisterOutput("user_error", &warning)
+	if m.CanProve(`nope(1).`) {
+		t.Errorf("Proved an unknown procedure")
+	}
+	if warning.String() != "Warning: Unknown procedure: nope/1\n" {
+		t.Errorf("Wrong warning: %q", warning.String())
+	}
+}
+
 func TestCall(t *testing.T) {
 	m := NewMachine().Consult(`
         bug(spider).
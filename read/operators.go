@@ -0,0 +1,124 @@
+package read
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Operators is an operator table.  It decides which atoms a reader
+// treats as prefix, infix or postfix operators, and with which
+// priorities.  See ISO §6.3.4.4
+type Operators struct {
+	table map[string]*[7]priority
+}
+
+// NewOperators returns a table holding the default operators specified
+// in ISO Prolog §6.3.4.4, table 7, along with a few common extensions.
+func NewOperators() *Operators {
+	o := &Operators{table: make(map[string]*[7]priority)}
+	o.op(1200, xfx, `:-`, `-->`)
+	o.op(1200, fx, `:-`, `?-`)
+	o.op(1150, fx, `dynamic`, `discontiguous`, `multifile`)
+	o.op(1150, fx, `meta_predicate`) // SWI, YAP, etc. extension
+	o.op(1100, xfy, `;`)
+	o.op(1050, xfy, `->`)
+	o.op(1000, xfy, `,`)
+	o.op(900, fy, `\+`)
+	o.op(700, xfx, `=`, `\=`)
+	o.op(700, xfx, `==`, `\==`, `@<`, `@=<`, `@>`, `@>=`)
+	o.op(700, xfx, `=..`)
+	o.op(700, xfx, `=@=`, `\=@=`) // SWI extension
+	o.op(700, xfx, `is`, `=:=`, `=\=`, `<`, `=<`, `>`, `>=`)
+	o.op(500, yfx, `+`, `-`, `/\`, `\/`, `xor`) // syntax highlighter `
+	o.op(400, yfx, `*`, `/`, `//`, `rdiv`, `rem`, `mod`, `div`, `<<`, `>>`)
+	o.op(200, xfx, `**`)
+	o.op(200, xfy, `^`)
+	o.op(200, fy, `-`, `+`, `\`) // syntax highlighter `
+	return o
+}
+
+// Copy returns a copy of this table which can be changed without
+// affecting the original
+func (o *Operators) Copy() *Operators {
+	o1 := &Operators{table: make(map[string]*[7]priority, len(o.table))}
+	for name, priorities := range o.table {
+		p := *priorities
+		o1.table[name] = &p
+	}
+	return o1
+}
+
+// Add creates, changes or removes operators like op/3 does.  spec is a
+// specifier like xfx or fy.  A priority of 0 removes the operator.
+// Since an atom may be only one kind of prefix, infix or postfix
+// operator, a new definition replaces any other of the same kind.
+func (o *Operators) Add(p int, spec string, names ...string) error {
+	if p < 0 || p > 1200 {
+		return fmt.Errorf("invalid operator priority: %d", p)
+	}
+	s, ok := parseSpecifier(spec)
+	if !ok {
+		return fmt.Errorf("invalid operator specifier: %s", spec)
+	}
+	for _, name := range names {
+		for _, other := range s.kind() {
+			o.op(0, other, name)
+		}
+		o.op(priority(p), s, name)
+	}
+	return nil
+}
+
+// List returns every entry in this table, ordered by name and then by
+// specifier
+func (o *Operators) List() []Operator {
+	names := make([]string, 0, len(o.table))
+	for name := range o.table {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ops := make([]Operator, 0, len(names))
+	for _, name := range names {
+		for s, p := range o.table[name] {
+			if p > 0 {
+				ops = append(ops, Operator{int(p), specifier(s).String(), name})
+			}
+		}
+	}
+	return ops
+}
+
+// parseSpecifier returns the specifier with the given name, like xfx
+func parseSpecifier(name string) (specifier, bool) {
+	for s, n := range specifierNames {
+		if n == name {
+			return specifier(s), true
+		}
+	}
+	return 0, false
+}
+
+// kind returns every specifier of the same kind as s: prefix, infix or
+// postfix
+func (s specifier) kind() []specifier {
+	switch s {
+	case fx, fy:
+		return []specifier{fx, fy}
+	case xf, yf:
+		return []specifier{xf, yf}
+	}
+	return []specifier{xfx, xfy, yfx}
+}
+
+// op sets the priority of a single specifier for some operators
+func (o *Operators) op(p priority, s specifier, names ...string) {
+	for _, name := range names {
+		priorities, ok := o.table[name]
+		if !ok {
+			priorities = new([7]priority)
+			o.table[name] = priorities
+		}
+		priorities[s] = p
+	}
+}
@@ -30,9 +30,49 @@ const (
 	yf
 )
 
+var specifierNames = [...]string{"fx", "fy", "xfx", "xfy", "yfx", "xf", "yf"}
+
+func (s specifier) String() string {
+	return specifierNames[s]
+}
+
 // ISO operator priorities per §6.3.4
 type priority int // between 1 and 1200, inclusive
 
+// Operator describes one entry in a reader's operator table, like the
+// arguments to op/3
+type Operator struct {
+	Priority  int    // between 1 and 1200, inclusive
+	Specifier string // xfx, fy, etc.
+	Name      string
+}
+
+// DoubleQuotes describes which term represents double quoted text.
+// It corresponds to the double_quotes flag. See ISO §7.11.2.5
+type DoubleQuotes int
+
+const (
+	DoubleQuotesCodes  DoubleQuotes = iota // a list of character codes
+	DoubleQuotesChars                      // a list of one character atoms
+	DoubleQuotesAtom                       // an atom
+	DoubleQuotesString                     // a packed string
+)
+
+var doubleQuotes = map[string]DoubleQuotes{
+	"codes":  DoubleQuotesCodes,
+	"chars":  DoubleQuotesChars,
+	"atom":   DoubleQuotesAtom,
+	"string": DoubleQuotesString,
+}
+
+// NewDoubleQuotes returns the DoubleQuotes value with the given name,
+// as used by the double_quotes flag.  Returns false if there's no
+// such value.
+func NewDoubleQuotes(name string) (DoubleQuotes, bool) {
+	dq, ok := doubleQuotes[name]
+	return dq, ok
+}
+
 // Term reads a single term from a term source.  A term source can
 // be any of the following:
 //
@@ -87,19 +127,55 @@ func toReader(src interface{}) (io.Reader, error) {
 }
 
 type TermReader struct {
-	operators map[string]*[7]priority
-	ll        *lex.List
+	ops          *Operators
+	doubleQuotes DoubleQuotes
+	ll           *lex.List
+	pos          lex.Position // where the most recent term started
+	variables    []*term.Variable
+	singletons   []*term.Variable
+	malformed    term.Term // error for a term which can't be read
 }
 
-func NewTermReader(src interface{}) (*TermReader, error) {
+// Options configure a TermReader.  A zero value reads with the default
+// operator table and double quoted text as codes.
+type Options struct {
+	Operators    *Operators   // nil for the default table
+	DoubleQuotes DoubleQuotes // see SetDoubleQuotes
+}
+
+// Option changes one setting in Options.  Options given to
+// NewTermReader are applied in order, so they compose.
+type Option func(*Options)
+
+// WithOperators reads with an operator table
+func WithOperators(ops *Operators) Option {
+	return func(opts *Options) { opts.Operators = ops }
+}
+
+// WithDoubleQuotes reads double quoted text as dq
+func WithDoubleQuotes(dq DoubleQuotes) Option {
+	return func(opts *Options) { opts.DoubleQuotes = dq }
+}
+
+// NewTermReader returns a reader for the terms in src, which is a
+// string or an io.Reader.
+func NewTermReader(src interface{}, options ...Option) (*TermReader, error) {
 	ioReader, err := toReader(src)
 	if err != nil {
 		return nil, err
 	}
 
+	var opts Options
+	for _, option := range options {
+		option(&opts)
+	}
 	tokens := lex.Scan(ioReader)
-	r := TermReader{ll: lex.NewList(tokens)}
-	r.ResetOperatorTable()
+	r := TermReader{ll: lex.NewList(tokens), doubleQuotes: opts.DoubleQuotes}
+	if opts.Operators == nil {
+		r.ResetOperatorTable()
+	} else {
+		r.SetOperators(opts.Operators)
+	}
 	return &r, nil
 }
 
@@ -112,8 +188,15 @@ func (r *TermReader) Next() (term.Term, error) {
 		if term.IsError(t) {
 			return nil, fmt.Errorf("%s", t.String())
 		}
+		start := r.ll
+		for start.Value.Type == lex.Comment {
+			start = start.Next()
+		}
+		r.pos = *start.Value.Pos
 		r.ll = ll
-		return term.RenameVariables(t), nil
+		renamed := term.RenameVariables(t)
+		r.namedVariables(t, renamed)
+		return renamed, nil
 	}
 
 	return nil, NoMoreTerms
@@ -138,35 +221,85 @@ func (r *TermReader) all() ([]term.Term, error) {
 // ResetOperatorTable replaces the reader's current operator table
 // with the default table specified in ISO Prolog §6.3.4.4, table 7
 func (r *TermReader) ResetOperatorTable() {
-	r.operators = make(map[string]*[7]priority)
-	r.Op(1200, xfx, `:-`, `-->`)
-	r.Op(1200, fx, `:-`, `?-`)
-	r.Op(1150, fx, `meta_predicate`) // SWI, YAP, etc. extension
-	r.Op(1100, xfy, `;`)
-	r.Op(1050, xfy, `->`)
-	r.Op(1000, xfy, `,`)
-	r.Op(900, fy, `\+`)
-	r.Op(700, xfx, `=`, `\=`)
-	r.Op(700, xfx, `==`, `\==`, `@<`, `@=<`, `@>`, `@>=`)
-	r.Op(700, xfx, `=..`)
-	r.Op(700, xfx, `is`, `=:=`, `=\=`, `<`, `=<`, `>`, `>=`)
-	r.Op(500, yfx, `+`, `-`, `/\`, `\/`) // syntax highlighter `
-	r.Op(400, yfx, `*`, `/`, `//`, `rem`, `mod`, `<<`, `<<`)
-	r.Op(200, xfx, `**`)
-	r.Op(200, xfy, `^`)
-	r.Op(200, fy, `-`, `\`) // syntax highlighter `
+	r.ops = NewOperators()
+}
+
+// SetOperators makes this reader use an operator table.  The table is
+// shared, so later changes to it affect this reader too.
+func (r *TermReader) SetOperators(ops *Operators) {
+	r.ops = ops
 }
 
 // Op creates or changes the parsing behavior of a Prolog operator.
-// It's equivalent to op/3
+// It's equivalent to op/3.  The reader's table is copied first, so a
+// table given by SetOperators isn't changed.
 func (r *TermReader) Op(p priority, s specifier, os ...string) {
-	for _, o := range os {
-		priorities, ok := r.operators[o]
-		if !ok {
-			priorities = new([7]priority)
-			r.operators[o] = priorities
+	r.ops = r.ops.Copy()
+	r.ops.op(p, s, os...)
+}
+
+// Operators returns every entry in this reader's operator table,
+// ordered by name and then by specifier
+func (r *TermReader) Operators() []Operator {
+	return r.ops.List()
+}
+
+// Position returns the source position where the term most recently
+// returned by Next() started
+func (r *TermReader) Position() lex.Position {
+	return r.pos
+}
+
+// Variables returns the named variables of the term most recently
+// returned by Next(), in the order they first appear.  Each variable's
+// Name is the name it had in the source.  Anonymous variables (_) are
+// not included.
+func (r *TermReader) Variables() []*term.Variable {
+	return r.variables
+}
+
+// Singletons is like Variables but returns only variables which appear
+// once.  As in SWI-Prolog, variables whose names start with an
+// underscore are not included.
+func (r *TermReader) Singletons() []*term.Variable {
+	return r.singletons
+}
+
+// namedVariables records the named variables of a term as it was parsed
+// (raw) and after renaming.  Renaming gives anonymous variables a name,
+// so they can only be recognized in the raw term.
+func (r *TermReader) namedVariables(raw, renamed term.Term) {
+	r.variables, r.singletons = nil, nil
+	counts := make(map[string]int)
+	var visit func(raw, renamed term.Term)
+	visit = func(raw, renamed term.Term) {
+		switch x := raw.(type) {
+		case *term.Variable:
+			if x.Name == "_" {
+				return
+			}
+			if counts[x.Name] == 0 {
+				r.variables = append(r.variables, renamed.(*term.Variable))
+			}
+			counts[x.Name]++
+		case *term.Compound:
+			for i, arg := range x.Arguments() {
+				visit(arg, renamed.(*term.Compound).Arguments()[i])
+			}
+		case *term.Dict:
+			visit(x.Tag(), renamed.(*term.Dict).Tag())
+			values := renamed.(*term.Dict).Values()
+			for i, value := range x.Values() {
+				visit(value, values[i])
+			}
+		}
+	}
+	visit(raw, renamed)
+
+	for _, v := range r.variables {
+		if counts[v.Name] == 1 && !strings.HasPrefix(v.Name, "_") {
+			r.singletons = append(r.singletons, v)
 		}
-		priorities[s] = p
 	}
 }
 
@@ -181,6 +314,31 @@ func (r *TermReader) functor(in *lex.List, out **lex.List, f *string) bool {
 	return false
 }
 
+// SetDoubleQuotes changes how this reader represents double quoted
+// text.  The default is DoubleQuotesCodes.
+func (r *TermReader) SetDoubleQuotes(dq DoubleQuotes) {
+	r.doubleQuotes = dq
+}
+
+// doubleQuoted returns the term represented by a double quoted
+// string lexeme
+func (r *TermReader) doubleQuoted(lexeme string) term.Term {
+	s := term.NewStringFromLexeme(lexeme)
+	switch r.doubleQuotes {
+	case DoubleQuotesChars:
+		var chars []term.Term
+		for _, c := range s.Text() {
+			chars = append(chars, term.NewAtom(string(c)))
+		}
+		return term.NewTermList(chars)
+	case DoubleQuotesAtom:
+		return term.NewAtom(s.Text())
+	case DoubleQuotesString:
+		return s
+	}
+	return term.NewCodeList(s.Text())
+}
+
 // parse all list items after the first one
 func (r *TermReader) listItems(i *lex.List, o **lex.List, t *term.Term) bool {
 	var arg, rest term.Term
@@ -228,6 +386,10 @@ func (r *TermReader) readTerm(p priority, i *lex.List, o **lex.List, t *term.Ter
 		}
 	}
 
+	if r.malformed != nil {
+		*t, r.malformed = r.malformed, nil
+		return true
+	}
 	msg := fmt.Sprintf("expected term but got `%s`", i.Value.Content)
 	*t = term.NewError(msg, i.Value)
 	return false
@@ -240,6 +402,11 @@ func (r *TermReader) term(p priority, i *lex.List, o **lex.List, t *term.Term) b
 	var opP, argP priority
 	//  fmt.Printf("seeking term with %s\n", i.Value.Content)
 
+	// negative numbers §6.3.4.1
+	if r.negativeNumber(i, o, &t0) {
+		return r.restTerm(0, p, *o, o, t0, t)
+	}
+
 	// prefix operator
 	if r.prefix(&op, &opP, &argP, i, o) && opP <= p && r.term(argP, *o, o, &t0) {
 		opT := term.NewCallable(op, t0)
@@ -262,6 +429,15 @@ func (r *TermReader) term(p priority, i *lex.List, o **lex.List, t *term.Term) b
 		return r.restTerm(0, p, *o, o, t0, t)
 	}
 
+	// dicts like Tag{k: v} (SWI-Prolog extension)
+	if r.dictOpen(i) {
+		if !r.dict(i, o, &t0) {
+			*t = t0
+			return false
+		}
+		return r.restTerm(0, p, *o, o, t0, t)
+	}
+
 	switch i.Value.Type {
 	case lex.Int: // integer term §6.3.1.1
 		n := term.NewInt(i.Value.Content)
@@ -271,14 +447,21 @@ func (r *TermReader) term(p priority, i *lex.List, o **lex.List, t *term.Term) b
 		f := term.NewFloat(i.Value.Content)
 		*o = i.Next()
 		return r.restTerm(0, p, *o, o, f, t)
+	case lex.Rational: // rational number like 1r3
+		n, ok := term.NewRationalFromLexeme(i.Value.Content)
+		if !ok {
+			return false
+		}
+		*o = i.Next()
+		return r.restTerm(0, p, *o, o, n, t)
 	case lex.Atom: // atom term §6.3.1.3
 		a := term.NewAtomFromLexeme(i.Value.Content)
 		*o = i.Next()
 		return r.restTerm(0, p, *o, o, a, t)
 	case lex.String: // double quated string §6.3.7
-		cl := term.NewCodeListFromDoubleQuotedString(i.Value.Content)
+		s := r.doubleQuoted(i.Value.Content)
 		*o = i.Next()
-		return r.restTerm(0, p, *o, o, cl, t)
+		return r.restTerm(0, p, *o, o, s, t)
 	case lex.Variable: // variable term §6.3.2
 		v := term.NewVar(i.Value.Content)
 		*o = i.Next()
@@ -296,15 +479,23 @@ func (r *TermReader) term(p priority, i *lex.List, o **lex.List, t *term.Term) b
 	if r.functor(i, o, &f) && r.tok('(', *o, o) {
 		var args []term.Term
 		var arg term.Term
+		closed := false
 		for r.term(999, *o, o, &arg) { // 999 priority per §6.3.3.1
 			args = append(args, arg)
 			if r.tok(')', *o, o) {
+				closed = true
 				break
 			}
 			if r.tok(',', *o, o) {
 				continue
 			}
-			panic("Unexpected content inside compound term arguments")
+			msg := fmt.Sprintf("expected `,` or `)` in arguments but got `%s`", (*o).Value.Content)
+			r.malformed = term.NewError(msg, (*o).Value)
+			break
+		}
+		if !closed {
+			*t = term.NewError("Syntax error", i.Value)
+			return false
 		}
 		f := term.NewTermFromLexeme(f, args...)
 		return r.restTerm(0, p, *o, o, f, t)
@@ -314,6 +505,116 @@ func (r *TermReader) term(p priority, i *lex.List, o **lex.List, t *term.Term) b
 	return false
 }
 
+// dictOpen returns true if i is a dict tag (an atom or variable)
+// followed directly by "{"
+func (r *TermReader) dictOpen(i *lex.List) bool {
+	switch i.Value.Type {
+	case lex.Atom, lex.Variable, lex.Void:
+	default:
+		return false
+	}
+	next := i.Next()
+	if next.Value.Type != '{' {
+		return false
+	}
+	return next.Value.Pos.Offset == i.Value.Pos.Offset+len(i.Value.Content)
+}
+
+// parse a dict whose tag is the first token of i.  On failure, t holds
+// an error term describing the problem
+func (r *TermReader) dict(i *lex.List, o **lex.List, t *term.Term) bool {
+	var tag term.Term
+	switch i.Value.Type {
+	case lex.Atom:
+		tag = term.NewAtomFromLexeme(i.Value.Content)
+	case lex.Variable:
+		tag = term.NewVar(i.Value.Content)
+	case lex.Void:
+		tag = term.NewVar("_")
+	}
+	d := term.NewDict(tag)
+	*o = i.Next().Next() // skip tag and "{"
+	if r.tok('}', *o, o) {
+		*t = d
+		return true
+	}
+
+	for {
+		var key, value term.Term
+		k := *o
+		switch k.Value.Type {
+		case lex.Atom:
+			key = term.NewAtomFromLexeme(k.Value.Content)
+		case lex.Int:
+			key = term.NewInt(k.Value.Content)
+		}
+		if key == nil || !term.IsDictKey(key) {
+			*t = term.NewError("expected dict key", k.Value)
+			return false
+		}
+		colon := k.Next()
+		if colon.Value.Type != lex.Atom || colon.Value.Content != ":" {
+			*t = term.NewError("expected `:` after dict key", colon.Value)
+			return false
+		}
+		if !r.term(999, colon.Next(), o, &value) {
+			*t = term.NewError("expected dict value", colon.Next().Value)
+			return false
+		}
+		if _, ok := d.Get(key); ok {
+			msg := fmt.Sprintf("duplicate dict key `%s`", key)
+			*t = term.NewError(msg, k.Value)
+			return false
+		}
+		d = d.Put(key, value)
+
+		if r.tok('}', *o, o) {
+			*t = d
+			return true
+		}
+		if !r.tok(',', *o, o) {
+			*t = term.NewError("expected `,` or `}` in dict", (*o).Value)
+			return false
+		}
+	}
+}
+
+// a name token "-" followed directly by a numeric literal denotes a
+// negative number. See §6.3.4.1
+func (r *TermReader) negativeNumber(i *lex.List, o **lex.List, t *term.Term) bool {
+	if i.Value.Type != lex.Atom || i.Value.Content != "-" {
+		return false
+	}
+	next := i.Next()
+	switch next.Value.Type {
+	case lex.Int, lex.Float, lex.Rational:
+	default:
+		return false
+	}
+	if next.Value.Pos.Offset != i.Value.Pos.Offset+1 {
+		return false // whitespace between "-" and the number
+	}
+
+	var n term.Number
+	switch next.Value.Type {
+	case lex.Int:
+		n = term.NewInt(next.Value.Content)
+	case lex.Float:
+		n = term.NewFloat(next.Value.Content)
+	case lex.Rational:
+		var ok bool
+		n, ok = term.NewRationalFromLexeme(next.Value.Content)
+		if !ok {
+			return false
+		}
+	}
+	n, err := term.ArithmeticNegate(n)
+	maybePanic(err)
+	*t = n
+	*o = next.Next()
+	return true
+}
+
 func (r *TermReader) restTerm(leftP, p priority, i *lex.List, o **lex.List, leftT term.Term, t *term.Term) bool {
 	var op string
 	var rightT term.Term
@@ -348,7 +649,7 @@ func (r *TermReader) infix(op *string, opP, lap, rap *priority, i *lex.List, o *
 
 	// is this an operator at all?
 	name := i.Value.Content
-	priorities, ok := r.operators[name]
+	priorities, ok := r.ops.table[name]
 	if !ok {
 		//      fmt.Printf("  no operator %s found\n", name)
 		return false
@@ -387,7 +688,7 @@ func (r *TermReader) prefix(op *string, opP, argP *priority, i *lex.List, o **le
 
 	// is this an operator at all?
 	name := i.Value.Content
-	priorities, ok := r.operators[name]
+	priorities, ok := r.ops.table[name]
 	if !ok {
 		return false
 	}
@@ -417,7 +718,7 @@ func (r *TermReader) postfix(op *string, opP, argP *priority, i *lex.List, o **l
 
 	// is this an operator at all?
 	name := i.Value.Content
-	priorities, ok := r.operators[name]
+	priorities, ok := r.ops.table[name]
 	if !ok {
 		return false
 	}
@@ -1,6 +1,11 @@
 package read
 
-import "testing"
+import (
+	"strings"
+	"testing"
+
+	"github.com/mndrix/golog/term"
+)
 
 func TestBasic(t *testing.T) {
 
@@ -39,6 +44,24 @@ func TestBasic(t *testing.T) {
 	single[`(true->(true)).`] = `->(true, true)`
 	single[`(if->then;else).`] = `;(->(if, then), else)`
 	single[`A = 3.`] = `=(A, 3)`
+	single[`A is 1 // 0.`] = `is(A, //(1, 0))` // 0 followed by full stop
+	single[`A is 8 >> 1 << 2.`] = `is(A, <<(>>(8, 1), 2))`
+	single[`A is 5 xor 3 + 1.`] = `is(A, +(xor(5, 3), 1))`
+	single[`A is -1.`] = `is(A, -1)`
+	single[`A is - 1.`] = `is(A, -(1))`
+	single[`A is 3-1.`] = `is(A, -(3, 1))`
+	single[`A is -(1).`] = `is(A, -(1))`
+	single[`A is -2.5.`] = `is(A, -2.5)`
+	single[`A is 1r3 rdiv 2.`] = `is(A, rdiv(1r3, 2))`
+	single[`A is -1r3.`] = `is(A, -1r3)`
+	single[`A is 4r2.`] = `is(A, 2)`
+	single[`A is 0.1.`] = `is(A, 0.1)`
+	single[`A is 7 div 2.`] = `is(A, div(7, 2))`
+	single[`f(A) =@= f(B).`] = `=@=(f(A), f(B))`
+	single[`F/[X]>>foo(X, Y).`] = `>>(/(F, [X]), foo(X, Y))`
+	single[`\X^Y^foo(X, Y).`] = `\(^(X, ^(Y, foo(X, Y))))`
+	single[`point{y: 2, x: f(A)}.`] = `point{x:f(A), y:2}`
+	single[`X = _{1: a, b: [c]}.`] = `=(X, _{1:a, b:[c]})`
 	for test, wanted := range single {
 		got, err := Term(test)
 		maybePanic(err)
@@ -52,11 +75,12 @@ func TestBasic(t *testing.T) {
 	user[`a x b.`] = `x(a, b)`
 	user[`a x b x c.`] = `x(x(a, b), c)`
 	user[`two weeks.`] = `weeks(two)`
+	ops := NewOperators()
+	maybePanic(ops.Add(400, "yfx", "x"))
+	maybePanic(ops.Add(200, "yf", "weeks"))
 	for test, wanted := range user {
-		r, err := NewTermReader(test)
+		r, err := NewTermReader(test, WithOperators(ops))
 		maybePanic(err)
-		r.Op(400, yfx, "x")
-		r.Op(200, yf, "weeks")
 
 		got, err := r.Next()
 		maybePanic(err)
@@ -77,6 +101,32 @@ func TestBasic(t *testing.T) {
 	}
 }
 
+func TestUnclosedArguments(t *testing.T) {
+	terms, err := TermAll(`foo( .`)
+	if err == nil && len(terms) > 0 {
+		t.Errorf("Unclosed arguments read as %s", terms[0])
+	}
+}
+
+func TestDoubleQuotes(t *testing.T) {
+	tests := map[DoubleQuotes]string{
+		DoubleQuotesCodes:  `"hi"`,
+		DoubleQuotesChars:  `[h,i]`,
+		DoubleQuotesAtom:   `hi`,
+		DoubleQuotesString: `"hi"`,
+	}
+	for dq, wanted := range tests {
+		r, err := NewTermReader(`"hi".`)
+		maybePanic(err)
+		r.SetDoubleQuotes(dq)
+		got, err := r.Next()
+		maybePanic(err)
+		if got.String() != wanted {
+			t.Errorf("Reading with %d gave `%s` instead of `%s`", dq, got, wanted)
+		}
+	}
+}
+
 func TestEolComment(t *testing.T) {
 	terms := TermAll_(`
         one.  % shouldn't hide following term
@@ -92,3 +142,99 @@ func TestEolComment(t *testing.T) {
 		t.Errorf("Expected `two` in %#v", terms)
 	}
 }
+
+func TestPosition(t *testing.T) {
+	r, err := NewTermReader("one.\n% a comment\n\n  two(\n  x).\n")
+	maybePanic(err)
+	lines := []int{1, 4}
+	for _, line := range lines {
+		got, err := r.Next()
+		maybePanic(err)
+		if pos := r.Position(); pos.Line != line {
+			t.Errorf("`%s` starts on line %d, not %d", got, pos.Line, line)
+		}
+	}
+}
+
+func TestOperators(t *testing.T) {
+	r, err := NewTermReader(``)
+	maybePanic(err)
+	var minus []Operator
+	for _, op := range r.Operators() {
+		if op.Name == "-" {
+			minus = append(minus, op)
+		}
+	}
+	wanted := []Operator{{200, "fy", "-"}, {500, "yfx", "-"}}
+	if len(minus) != len(wanted) || minus[0] != wanted[0] || minus[1] != wanted[1] {
+		t.Errorf("Wrong operators for -: %v", minus)
+	}
+}
+
+func TestVariables(t *testing.T) {
+	r, err := NewTermReader(`f(X, _, Y, X, _Z, g(W)).`)
+	maybePanic(err)
+	got, err := r.Next()
+	maybePanic(err)
+
+	names := func(vs []*term.Variable) string {
+		var s []string
+		for _, v := range vs {
+			s = append(s, v.Name)
+		}
+		return strings.Join(s, ",")
+	}
+	if n := names(r.Variables()); n != "X,Y,_Z,W" {
+		t.Errorf("Wrong variables: %s", n)
+	}
+	if n := names(r.Singletons()); n != "Y,W" {
+		t.Errorf("Wrong singletons: %s", n)
+	}
+
+	// variables are the same ones which appear in the term
+	x := got.(*term.Compound).Arguments()[0]
+	if x != r.Variables()[0] {
+		t.Errorf("Variable X isn't the one in %s", got)
+	}
+}
+
+func TestOperatorsAdd(t *testing.T) {
+	ops := NewOperators()
+	maybePanic(ops.Add(700, "xfx", "===>"))
+	maybePanic(ops.Add(500, "xfx", "-"))
+	maybePanic(ops.Add(0, "xfx", "is"))
+
+	r, err := NewTermReader(`a ===> b. - 1 - 2. f(is).`, WithOperators(ops))
+	maybePanic(err)
+	for _, wanted := range []string{`===>(a, b)`, `-(-(1), 2)`, `f(is)`} {
+		got, err := r.Next()
+		maybePanic(err)
+		if got.String() != wanted {
+			t.Errorf("Read `%s` instead of `%s`", got, wanted)
+		}
+	}
+
+	// options compose rather than replace each other
+	r, err = NewTermReader(`a ===> "hi".`,
+		WithOperators(ops),
+		WithDoubleQuotes(DoubleQuotesAtom),
+	)
+	maybePanic(err)
+	got, err := r.Next()
+	maybePanic(err)
+	if got.String() != `===>(a, hi)` {
+		t.Errorf("Read `%s` with both options", got)
+	}
+
+	// the default table doesn't change
+	if _, err := Term(`a ===> b.`); err == nil {
+		t.Errorf("Operator leaked into the default table")
+	}
+
+	if ops.Add(1201, "xfx", "x") == nil {
+		t.Errorf("Priority 1201 should be invalid")
+	}
+	if ops.Add(700, "yfy", "x") == nil {
+		t.Errorf("Specifier yfy should be invalid")
+	}
+}
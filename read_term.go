@@ -0,0 +1,257 @@
+package golog
+
+// read_term/2 and friends read terms from streams and from text.  A
+// stream is scanned character by character up to the end token of a
+// clause, so reading a term consumes no more of the stream than it must.
+// The clause's text is then parsed by a read.TermReader configured with
+// this machine's flags.  See ISO §8.14.1
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mndrix/golog/lex"
+	"github.com/mndrix/golog/read"
+	"github.com/mndrix/golog/term"
+	. "github.com/mndrix/golog/util"
+)
+
+// readOptions are the options accepted by read_term/2
+type readOptions struct {
+	variables     term.Term // nil unless variables(Vs) was given
+	variableNames term.Term // nil unless variable_names(Vs) was given
+	singletons    term.Term // nil unless singletons(Vs) was given
+	syntaxErrors  string    // error, fail or quiet
+}
+
+// mustReadOptions converts a read_term/2 option list into readOptions.
+// Raises an ISO error for invalid options.
+func mustReadOptions(options term.Term) readOptions {
+	opts := readOptions{syntaxErrors: "error"}
+	for _, option := range mustProperList(options) {
+		if term.IsVariable(option) {
+			panic(term.InstantiationError())
+		}
+		var value term.Term
+		if term.IsCompound(option) && option.(*term.Compound).Arity() == 1 {
+			value = option.(*term.Compound).Arguments()[0]
+		}
+		switch {
+		case option.Indicator() == "variables/1":
+			opts.variables = value
+		case option.Indicator() == "variable_names/1":
+			opts.variableNames = value
+		case option.Indicator() == "singletons/1":
+			opts.singletons = value
+		case option.Indicator() == "syntax_errors/1" && term.IsVariable(value):
+			panic(term.InstantiationError())
+		case option.Indicator() == "syntax_errors/1" && isAtomIn(value, "error", "fail", "quiet"):
+			opts.syntaxErrors = value.(*term.Atom).Name()
+		default:
+			panic(term.DomainError("read_option", option))
+		}
+	}
+	return opts
+}
+
+// termReader returns a reader for src which follows this machine's
+// flags and operator table
+func (m *machine) termReader(src interface{}) *read.TermReader {
+	r, err := read.NewTermReader(src,
+		read.WithOperators(m.ops),
+		read.WithDoubleQuotes(m.doubleQuotes()),
+	)
+	MaybePanic(err)
+	return r
+}
+
+// parseClause parses the text of a single clause, as returned by
+// readClause.  Returns the reader so that the caller can ask about the
+// term's variables.
+func (m *machine) parseClause(text string) (t term.Term, r *read.TermReader, err error) {
+	defer func() { // convert parsing panics into errors
+		if x := recover(); x != nil {
+			t, r, err = nil, nil, fmt.Errorf("%v", x)
+		}
+	}()
+	r = m.termReader(text)
+	t, err = r.Next()
+	if err == read.NoMoreTerms {
+		err = fmt.Errorf("unexpected end of file")
+	}
+	return t, r, err
+}
+
+// parseText parses text holding a single term, with or without a
+// final full stop, like term_to_atom/2 does
+func (m *machine) parseText(text string) (term.Term, *read.TermReader, error) {
+	s := newInputStream(strings.NewReader(text))
+	clause, complete := s.readClause()
+	if !complete {
+		clause += " ."
+	} else if rest, _ := s.readClause(); strings.TrimSpace(rest) != "" {
+		return nil, nil, fmt.Errorf("expected exactly one term in %q", text)
+	}
+	return m.parseClause(clause)
+}
+
+// readClause reads the text of the next clause from s, up to and
+// including its end token.  Comments are replaced by layout.  Returns
+// false if the stream ended before the end token, in which case text
+// holds whatever was read.
+func (s *Stream) readClause() (text string, complete bool) {
+	var buf bytes.Buffer
+	prev := ' ' // most recent character outside of comments
+	for {
+		c, ok := s.readRune(false)
+		if !ok {
+			return buf.String(), false
+		}
+		next, more := s.readRune(true)
+
+		switch {
+		case c == '%':
+			for c != '\n' {
+				if c, ok = s.readRune(false); !ok {
+					return buf.String(), false
+				}
+			}
+			buf.WriteRune('\n')
+			prev = ' '
+		case c == '/' && more && next == '*':
+			s.readRune(false)
+			for last := ' '; ; last = c {
+				if c, ok = s.readRune(false); !ok {
+					return buf.String(), false
+				}
+				if last == '*' && c == '/' {
+					break
+				}
+			}
+			buf.WriteRune(' ')
+			prev = ' '
+		case c == '\'' && isCharacterCodeStart(buf.Bytes()):
+			// character code like 0'a
+			buf.WriteRune(c)
+			if c, ok = s.readRune(false); !ok {
+				return buf.String(), false
+			}
+			buf.WriteRune(c)
+			next, more = s.readRune(true)
+			if c == '\\' || (c == '\'' && more && next == '\'') {
+				c, _ = s.readRune(false)
+				buf.WriteRune(c)
+			}
+			prev = c
+		case c == '\'' || c == '"' || c == '`':
+			quote := c
+			buf.WriteRune(c)
+			for {
+				if c, ok = s.readRune(false); !ok {
+					return buf.String(), false
+				}
+				buf.WriteRune(c)
+				if c == '\\' {
+					if c, ok = s.readRune(false); !ok {
+						return buf.String(), false
+					}
+					buf.WriteRune(c)
+				} else if c == quote {
+					break
+				}
+			}
+			prev = quote
+		case c == '.' && !lex.IsGraphic(prev) && (!more || unicode.IsSpace(next) || next == '%'):
+			buf.WriteRune(c)
+			if more && unicode.IsSpace(next) {
+				s.readRune(false) // the end token includes one layout character
+			}
+			return buf.String(), true
+		default:
+			buf.WriteRune(c)
+			if !unicode.IsSpace(c) {
+				prev = c
+			}
+		}
+	}
+}
+
+// isCharacterCodeStart returns true if text ends with a 0 which starts
+// a number token, so that a quote after it begins a character code like
+// 0'a
+func isCharacterCodeStart(text []byte) bool {
+	if len(text) == 0 || text[len(text)-1] != '0' {
+		return false
+	}
+	c, _ := utf8.DecodeLastRune(text[:len(text)-1])
+	return !isAlphanumeric(c)
+}
+
+// readTermFrom reads a term from an input stream and unifies it, and
+// the information requested by options, with the arguments of
+// read_term/3.  At the end of the stream, the term is end_of_file.
+func (m *machine) readTermFrom(s *Stream, t term.Term, opts readOptions) ForeignReturn {
+	text, complete := s.readClause()
+	if !complete && strings.TrimSpace(text) == "" {
+		return opts.unify(t, term.NewAtom("end_of_file"), nil)
+	}
+	if !complete {
+		return m.syntaxError(opts, fmt.Errorf("unexpected end of file"))
+	}
+	parsed, r, err := m.parseClause(text)
+	if err != nil {
+		return m.syntaxError(opts, err)
+	}
+	return opts.unify(t, parsed, r)
+}
+
+// syntaxError handles a syntax error as requested by the syntax_errors
+// option
+func (m *machine) syntaxError(opts readOptions, err error) ForeignReturn {
+	switch opts.syntaxErrors {
+	case "fail":
+		msg := fmt.Sprintf("Warning: Syntax error: %s\n", err)
+		mustStream(m, term.NewAtom("user_error")).write([]byte(msg))
+		return ForeignFail()
+	case "quiet":
+		return ForeignFail()
+	}
+	panic(term.SyntaxError(err.Error()))
+}
+
+// unify returns a foreign result which unifies t with a term that was
+// read and each requested option with the corresponding information
+// from the reader.  r is nil if nothing was read.
+func (opts readOptions) unify(t, parsed term.Term, r *read.TermReader) ForeignReturn {
+	var named, singletons []*term.Variable
+	if r != nil {
+		named, singletons = r.Variables(), r.Singletons()
+	}
+	bindings := func(vs []*term.Variable) term.Term {
+		ts := make([]term.Term, len(vs))
+		for i, v := range vs {
+			ts[i] = term.NewCallable("=", term.NewAtom(v.Name), v)
+		}
+		return term.NewTermList(ts)
+	}
+
+	pairs := []term.Term{t, parsed}
+	if opts.variables != nil {
+		vars := term.TermVariables(parsed)
+		ts := make([]term.Term, len(vars))
+		for i, v := range vars {
+			ts[i] = v
+		}
+		pairs = append(pairs, opts.variables, term.NewTermList(ts))
+	}
+	if opts.variableNames != nil {
+		pairs = append(pairs, opts.variableNames, bindings(named))
+	}
+	if opts.singletons != nil {
+		pairs = append(pairs, opts.singletons, bindings(singletons))
+	}
+	return ForeignUnify(pairs...)
+}
@@ -0,0 +1,30 @@
+package golog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadTerm(t *testing.T) {
+	input := strings.Join([]string{
+		`% a comment with a full stop. in it`,
+		`a('x. y', "z. ", 0'., 0'', /* b. */ c).`,
+		`X = 1.5, Y =.. [f]. % trailing comment`,
+		`b.`,
+		`!`,
+	}, "\n")
+	m := NewMachine().RegisterInput("user_input", strings.NewReader(input))
+
+	goal := `read(T1), T1 = a(A, _, 46, 39, c), atom_length(A, 4),
+	         read_term(T2, [variable_names(Ns)]), Ns = ['X'=_, 'Y'=_],
+	         read(b), get_char('!'), % the end token ate the newline
+	         read(end_of_file).`
+	if !m.CanProve(goal) {
+		t.Errorf("Can't read terms from a stream")
+	}
+
+	m = NewMachine().RegisterInput("user_input", strings.NewReader(`foo(`))
+	if !m.CanProve(`catch(read(_), error(syntax_error(_), _), true).`) {
+		t.Errorf("Unfinished term isn't a syntax error")
+	}
+}
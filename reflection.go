@@ -0,0 +1,129 @@
+package golog
+
+// Reflection lets Prolog code inspect the predicates a machine knows
+// about.  Predicates come from two places: clauses in the database and
+// foreign predicates implemented in Go.
+
+import (
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mndrix/golog/read"
+	"github.com/mndrix/golog/term"
+	. "github.com/mndrix/golog/util"
+)
+
+// source describes where a consulted predicate was defined
+type source struct {
+	file string // absolute path, if the clauses came from a file
+	line int    // line on which the first clause starts
+}
+
+// sourceFile returns the absolute path of the file from which text
+// is consulted.  Returns "" if text doesn't come from a file.
+func sourceFile(text interface{}) string {
+	f, ok := text.(interface {
+		Name() string
+	})
+	if !ok {
+		return ""
+	}
+	path, err := filepath.Abs(f.Name())
+	if err != nil {
+		return f.Name()
+	}
+	return path
+}
+
+// splitIndicator returns the name and arity from a predicate indicator
+// like foo/2
+func splitIndicator(indicator string) (string, int) {
+	i := strings.LastIndex(indicator, "/")
+	arity, err := strconv.Atoi(indicator[i+1:])
+	MaybePanic(err)
+	return indicator[:i], arity
+}
+
+// predicateIndicator returns a term like foo/2
+func predicateIndicator(name string, arity int) term.Term {
+	return term.NewCallable("/", term.NewAtom(name), term.NewInt64(int64(arity)))
+}
+
+// predicateHead returns a term with the given name and arity whose
+// arguments are all fresh variables
+func predicateHead(name string, arity int) term.Callable {
+	args := make([]term.Term, arity)
+	for i := range args {
+		args[i] = term.NewVar("_")
+	}
+	return term.NewCallable(name, args...)
+}
+
+// predicates returns the indicator of each predicate defined in this
+// machine, whether by clauses or in Go, in sorted order
+func (m *machine) predicates() []string {
+	seen := make(map[string]bool)
+	for _, indicator := range m.db.Predicates() {
+		seen[indicator] = true
+	}
+	for arity, fs := range m.smallForeign {
+		for _, name := range fs.Keys() {
+			seen[name+"/"+strconv.Itoa(arity)] = true
+		}
+	}
+	for _, indicator := range m.largeForeign.Keys() {
+		seen[indicator] = true
+	}
+
+	indicators := make([]string, 0, len(seen))
+	for indicator := range seen {
+		indicators = append(indicators, indicator)
+	}
+	sort.Strings(indicators)
+	return indicators
+}
+
+// operators returns the entries in this machine's operator table
+func (m *machine) operators() []read.Operator {
+	return m.ops.List()
+}
+
+// isDefined returns true if head's predicate is defined in this machine
+func (m *machine) isDefined(head term.Callable) bool {
+	if _, ok := m.lookupForeign(head); ok {
+		return true
+	}
+	_, err := m.db.Candidates(head)
+	return err == nil
+}
+
+// predicateProperties returns the properties of head's predicate, as
+// reported by predicate_property/2.  The predicate must be defined.
+func (m *machine) predicateProperties(head term.Callable) []term.Term {
+	indicator := head.Indicator()
+	props := []term.Term{term.NewAtom("defined")}
+	if m.db.IsDynamic(indicator) {
+		props = append(props, term.NewAtom("dynamic"))
+	}
+	if _, ok := m.lookupForeign(head); ok {
+		props = append(props, term.NewAtom("foreign"))
+		if _, ok := m.library.Lookup(indicator); !ok {
+			props = append(props, term.NewAtom("built_in"))
+		}
+		return props
+	}
+
+	clauses, _ := m.db.Candidates(predicateHead(head.Name(), head.Arity()))
+	n := term.NewInt64(int64(len(clauses)))
+	props = append(props, term.NewCallable("number_of_clauses", n))
+	if src, ok := m.sources.Lookup(indicator); ok {
+		if file := src.(*source).file; file != "" {
+			props = append(props, term.NewCallable("file", term.NewAtom(file)))
+		}
+		line := term.NewInt64(int64(src.(*source).line))
+		props = append(props, term.NewCallable("line_count", line))
+	}
+	return props
+}
@@ -0,0 +1,394 @@
+package golog
+
+// Streams are the sources and sinks for Prolog I/O.  Each stream wraps
+// a Go io.Reader or io.Writer.  A machine keeps a table of its open
+// streams, their aliases and the current input and output streams.
+// Opening and closing streams are side effects, so like nb_setval/2
+// values, the table survives backtracking.  See carryGlobals and
+// ISO §7.10
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/mndrix/golog/term"
+	"github.com/mndrix/ps"
+)
+
+// Stream is a Prolog stream.  See ISO §7.10.1
+type Stream struct {
+	id        int64
+	file      string // file name, for streams opened by open/3,4
+	mode      string // read, write or append
+	binary    bool   // binary streams hold bytes.  text streams hold characters
+	eofAction string // error, eof_code or reset
+	reader    *bufio.Reader
+	writer    io.Writer
+	closer    io.Closer // nil if closing the stream leaves its source open
+	pastEnd   bool      // true once end_of_file has been read
+}
+
+// the standard streams are shared by all machines
+var (
+	userInput  = &Stream{id: 0, mode: "read", eofAction: "reset", reader: bufio.NewReader(os.Stdin)}
+	userOutput = &Stream{id: 1, mode: "append", eofAction: "error", writer: os.Stdout}
+	userError  = &Stream{id: 2, mode: "append", eofAction: "error", writer: os.Stderr}
+)
+
+// streamCounter is the identifier of the most recently created stream
+var streamCounter int64 = 2
+
+// newInputStream creates a text stream which reads from r
+func newInputStream(r io.Reader) *Stream {
+	return &Stream{
+		id:        atomic.AddInt64(&streamCounter, 1),
+		mode:      "read",
+		eofAction: "error",
+		reader:    bufio.NewReader(r),
+	}
+}
+
+// newOutputStream creates a text stream which writes to w
+func newOutputStream(w io.Writer, mode string) *Stream {
+	return &Stream{
+		id:        atomic.AddInt64(&streamCounter, 1),
+		mode:      mode,
+		eofAction: "error",
+		writer:    w,
+	}
+}
+
+// Term returns the term by which Prolog code refers to this stream
+func (s *Stream) Term() term.Term {
+	return term.NewCallable("$stream", term.NewInt64(s.id))
+}
+
+func (s *Stream) isInput() bool {
+	return s.reader != nil
+}
+
+func (s *Stream) isOutput() bool {
+	return s.writer != nil
+}
+
+func (s *Stream) isStandard() bool {
+	return s == userInput || s == userOutput || s == userError
+}
+
+// checkPastEnd handles an attempt to read after end_of_file according
+// to the stream's eof_action
+func (s *Stream) checkPastEnd() {
+	if !s.pastEnd {
+		return
+	}
+	switch s.eofAction {
+	case "error":
+		panic(term.PermissionError("input", "past_end_of_stream", s.Term()))
+	case "reset":
+		s.pastEnd = false
+	}
+}
+
+// readRune returns the next character from this stream.  If peek is
+// true, the character is left on the stream.  Returns false at the end
+// of the stream.
+func (s *Stream) readRune(peek bool) (rune, bool) {
+	s.checkPastEnd()
+	c, _, err := s.reader.ReadRune()
+	if err == io.EOF {
+		s.pastEnd = !peek
+		return 0, false
+	}
+	if err != nil {
+		panic(term.IOError("read", s.Term()))
+	}
+	if peek {
+		_ = s.reader.UnreadRune()
+	}
+	return c, true
+}
+
+// readByte is like readRune but for binary streams
+func (s *Stream) readByte(peek bool) (byte, bool) {
+	s.checkPastEnd()
+	b, err := s.reader.ReadByte()
+	if err == io.EOF {
+		s.pastEnd = !peek
+		return 0, false
+	}
+	if err != nil {
+		panic(term.IOError("read", s.Term()))
+	}
+	if peek {
+		_ = s.reader.UnreadByte()
+	}
+	return b, true
+}
+
+// write sends bytes to this stream
+func (s *Stream) write(p []byte) {
+	if _, err := s.writer.Write(p); err != nil {
+		panic(term.IOError("write", s.Term()))
+	}
+}
+
+// endOfStream describes an input stream's position as at, past or not,
+// like the end_of_stream property.  Standard input is never checked
+// because doing so would wait for the user to type something.
+func (s *Stream) endOfStream() string {
+	if s.pastEnd {
+		return "past"
+	}
+	if s != userInput && s.reader.Buffered() == 0 {
+		if _, err := s.reader.Peek(1); err == io.EOF {
+			return "at"
+		}
+	}
+	return "not"
+}
+
+// properties returns this stream's properties for stream_property/2
+func (s *Stream) properties(aliases []string) []term.Term {
+	var props []term.Term
+	prop := func(name string, value string) {
+		props = append(props, term.NewCallable(name, term.NewAtom(value)))
+	}
+
+	if s.file != "" {
+		prop("file_name", s.file)
+	}
+	prop("mode", s.mode)
+	if s.isInput() {
+		props = append(props, term.NewAtom("input"))
+	} else {
+		props = append(props, term.NewAtom("output"))
+	}
+	for _, alias := range aliases {
+		prop("alias", alias)
+	}
+	if s.isInput() {
+		prop("end_of_stream", s.endOfStream())
+	}
+	prop("eof_action", s.eofAction)
+	prop("reposition", "false")
+	if s.binary {
+		prop("type", "binary")
+	} else {
+		prop("type", "text")
+	}
+	return props
+}
+
+// openFile opens the named file as a stream.  mode is read, write or
+// append.  source is the term which named the file, for errors.
+func openFile(source term.Term, name string, mode term.Term) *Stream {
+	var f *os.File
+	var err error
+	switch mode.(*term.Atom).Name() {
+	case "read":
+		f, err = os.Open(name)
+	case "write":
+		f, err = os.Create(name)
+	case "append":
+		f, err = os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	default:
+		panic(term.DomainError("io_mode", mode))
+	}
+	switch {
+	case os.IsNotExist(err):
+		panic(term.ExistenceError("source_sink", source))
+	case os.IsPermission(err):
+		panic(term.PermissionError("open", "source_sink", source))
+	case err != nil:
+		panic(term.IOError("open", source))
+	}
+
+	var s *Stream
+	if mode.(*term.Atom).Name() == "read" {
+		s = newInputStream(f)
+	} else {
+		s = newOutputStream(f, mode.(*term.Atom).Name())
+	}
+	s.file = sourceFile(f)
+	s.closer = f
+	return s
+}
+
+// addStream returns a machine which knows about a new stream.  If alias
+// isn't empty, it refers to the stream.
+func (m *machine) addStream(s *Stream, alias string) *machine {
+	m1 := m.clone()
+	m1.streams = m.streams.Set(strconv.FormatInt(s.id, 10), s)
+	if alias != "" {
+		m1.aliases = m.aliases.Set(alias, s)
+	}
+	return m1
+}
+
+// removeStream returns a machine which no longer knows about a stream.
+// If the stream was the current input or output, the stream known as
+// user_input or user_output takes its place.
+func (m *machine) removeStream(s *Stream) *machine {
+	m1 := m.clone()
+	m1.streams = m.streams.Delete(strconv.FormatInt(s.id, 10))
+	for _, alias := range m.streamAliases(s) {
+		m1.aliases = m1.aliases.Delete(alias)
+	}
+	if m.input == s {
+		m1.input = mustStream(m1, term.NewAtom("user_input"))
+	}
+	if m.output == s {
+		m1.output = mustStream(m1, term.NewAtom("user_output"))
+	}
+	return m1
+}
+
+// streamAliases returns the aliases which refer to a stream, in sorted
+// order
+func (m *machine) streamAliases(s *Stream) []string {
+	var aliases []string
+	m.aliases.ForEach(func(alias string, v interface{}) {
+		if v.(*Stream) == s {
+			aliases = append(aliases, alias)
+		}
+	})
+	sort.Strings(aliases)
+	return aliases
+}
+
+// openStreams returns every stream in this machine's table, in the
+// order they were created
+func (m *machine) openStreams() []*Stream {
+	streams := make([]*Stream, 0, m.streams.Size())
+	m.streams.ForEach(func(_ string, v interface{}) {
+		streams = append(streams, v.(*Stream))
+	})
+	sort.Slice(streams, func(i, j int) bool { return streams[i].id < streams[j].id })
+	return streams
+}
+
+func (m *machine) WithOutput(w io.Writer) Machine {
+	return m.RegisterOutput("user_output", w)
+}
+
+func (m *machine) WithError(w io.Writer) Machine {
+	return m.RegisterOutput("user_error", w)
+}
+
+func (m *machine) RegisterInput(alias string, r io.Reader) Machine {
+	s := newInputStream(r)
+	m1 := m.addStream(s, alias)
+	if alias == "user_input" {
+		m1.input = s
+	}
+	return m1
+}
+
+func (m *machine) RegisterOutput(alias string, w io.Writer) Machine {
+	s := newOutputStream(w, "append")
+	m1 := m.addStream(s, alias)
+	if alias == "user_output" {
+		m1.output = s
+	}
+	return m1
+}
+
+// newStreamTable returns the stream table for a new machine, which
+// holds just the standard streams
+func newStreamTable() (streams, aliases ps.Map) {
+	streams, aliases = ps.NewMap(), ps.NewMap()
+	for alias, s := range map[string]*Stream{
+		"user_input":  userInput,
+		"user_output": userOutput,
+		"user_error":  userError,
+	} {
+		streams = streams.Set(strconv.FormatInt(s.id, 10), s)
+		aliases = aliases.Set(alias, s)
+	}
+	return streams, aliases
+}
+
+// outputSink returns the kind of text collected by an output sink like
+// atom(A), string(S), codes(Cs) or chars(Cs).  Returns false if t isn't
+// an output sink.
+func outputSink(t term.Term) (string, bool) {
+	switch t.Indicator() {
+	case "atom/1", "string/1", "codes/1", "chars/1":
+		return t.(*term.Compound).Name(), true
+	}
+	return "", false
+}
+
+// sinkText returns a term of the given kind, as described by
+// outputSink, which holds text
+func sinkText(kind, text string) term.Term {
+	switch kind {
+	case "atom":
+		return term.NewAtom(text)
+	case "string":
+		return term.NewString(text)
+	case "codes":
+		return term.NewCodeList(text)
+	}
+	return newCharList(text)
+}
+
+// mustStream returns the stream to which a stream term or alias refers.
+// Panics with an ISO error if there's no such stream.
+func mustStream(m Machine, t term.Term) *Stream {
+	m1 := m.(*machine)
+	switch {
+	case term.IsVariable(t):
+		panic(term.InstantiationError())
+	case term.IsAtom(t):
+		if s, ok := m1.aliases.Lookup(t.(*term.Atom).Name()); ok {
+			return s.(*Stream)
+		}
+		panic(term.ExistenceError("stream", t))
+	case term.IsCompound(t) && t.Indicator() == "$stream/1":
+		id := t.(*term.Compound).Arguments()[0]
+		if term.IsInteger(id) {
+			if s, ok := m1.streams.Lookup(id.String()); ok {
+				return s.(*Stream)
+			}
+			panic(term.ExistenceError("stream", t))
+		}
+	}
+	panic(term.DomainError("stream_or_alias", t))
+}
+
+// mustInputStream is like mustStream but the stream must be an input
+// stream of the given type
+func mustInputStream(m Machine, t term.Term, binary bool) *Stream {
+	s := mustStream(m, t)
+	if !s.isInput() {
+		panic(term.PermissionError("input", "stream", t))
+	}
+	if s.binary && !binary {
+		panic(term.PermissionError("input", "binary_stream", t))
+	}
+	if !s.binary && binary {
+		panic(term.PermissionError("input", "text_stream", t))
+	}
+	return s
+}
+
+// mustOutputStream is like mustStream but the stream must be an output
+// stream of the given type
+func mustOutputStream(m Machine, t term.Term, binary bool) *Stream {
+	s := mustStream(m, t)
+	if !s.isOutput() {
+		panic(term.PermissionError("output", "stream", t))
+	}
+	if s.binary && !binary {
+		panic(term.PermissionError("output", "binary_stream", t))
+	}
+	if !s.binary && binary {
+		panic(term.PermissionError("output", "text_stream", t))
+	}
+	return s
+}
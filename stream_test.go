@@ -0,0 +1,113 @@
+package golog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/mndrix/golog/term"
+)
+
+func TestRegisterStreams(t *testing.T) {
+	var out bytes.Buffer
+	m := NewMachine().
+		RegisterInput("greeting", strings.NewReader("hi")).
+		RegisterOutput("log", &out)
+
+	if !m.CanProve(`get_char(greeting, h), put_char(log, x), nl(log).`) {
+		t.Errorf("Can't use registered streams")
+	}
+	if out.String() != "x\n" {
+		t.Errorf("Wrong output: %q", out.String())
+	}
+
+	// replacing user_output changes the current output
+	out.Reset()
+	m = NewMachine().RegisterOutput("user_output", &out)
+	if !m.CanProve(`put_char(a), nl, format('~w~n', [b]).`) {
+		t.Errorf("Can't write to current output")
+	}
+	if out.String() != "a\nb\n" {
+		t.Errorf("Wrong current output: %q", out.String())
+	}
+
+	// replacing user_input changes the current input
+	m = NewMachine().RegisterInput("user_input", strings.NewReader("z"))
+	if !m.CanProve(`get_char(z), get_char(end_of_file).`) {
+		t.Errorf("Can't read from current input")
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "golog")
+	if err != nil {
+		t.Fatalf("Can't create directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "out.txt")
+
+	m := NewMachine().Consult(`
+        write_file(File, Mode, Chars) :-
+            open(File, Mode, S),
+            put_chars(S, Chars),
+            close(S).
+
+        put_chars(_, []).
+        put_chars(S, [C|Cs]) :-
+            put_char(S, C),
+            put_chars(S, Cs).
+    `)
+	goal := `write_file('` + file + `', write, [a,b]), write_file('` + file + `', append, [c]).`
+	if !m.CanProve(goal) {
+		t.Fatalf("Can't write file")
+	}
+	content, err := ioutil.ReadFile(file)
+	if err != nil || string(content) != "abc" {
+		t.Errorf("Wrong file content: %q, %v", content, err)
+	}
+
+	// opening a stream survives backtracking
+	binary := filepath.Join(dir, "out.bin")
+	goal = `( open('` + binary + `', write, _, [type(binary), alias(out)]), fail ; true ),
+            put_byte(out, 200), close(out).`
+	if !m.CanProve(goal) {
+		t.Fatalf("Can't write binary file")
+	}
+	content, err = ioutil.ReadFile(binary)
+	if err != nil || !bytes.Equal(content, []byte{200}) {
+		t.Errorf("Wrong binary content: %v, %v", content, err)
+	}
+}
+
+func TestWithOutput(t *testing.T) {
+	base := NewMachine().Consult(`greet(Name) :- format("hello ~w~n", [Name]).`)
+
+	// each machine writes to its own sink
+	var alice, bob, errors bytes.Buffer
+	m1 := base.WithOutput(&alice)
+	m2 := base.WithOutput(&bob).WithError(&errors)
+	if !m1.CanProve(`greet(alice).`) || !m2.CanProve(`greet(bob).`) {
+		t.Fatalf("Can't greet")
+	}
+	if alice.String() != "hello alice\n" {
+		t.Errorf("Wrong output for alice: %q", alice.String())
+	}
+	if bob.String() != "hello bob\n" {
+		t.Errorf("Wrong output for bob: %q", bob.String())
+	}
+
+	// warnings go to the error sink
+	m2, err := m2.SetPrologFlag("unknown", NewAtom("warning"))
+	if err != nil {
+		t.Fatalf("Can't set unknown: %s", err)
+	}
+	if m2.CanProve(`nope.`) {
+		t.Errorf("Proved an unknown procedure")
+	}
+	if errors.String() != "Warning: Unknown procedure: nope/0\n" {
+		t.Errorf("Wrong warning: %q", errors.String())
+	}
+}
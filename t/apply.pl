@@ -0,0 +1,102 @@
+% Tests for library(apply) and call/N
+%
+% These predicates follow SWI-Prolog's library(apply)
+
+% helpers
+even(X) :-
+    0 =:= X mod 2.
+
+double(X, Y) :-
+    Y is 2 * X.
+
+add(X, Y0, Y) :-
+    Y is Y0 + X.
+
+sum3(A, B, C, S) :-
+    S is A + B + C.
+
+cmp(Pivot, X, Order) :-
+    compare(Order, X, Pivot).
+
+bad_order(_, bad).
+
+args(A, B, C, D, E, F, G, t(A, B, C, D, E, F, G)).
+
+:- use_module(library(tap)).
+
+'call/7' :-
+    call(args(a, b), c, d, e, f, g, T),
+    T == t(a, b, c, d, e, f, g).
+'call/8' :-
+    call(args(a), b, c, d, e, f, g, T),
+    T == t(a, b, c, d, e, f, g).
+
+'maplist/2' :-
+    maplist(even, [2, 4, 6]).
+'maplist/2 fails'(fail) :-
+    maplist(even, [2, 3, 6]).
+'maplist/3' :-
+    maplist(double, [1, 2, 3], L),
+    L == [2, 4, 6].
+'maplist/3 closure' :-
+    maplist(add(10), [1, 2], L),
+    L == [11, 12].
+'maplist/2 binds' :-
+    length(L, 2),
+    maplist(=(x), L),
+    L == [x, x].
+'maplist/3 different lengths'(fail) :-
+    maplist(=, [a, b], [a]).
+'maplist/4' :-
+    maplist(add, [1, 2], [10, 20], L),
+    L == [11, 22].
+'maplist/5' :-
+    maplist(sum3, [1, 2], [10, 20], [100, 200], L),
+    L == [111, 222].
+'maplist/6' :-
+    maplist(args(a, b, c), [d], [e], [f], [g], [T]),
+    T == t(a, b, c, d, e, f, g).
+'maplist/7' :-
+    maplist(args(a, b), [c], [d], [e], [f], [g], [T]),
+    T == t(a, b, c, d, e, f, g).
+'maplist/3 enumerates' :-
+    findall(L, maplist(member, L, [[a, b], [c]]), Ls),
+    Ls == [[a, c], [b, c]].
+
+'foldl/4' :-
+    foldl(add, [1, 2, 3], 0, Sum),
+    Sum == 6.
+'foldl/4 empty' :-
+    foldl(add, [], x, V),
+    V == x.
+'foldl/5' :-
+    foldl(sum3, [1, 2], [10, 20], 0, Sum),
+    Sum == 33.
+'foldl/6' :-
+    foldl(args(a, b, c), [d], [e], [f], g, V),
+    V == t(a, b, c, d, e, f, g).
+'foldl/7' :-
+    foldl(args(a, b), [c], [d], [e], [f], g, V),
+    V == t(a, b, c, d, e, f, g).
+
+'include/3' :-
+    include(even, [1, 2, 3, 4], L),
+    L == [2, 4].
+'exclude/3' :-
+    exclude(even, [1, 2, 3, 4], L),
+    L == [1, 3].
+'partition/4' :-
+    partition(even, [1, 2, 3, 4, 5], I, E),
+    I == [2, 4],
+    E == [1, 3, 5].
+'partition/5' :-
+    partition(cmp(3), [1, 5, 3, 2, 4, 3], L, E, G),
+    L == [1, 2],
+    E == [3, 3],
+    G == [5, 4].
+'partition/5 bad order'(throws(error(domain_error(order, bad), _))) :-
+    partition(bad_order, [1], _, _, _).
+
+'aggregate_all/3 over a list' :-
+    aggregate_all(sum(X), member(X, [1, 2, 3]), Sum),
+    Sum == 6.
@@ -0,0 +1,101 @@
+% Tests for library(assoc)
+%
+% These predicates follow SWI-Prolog's library(assoc)
+
+% helpers
+put_each(K, A0, A) :-
+    put_assoc(K, A0, K, A).
+
+% balanced(+Assoc, -Height) is semidet.
+%
+% True if Assoc is a valid AVL tree with the given height
+balanced(t, 0).
+balanced(t(_,_,B,L,R), H) :-
+    balanced(L, HL),
+    balanced(R, HR),
+    D is HL - HR,
+    balance(D, B),
+    H is max(HL, HR) + 1.
+
+balance(-1, <).
+balance(0, =).
+balance(1, >).
+
+:- use_module(library(tap)).
+
+empty_assoc :-
+    empty_assoc(A),
+    assoc_to_list(A, L),
+    L == [].
+get_assoc :-
+    list_to_assoc([a-1, b-2, c-3], A),
+    get_assoc(b, A, V),
+    V == 2.
+'get_assoc missing'(fail) :-
+    list_to_assoc([a-1, b-2, c-3], A),
+    get_assoc(d, A, _).
+put_assoc :-
+    list_to_assoc([a-1], A0),
+    put_assoc(b, A0, 2, A),
+    assoc_to_list(A, L),
+    L == [a-1, b-2].
+'put_assoc replaces' :-
+    list_to_assoc([a-1, b-2], A0),
+    put_assoc(a, A0, 10, A),
+    assoc_to_list(A, L),
+    L == [a-10, b-2].
+'put_assoc is persistent' :-
+    list_to_assoc([a-1], A0),
+    put_assoc(a, A0, 2, _),
+    get_assoc(a, A0, V),
+    V == 1.
+'list_to_assoc duplicates'(throws(error(domain_error(unique_key_pairs, _), _))) :-
+    list_to_assoc([a-1, a-2], _).
+'list_to_assoc unbound'(throws(error(instantiation_error, _))) :-
+    list_to_assoc(_, _).
+'list_to_assoc partial list'(throws(error(instantiation_error, _))) :-
+    list_to_assoc([a-1|_], _).
+'list_to_assoc not a list'(throws(error(type_error(list, foo), _))) :-
+    list_to_assoc(foo, _).
+assoc_to_keys :-
+    list_to_assoc([c-3, a-1, b-2], A),
+    assoc_to_keys(A, K),
+    K == [a, b, c].
+assoc_to_values :-
+    list_to_assoc([c-3, a-1, b-2], A),
+    assoc_to_values(A, V),
+    V == [1, 2, 3].
+gen_assoc :-
+    list_to_assoc([c-3, a-1, b-2], A),
+    findall(K-V, gen_assoc(K, A, V), L),
+    L == [a-1, b-2, c-3].
+max_assoc :-
+    list_to_assoc([c-3, a-1, b-2], A),
+    max_assoc(A, K, V),
+    K-V == c-3.
+min_assoc :-
+    list_to_assoc([c-3, a-1, b-2], A),
+    min_assoc(A, K, V),
+    K-V == a-1.
+'max_assoc empty'(fail) :-
+    empty_assoc(A),
+    max_assoc(A, _, _).
+
+'ascending keys stay balanced' :-
+    numlist(1, 100, Ks),
+    foldl(put_each, Ks, t, A),
+    balanced(A, H),
+    H =< 8,
+    assoc_to_keys(A, Keys),
+    Keys == Ks.
+'descending keys stay balanced' :-
+    numlist(1, 100, Ks0),
+    reverse(Ks0, Ks),
+    foldl(put_each, Ks, t, A),
+    balanced(A, H),
+    H =< 8.
+'zigzag keys stay balanced' :-
+    foldl(put_each, [50, 10, 30, 20, 25, 90, 60, 70, 65, 5, 7, 6], t, A),
+    balanced(A, _),
+    assoc_to_keys(A, Keys),
+    Keys == [5, 6, 7, 10, 20, 25, 30, 50, 60, 65, 70, 90].
@@ -0,0 +1,127 @@
+% Tests for atom_chars/2, atom_length/2, char_code/2 and friends
+%
+% These are defined in ISO §8.16
+:- use_module(library(tap)).
+
+% examples from ISO §8.16.1.4
+'atom_length enchanted' :-
+    atom_length('enchanted evening', N),
+    N == 17.
+'atom_length empty' :-
+    atom_length('', N),
+    N == 0.
+'atom_length wrong'(fail) :-
+    atom_length('scarlet', 5).
+'atom_length variable'(throws(error(instantiation_error, _))) :-
+    atom_length(_, 4).
+'atom_length number'(throws(error(type_error(atom, 123), _))) :-
+    atom_length(123, _).
+'atom_length length'(throws(error(type_error(integer, '4'), _))) :-
+    atom_length(atom, '4').
+'atom_length negative'(throws(error(domain_error(not_less_than_zero, -1), _))) :-
+    atom_length(atom, -1).
+'atom_length unicode' :-
+    atom_length('λx', 2).
+
+% examples from ISO §8.16.4.4
+'atom_chars empty' :-
+    atom_chars('', L),
+    L == [].
+'atom_chars brackets' :-
+    atom_chars([], L),
+    L == ['[', ']'].
+'atom_chars quote' :-
+    atom_chars('\'', L),
+    L == ['\''].
+'atom_chars ant' :-
+    atom_chars(ant, L),
+    L == [a, n, t].
+'atom_chars sop' :-
+    atom_chars(Str, [s, o, p]),
+    Str == sop.
+'atom_chars partial' :-
+    atom_chars('North', ['N'|X]),
+    X == [o, r, t, h].
+'atom_chars mismatch'(fail) :-
+    atom_chars(soap, [s, o, p]).
+'atom_chars variable'(throws(error(instantiation_error, _))) :-
+    atom_chars(_, _).
+'atom_chars element'(throws(error(type_error(character, ab), _))) :-
+    atom_chars(_, [a, ab]).
+
+'atom_codes partial'(throws(error(instantiation_error, _))) :-
+    atom_codes(_, [0'a|_]).
+'atom_codes element'(throws(error(representation_error(character_code), _))) :-
+    atom_codes(_, [0'a, -1]).
+
+% examples from ISO §8.16.6.4
+'char_code a' :-
+    char_code(a, X),
+    X == 0'a.
+'char_code reverse' :-
+    char_code(Str, 0'c),
+    Str == c.
+'char_code b' :-
+    char_code(b, 0'b).
+'char_code atom'(throws(error(type_error(character, ab), _))) :-
+    char_code(ab, _).
+'char_code variables'(throws(error(instantiation_error, _))) :-
+    char_code(_, _).
+'char_code code'(throws(error(representation_error(character_code), _))) :-
+    char_code(_, -2).
+
+% examples from ISO §8.16.8.4
+'number_codes integer' :-
+    number_codes(33, L),
+    L == "33".
+'number_codes parse' :-
+    number_codes(X, "3.3"),
+    X == 3.3.
+'number_codes hex' :-
+    number_codes(X, "0xf"),
+    X == 15.
+'number_codes negative' :-
+    number_codes(X, "-25"),
+    X == -25.
+'number_codes round trip' :-
+    number_codes(0.1, L),
+    number_codes(X, L),
+    X == 0.1.
+'number_codes syntax'(throws(error(syntax_error(_), _))) :-
+    number_codes(_, "3x").
+'number_codes end token'(throws(error(syntax_error(illegal_number), _))) :-
+    number_codes(_, "12.").
+'number_codes trailing layout'(throws(error(syntax_error(illegal_number), _))) :-
+    number_codes(_, "12 ").
+'number_codes two numbers'(throws(error(syntax_error(illegal_number), _))) :-
+    number_codes(_, "1 2").
+'number_codes space after minus'(throws(error(syntax_error(illegal_number), _))) :-
+    number_codes(_, "- 1").
+'number_codes leading layout' :-
+    number_codes(X, " 12"),
+    X == 12.
+'number_chars end token'(throws(error(syntax_error(illegal_number), _))) :-
+    number_chars(_, ['1', '2', '.']).
+'number_codes variable'(throws(error(instantiation_error, _))) :-
+    number_codes(_, _).
+'number_codes atom'(throws(error(type_error(number, a), _))) :-
+    number_codes(a, _).
+
+'number_chars integer' :-
+    number_chars(X, ['4', '2']),
+    X == 42.
+'number_chars float' :-
+    number_chars(3.5, L),
+    L == ['3', '.', '5'].
+'number_chars compare' :-
+    number_chars(A, ['1', '0']),
+    A =:= 10.
+
+'upcase_atom' :-
+    upcase_atom('Hello World', X),
+    X == 'HELLO WORLD'.
+'upcase_atom number' :-
+    upcase_atom(12, X),
+    X == '12'.
+'upcase_atom variable'(throws(error(instantiation_error, _))) :-
+    upcase_atom(_, _).
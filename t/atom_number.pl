@@ -15,6 +15,11 @@ int_to_atom :-
     atom_number(A, 7),
     A = '7'.
 
+'trailing space'(fail) :-
+    atom_number('12 ', _).
+'end token'(fail) :-
+    atom_number('12.', _).
+
 atom_to_float :-
     atom_number('7.23', N),
     N = 7.23.
@@ -0,0 +1,118 @@
+% Tests for bagof/3, setof/3, forall/2, findall/4 and aggregate_all/3
+%
+% bagof/3 and setof/3 are defined in ISO §8.10.  The examples
+% come from ISO §8.10.2.4 and §8.10.3.4.  aggregate_all/3 follows
+% SWI-Prolog.
+
+% helpers
+elem(X, [X|_]).
+elem(X, [_|T]) :-
+    elem(X, T).
+
+a(1, f(_)).
+a(2, f(_)).
+
+age(peter, 7).
+age(ann, 11).
+age(pat, 8).
+age(tom, 5).
+age(mike, 11).
+
+:- use_module(library(tap)).
+
+'bagof simple' :-
+    bagof(X, (X = 1 ; X = 2), L),
+    L == [1, 2].
+'bagof template' :-
+    bagof(X, (X = Y ; X = Z), L),
+    L = [A, B],
+    A == Y,
+    B == Z.
+'bagof no solutions'(fail) :-
+    bagof(_, fail, _).
+'bagof free variable' :-
+    findall(Y-L, bagof(X, (X = 1, Y = 1 ; X = 2, Y = 2 ; X = 3, Y = 1), L), Groups),
+    Groups == [1-[1, 3], 2-[2]].
+'bagof existential' :-
+    bagof(X, Y^((X = 1, Y = 1) ; (X = 2, Y = 2)), L),
+    L == [1, 2].
+'bagof nested existential' :-
+    bagof(X, Y^Z^elem(X-Y-Z, [a-1-x, b-2-y]), L),
+    L == [a, b].
+'bagof variant witnesses' :-
+    bagof(X, a(X, Y), L),
+    L == [1, 2],
+    Y = f(W),
+    var(W).
+'bagof variable goal'(throws(error(instantiation_error, _))) :-
+    bagof(_, _, _).
+'bagof number goal'(throws(error(type_error(callable, 1), _))) :-
+    bagof(_, 1, _).
+
+'setof sorted' :-
+    setof(X, elem(X, [c, a, b, a]), L),
+    L == [a, b, c].
+'setof groups' :-
+    findall(A-Ns, setof(N, age(N, A), Ns), Groups),
+    Groups == [5-[tom], 7-[peter], 8-[pat], 11-[ann, mike]].
+'setof existential' :-
+    setof(A-N, age(N, A), L),
+    L = [5-tom|_].
+'setof caret' :-
+    setof(N, A^age(N, A), L),
+    L == [ann, mike, pat, peter, tom].
+
+'caret as a goal' :-
+    X^elem(X, [a]).
+
+forall :-
+    forall(elem(X, [1, 2, 3]), X > 0).
+'forall counterexample'(fail) :-
+    forall(elem(X, [1, -2, 3]), X > 0).
+'forall no solutions' :-
+    forall(fail, fail).
+
+'findall with tail' :-
+    findall(X, elem(X, [a, b]), L, [c]),
+    L == [a, b, c].
+'findall with open tail' :-
+    findall(X, elem(X, [a]), L, T),
+    L = [a|T2],
+    T2 == T.
+
+'aggregate_all count' :-
+    aggregate_all(count, age(_, _), N),
+    N == 5.
+'aggregate_all count no solutions' :-
+    aggregate_all(count, fail, N),
+    N == 0.
+'aggregate_all sum' :-
+    aggregate_all(sum(A), age(_, A), S),
+    S == 42.
+'aggregate_all sum empty' :-
+    aggregate_all(sum(_), fail, S),
+    S == 0.
+'aggregate_all max' :-
+    aggregate_all(max(A), age(_, A), M),
+    M == 11.
+'aggregate_all min' :-
+    aggregate_all(min(A * 2), age(_, A), M),
+    M == 10.
+'aggregate_all max empty'(fail) :-
+    aggregate_all(max(_), fail, _).
+'aggregate_all max witness' :-
+    aggregate_all(max(A, N), age(N, A), M),
+    M == max(11, ann).
+'aggregate_all min witness' :-
+    aggregate_all(min(A, N), age(N, A), M),
+    M == min(5, tom).
+'aggregate_all bag' :-
+    aggregate_all(bag(N), age(N, 11), L),
+    L == [ann, mike].
+'aggregate_all set' :-
+    aggregate_all(set(A), age(_, A), L),
+    L == [5, 7, 8, 11].
+'aggregate_all unknown'(throws(error(domain_error(aggregate_spec, avg(_)), _))) :-
+    aggregate_all(avg(_), true, _).
+'aggregate_all variable'(throws(error(instantiation_error, _))) :-
+    aggregate_all(_, true, _).
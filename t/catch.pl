@@ -0,0 +1,82 @@
+% Tests for catch/3 and throw/1
+%
+% catch/3 and throw/1 are defined in ISO §7.8.9 and §7.8.10
+
+% helper predicates
+foo(X) :-
+    Y is X * 2,
+    throw(test(Y)).
+
+bar(X) :-
+    X = Y,
+    throw(Y).
+
+coo(X) :-
+    throw(X).
+
+car(X) :-
+    X = 1,
+    throw(X).
+
+member_(X, [X|_]).
+member_(X, [_|T]) :-
+    member_(X, T).
+
+:- use_module(library(tap)).
+
+% Tests derived from examples in ISO §7.8.9.4
+'foo/1' :-
+    catch(foo(5), test(Y), true),
+    Y = 10.
+'bar/1' :-
+    catch(bar(3), Z, true),
+    Z = 3.
+'true/0' :-
+    catch(true, _, 3).
+'car/1' :-
+    catch(car(_X), Y, true),
+    Y = 1.
+'catcher mismatch'(throws(f(_))) :-
+    catch(coo(f(_)), g(_), true).
+
+% Tests derived from examples in ISO §7.8.10.4
+'variable ball'(throws(instantiation_error)) :-
+    throw(_).
+
+% Tests covering edge cases
+'recovery sees bindings before catch/3' :-
+    X = a,
+    catch((Y = b, throw(oops)), oops, true),
+    X == a,
+    var(Y).
+'backtracking into goal' :-
+    findall(X, catch(member_(X, [1,2,3]), _, true), Xs),
+    Xs = [1,2,3].
+'exited catch no longer active'(throws(late)) :-
+    catch(member_(_, [1,2]), late, true),
+    throw(late).
+'nested catch' :-
+    catch(catch(throw(inner), outer, fail), inner, true).
+'rethrow from recovery' :-
+    catch(catch(throw(first), first, throw(second)), second, true).
+'cut inside goal is local' :-
+    findall(X, catch((member_(X, [1,2]), !), _, true), Xs),
+    Xs = [1].
+'error context' :-
+    catch(_ is foo + 1, error(type_error(T, C), context(P, _)), true),
+    T = evaluable,
+    C = foo/0,
+    P = (is)/2.
+'throw/1 leaves context alone' :-
+    catch(throw(error(foo, _)), error(foo, C), true),
+    var(C).
+'throw/1 inside \\+ leaves context alone' :-
+    catch(\+ throw(error(foo, _)), error(foo, C), true),
+    var(C).
+'error context inside \\+' :-
+    catch(\+ atom_length(_, _), error(instantiation_error, context(P, _)), true),
+    P == atom_length/2.
+'exception inside \\+' :-
+    catch(\+ throw(deep), deep, true).
+'exception inside findall/3' :-
+    catch(findall(X, (X = 1 ; throw(deep)), _), deep, true).
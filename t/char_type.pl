@@ -0,0 +1,118 @@
+% Tests for char_type/2 and code_type/2
+%
+% These predicates follow the semantics of SWI-Prolog
+:- use_module(library(tap)).
+
+alpha :-
+    char_type(a, alpha).
+'alpha unicode' :-
+    char_type('é', alpha).
+'alpha digit' :-
+    char_type('1', alpha).
+'alpha underscore' :-
+    char_type('_', alpha).
+'alpha punctuation'(fail) :-
+    char_type('-', alpha).
+'csymf letter' :-
+    char_type(a, csymf).
+alnum :-
+    char_type('7', alnum).
+csym :-
+    char_type('_', csym).
+'csymf digit'(fail) :-
+    char_type('1', csymf).
+digit :-
+    char_type('7', digit(W)),
+    W == 7.
+'digit other script' :-
+    char_type('٣', digit(W)),  % ARABIC-INDIC DIGIT THREE
+    W == 3.
+'digit letter'(fail) :-
+    char_type(x, digit(_)).
+xdigit :-
+    char_type('F', xdigit(W)),
+    W == 15.
+space :-
+    char_type(' ', space).
+'space newline' :-
+    code_type(0'\n, space).
+white :-
+    code_type(0'\t, white).
+'white newline'(fail) :-
+    code_type(0'\n, white).
+end_of_line :-
+    code_type(0'\n, end_of_line).
+'end_of_line carriage return' :-
+    code_type(0'\r, end_of_line).
+'end_of_line vertical tab' :-
+    code_type(11, end_of_line).
+'end_of_line form feed' :-
+    code_type(12, end_of_line).
+'end_of_line end of file' :-
+    code_type(-1, end_of_line).
+'end_of_line end_of_file' :-
+    char_type(end_of_file, end_of_line).
+'end of file is only end_of_line' :-
+    findall(T, code_type(-1, T), Ts),
+    Ts == [end_of_line].
+'end_of_line space'(fail) :-
+    code_type(0' , end_of_line).
+'upper' :-
+    char_type('A', upper(L)),
+    L == a.
+'upper lower case'(fail) :-
+    char_type(a, upper(_)).
+lower :-
+    char_type(b, lower(U)),
+    U == 'B'.
+punct :-
+    char_type('!', punct).
+'punct letter'(fail) :-
+    char_type(a, punct).
+graph :-
+    char_type('#', graph).
+'graph space'(fail) :-
+    char_type(' ', graph).
+to_lower :-
+    char_type('A', to_lower(L)),
+    L == a.
+'to_lower already lower' :-
+    char_type(a, to_lower(L)),
+    L == a.
+to_upper :-
+    char_type(a, to_upper(U)),
+    U == 'A'.
+
+'enumerate digits' :-
+    findall(C, char_type(C, digit(5)), Cs),
+    Cs = ['5'|_].
+'enumerate upper' :-
+    findall(C, char_type(C, upper(b)), Cs),
+    Cs == ['B'].
+'enumerate to_lower' :-
+    findall(C, char_type(C, to_lower(a)), Cs),
+    Cs == ['A', a].
+'enumerate types' :-
+    findall(T, char_type(a, T), Ts),
+    memberchk(alpha, Ts),
+    memberchk(lower('A'), Ts),
+    memberchk(to_upper('A'), Ts),
+    \+ memberchk(upper(_), Ts).
+
+code_type :-
+    code_type(0'a, alpha).
+'code_type arguments are codes' :-
+    code_type(0'A, to_lower(L)),
+    L == 0'a.
+'code_type enumerate' :-
+    findall(C, code_type(C, upper(0'q)), Cs),
+    Cs == [0'Q].
+'code_type accepts chars' :-
+    code_type(a, lower).
+
+'unknown type'(throws(error(domain_error(char_type, bogus), _))) :-
+    char_type(a, bogus).
+'both unbound'(throws(error(instantiation_error, _))) :-
+    char_type(_, _).
+'not a character'(throws(error(type_error(character, ab), _))) :-
+    char_type(ab, alpha).
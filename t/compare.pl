@@ -0,0 +1,48 @@
+% Tests for compare/3 and predsort/3
+%
+% compare/3 is defined in ISO §8.4.2
+
+% helpers
+by_length(O, A, B) :-
+    length(A, NA),
+    length(B, NB),
+    compare(O, NA, NB).
+
+:- use_module(library(tap)).
+
+% examples taken from ISO §8.4.2.4
+'compare less' :-
+    compare(Order, 3, 5),
+    Order == (<).
+'compare equal' :-
+    compare(Order, d, d),
+    Order == (=).
+'compare greater' :-
+    compare(Order, d(x), d),
+    Order == (>).
+'compare with bound order' :-
+    compare(<, 1, 2).
+'compare wrong order'(fail) :-
+    compare(>, 1, 2).
+'compare bad order atom'(throws(domain_error(order, foo))) :-
+    compare(foo, 1, 2).
+'compare bad order type'(throws(type_error(atom, 1))) :-
+    compare(1, 1, 2).
+'float before integer of same value' :-
+    compare(<, 1.0, 1).
+'large integers' :-
+    compare(<, 9007199254740992, 9007199254740993).
+'rational order' :-
+    compare(<, 1r3, 0.34),
+    compare(>, 1r3, 0.33).
+
+% predsort/3
+'predsort by length' :-
+    predsort(by_length, [[a,b], [a], [a,b,c]], L),
+    L == [[a], [a,b], [a,b,c]].
+'predsort removes equal elements' :-
+    predsort(by_length, [[a], [b], [c,d]], L),
+    L == [[a], [c,d]].
+'predsort empty' :-
+    predsort(by_length, [], L),
+    L == [].
@@ -0,0 +1,94 @@
+% Tests for dicts
+%
+% These predicates follow SWI-Prolog's dicts
+:- use_module(library(tap)).
+
+'dict syntax' :-
+    D = point{x: 1, y: 2},
+    is_dict(D, point).
+'dict with variable tag' :-
+    is_dict(_{a: 1}, Tag),
+    var(Tag).
+'is_dict/1 on a compound term'(fail) :-
+    is_dict(point(1, 2)).
+'unification ignores key order' :-
+    point{y: Y, x: 1} = point{x: X, y: 2},
+    X == 1,
+    Y == 2.
+'unification binds the tag' :-
+    T{a: 1} = point{a: 1},
+    T == point.
+'unification needs the same keys'(fail) :-
+    _{a: 1} = _{a: 1, b: 2}.
+'dicts follow compound terms' :-
+    f(a) @< _{},
+    _{b: 1} @< _{a: 1, b: 1}.
+'dict with integer keys' :-
+    get_dict(1, _{1: one, 2: two}, V),
+    V == one.
+
+get_dict :-
+    get_dict(x, point{x: 1, y: 2}, V),
+    V == 1.
+'get_dict missing key'(fail) :-
+    get_dict(z, point{x: 1, y: 2}, _).
+'get_dict enumerates keys' :-
+    findall(K-V, get_dict(K, _{b: 2, a: 1, c: 3}, V), Pairs),
+    Pairs == [a-1, b-2, c-3].
+'get_dict not a dict'(throws(error(type_error(dict, foo), _))) :-
+    get_dict(a, foo, _).
+'get_dict unbound dict'(throws(error(instantiation_error, _))) :-
+    get_dict(a, _, _).
+'get_dict bad key'(throws(error(type_error(dict_key, f(x)), _))) :-
+    get_dict(f(x), _{a: 1}, _).
+
+'put_dict/4 adds a key' :-
+    put_dict(z, point{x: 1}, 3, D),
+    D == point{x: 1, z: 3}.
+'put_dict/4 replaces a key' :-
+    put_dict(x, point{x: 1, y: 2}, 9, D),
+    D == point{x: 9, y: 2}.
+'put_dict/4 leaves the original alone' :-
+    D0 = _{a: 1},
+    put_dict(a, D0, 2, _),
+    get_dict(a, D0, V),
+    V == 1.
+'put_dict/3 with a dict' :-
+    put_dict(_{y: 20, z: 30}, point{x: 1, y: 2}, D),
+    D == point{x: 1, y: 20, z: 30}.
+'put_dict/3 with pairs' :-
+    put_dict([a-1, b=2, c(3)], tag{}, D),
+    D == tag{a: 1, b: 2, c: 3}.
+
+del_dict :-
+    del_dict(a, t{a: 1, b: 2}, V, D),
+    V == 1,
+    D == t{b: 2}.
+'del_dict missing key'(fail) :-
+    del_dict(c, t{a: 1, b: 2}, _, _).
+'del_dict value mismatch'(fail) :-
+    del_dict(a, t{a: 1, b: 2}, 2, _).
+
+'dict_pairs from dict' :-
+    dict_pairs(point{y: 2, x: 1}, Tag, Pairs),
+    Tag == point,
+    Pairs == [x-1, y-2].
+'dict_pairs to dict' :-
+    dict_pairs(D, point, [y-2, x-1]),
+    D == point{x: 1, y: 2}.
+'dict_pairs duplicate key'(throws(error(duplicate_key(a), _))) :-
+    dict_pairs(_, t, [a-1, a-2]).
+dict_create :-
+    dict_create(D, t, [a-1, b=2, c(3)]),
+    D == t{a: 1, b: 2, c: 3}.
+
+'copy_term on dicts' :-
+    D = _{a: X},
+    copy_term(D, C),
+    get_dict(a, C, Y),
+    var(Y),
+    Y \== X.
+'ground dict' :-
+    ground(t{a: f(b)}).
+'ground dict with variable'(fail) :-
+    ground(t{a: f(_)}).
@@ -0,0 +1,89 @@
+% Tests for Prolog flags
+%
+% See ISO §7.11 and §8.17
+% helpers
+same(X, X).
+
+:- use_module(library(tap)).
+
+bounded :-
+    current_prolog_flag(bounded, false).
+max_integer :-
+    current_prolog_flag(max_integer, Max),
+    Max =:= 9223372036854775807.
+min_integer :-
+    current_prolog_flag(min_integer, Min),
+    Min =:= -9223372036854775808.
+integer_rounding_function :-
+    current_prolog_flag(integer_rounding_function, toward_zero),
+    X is -7 // 2,
+    X == -3.
+'char_conversion default' :-
+    current_prolog_flag(char_conversion, off).
+'debug default' :-
+    current_prolog_flag(debug, off).
+'unknown default' :-
+    current_prolog_flag(unknown, error).
+'enumerate flags' :-
+    findall(F, current_prolog_flag(F, _), Flags),
+    memberchk(bounded, Flags),
+    memberchk(occurs_check, Flags),
+    memberchk(unknown, Flags).
+'set a flag' :-
+    set_prolog_flag(debug, on),
+    current_prolog_flag(debug, on).
+'flags survive backtracking' :-
+    ( set_prolog_flag(unknown, fail), fail ; true ),
+    current_prolog_flag(unknown, fail).
+'flags survive findall' :-
+    findall(x, set_prolog_flag(debug, on), _),
+    current_prolog_flag(debug, on).
+'flags survive an exception' :-
+    catch((set_prolog_flag(debug, on), throw(oops)), oops, true),
+    current_prolog_flag(debug, on).
+'occurs_check survives backtracking'(fail) :-
+    ( set_prolog_flag(occurs_check, true), fail ; true ),
+    X = f(X).
+'read only flag'(throws(error(permission_error(modify, flag, bounded), _))) :-
+    set_prolog_flag(bounded, true).
+'bad flag value'(throws(error(domain_error(flag_value, unknown+maybe), _))) :-
+    set_prolog_flag(unknown, maybe).
+'unknown flag name'(throws(error(domain_error(prolog_flag, nope), _))) :-
+    current_prolog_flag(nope, _).
+'flag name must be an atom'(throws(error(type_error(atom, 1), _))) :-
+    set_prolog_flag(1, on).
+
+'occurs_check false' :-
+    current_prolog_flag(occurs_check, false),
+    X = f(X).
+'occurs_check true'(fail) :-
+    set_prolog_flag(occurs_check, true),
+    X = f(X).
+'occurs_check true through bindings'(fail) :-
+    set_prolog_flag(occurs_check, true),
+    Y = g(X),
+    X = f(Y).
+'occurs_check true allows aliasing' :-
+    set_prolog_flag(occurs_check, true),
+    X = Y,
+    Y = X,
+    f(X, a) = f(Y, Z),
+    Z == a.
+'occurs_check in clause heads'(fail) :-
+    set_prolog_flag(occurs_check, true),
+    same(X, f(X)).
+'occurs_check error'(throws(error(occurs_check(_, f(_)), _))) :-
+    set_prolog_flag(occurs_check, error),
+    X = f(X).
+
+'number_order value' :-
+    1 @< 1.5,
+    msort([2, 1.5, 1], L),
+    L == [1, 1.5, 2].
+'number_order iso' :-
+    set_prolog_flag(number_order, iso),
+    1.5 @< 1,
+    msort([2, 1.5, 1], L),
+    L == [1.5, 1, 2],
+    compare(O, 2.0, 1),
+    O == (<).
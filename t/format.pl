@@ -0,0 +1,121 @@
+% Tests for format/2,3 directives
+%
+% Expected output follows SWI-Prolog
+fmt(Template, Args, Text) :-
+    format(atom(Text), Template, Args).
+
+portray(secret(_)) :-
+    write('<hidden>').
+
+:- use_module(library(tap)).
+
+'~a' :-
+    fmt("~a and ~a", [x, 'y z'], A),
+    A == 'x and y z'.
+'~w uses operators' :-
+    fmt("~w", [1+2*a], A),
+    A == '1+2*a'.
+'~w numbervars' :-
+    fmt("~w", [f('$VAR'(1))], A),
+    A == 'f(B)'.
+'~q' :-
+    fmt("~q", [f('A', b)], A),
+    A == 'f(\'A\',b)'.
+'~p' :-
+    fmt("~p", [g(secret(1))], A),
+    A == 'g(<hidden>)'.
+'~d' :-
+    fmt("~d ~d", [42, -7], A),
+    A == '42 -7'.
+'~Nd inserts a decimal point' :-
+    fmt("~2d ~2d ~0d", [314, 5, 7], A),
+    A == '3.14 0.05 7'.
+'~D groups digits' :-
+    fmt("~D ~D ~2D", [1234567, 999, -1234567], A),
+    A == '1,234,567 999 -12,345.67'.
+'~d with a float'(throws(error(format('~d expects an integer argument'), _))) :-
+    fmt("~d", [1.0], _).
+'~f' :-
+    fmt("~f ~4f ~0f", [1, 3.14159, 7.2], A),
+    A == '1.000000 3.1416 7'.
+'~f is exact for big integers' :-
+    fmt("~2f", [12345678901234567890], A),
+    A == '12345678901234567890.00'.
+'~f is exact for rationals' :-
+    X is 1 rdiv 3,
+    fmt("~10f", [X], A),
+    A == '0.3333333333'.
+'~e' :-
+    fmt("~e ~3e ~2e", [12345.678, 0.000123, -5], A),
+    A == '1.234568e+04 1.230e-04 -5.00e+00'.
+'~e rounding carries into the exponent' :-
+    fmt("~2e", [9.999], A),
+    A == '1.00e+01'.
+'~e is exact for rationals' :-
+    X is 2 rdiv 3,
+    fmt("~15e", [X], A),
+    A == '6.666666666666667e-01'.
+'~g' :-
+    fmt("~g ~g ~g ~g ~g", [0.0001, 1000000.0, 100000, 123.456, 0.5], A),
+    A == '0.0001 1e+06 100000 123.456 0.5'.
+'~g is exact for rationals' :-
+    X is 1 rdiv 8,
+    fmt("~20g", [X], A),
+    A == '0.125'.
+'~f with an atom'(throws(error(format('~f expects a numeric argument'), _))) :-
+    fmt("~f", [pi], _).
+'~s' :-
+    string_to_atom(S, def),
+    fmt("~s-~s-~s", ["abc", S, []], A),
+    A == 'abc-def-'.
+'~s with an atom'(throws(error(format(_), _))) :-
+    fmt("~s", [abc], _).
+'~c' :-
+    fmt("~c~3c", [65, 0'x], A),
+    A == 'Axxx'.
+'~r' :-
+    fmt("~8r ~16r ~16R ~2r", [64, 255, 255, -5], A),
+    A == '100 ff FF -101'.
+'~r without a radix'(throws(error(format(_), _))) :-
+    fmt("~r", [10], _).
+'~i' :-
+    fmt("~i~w", [a, b], A),
+    A == b.
+'~*' :-
+    fmt("~*c", [3, 0'z], A),
+    A == zzz.
+'~~ and ~n' :-
+    fmt("~~~2n", [], A),
+    char_code(NL, 10),
+    atom_concat('~', NL, A1),
+    atom_concat(A1, NL, A).
+'column with left aligned text' :-
+    fmt("~w~t~10|~w", [abc, def], A),
+    A == 'abc       def'.
+'column with right aligned text' :-
+    fmt("~t~w~10|", [abc], A),
+    A == '       abc'.
+'column with centered text' :-
+    fmt("~t~w~t~11|", [abc], A),
+    A == '    abc    '.
+'column fill character' :-
+    fmt("~`-t~8|~w", [x], A),
+    A == '--------x'.
+'column without fill point' :-
+    fmt("~w~6|~w", [ab, c], A),
+    A == 'ab    c'.
+'relative columns' :-
+    fmt("~w~t~4+~w~t~4+|", [a, b], A),
+    A == 'a   b   |'.
+'column already passed' :-
+    fmt("~w~2|~w", [abcd, e], A),
+    A == abcde.
+'single argument' :-
+    fmt("<~w>", hello, A),
+    A == '<hello>'.
+'unknown directive'(throws(error(format('unknown directive: ~y'), _))) :-
+    fmt("~y", [], _).
+'truncated directive'(throws(error(format('truncated format specification'), _))) :-
+    fmt("abc~4", [], _).
+'~* needs an integer'(throws(error(format(_), _))) :-
+    fmt("~*c", [a, 0'x], _).
@@ -0,0 +1,82 @@
+% Tests for global variables and gensym/2
+%
+% These predicates follow SWI-Prolog
+:- use_module(library(tap)).
+
+'b_setval and b_getval' :-
+    b_setval(counter, 1),
+    b_getval(counter, X),
+    X == 1.
+'b_setval is undone on backtracking' :-
+    b_setval(v, old),
+    ( b_setval(v, new), fail ; true ),
+    b_getval(v, X),
+    X == old.
+'b_setval keeps variable bindings' :-
+    b_setval(v, f(X)),
+    X = a,
+    b_getval(v, V),
+    V == f(a).
+'nb_setval survives backtracking' :-
+    nb_setval(v, old),
+    ( nb_setval(v, new), fail ; true ),
+    nb_getval(v, X),
+    X == new.
+'nb_setval copies its value' :-
+    nb_setval(v, f(X)),
+    X = a,
+    nb_getval(v, V),
+    V = f(Y),
+    var(Y).
+'b_getval sees nb_setval' :-
+    nb_setval(v, 1),
+    b_getval(v, X),
+    X == 1.
+'nb_setval then b_setval then backtrack' :-
+    nb_setval(v, 1),
+    b_setval(v, 2),
+    ( true ; fail ),
+    b_getval(v, X),
+    X == 2.
+'nb_setval counter in forall' :-
+    nb_setval(count, 0),
+    forall(member(_, [a, b, c]),
+           ( nb_getval(count, C0),
+             C is C0 + 1,
+             nb_setval(count, C)
+           )),
+    nb_getval(count, N),
+    N == 3.
+'nb_setval inside findall' :-
+    nb_setval(last, none),
+    findall(X, (member(X, [a, b]), nb_setval(last, X)), _),
+    nb_getval(last, L),
+    L == b.
+'nb_setval survives exceptions' :-
+    nb_setval(v, before),
+    catch((nb_setval(v, after), throw(oops)), oops, true),
+    nb_getval(v, X),
+    X == after.
+'b_getval missing'(throws(error(existence_error(variable, nope), _))) :-
+    b_getval(nope, _).
+'nb_getval unbound name'(throws(error(instantiation_error, _))) :-
+    nb_getval(_, _).
+'b_setval bad name'(throws(error(type_error(atom, 1), _))) :-
+    b_setval(1, x).
+
+gensym :-
+    gensym(item_, A),
+    gensym(item_, B),
+    gensym(other, C),
+    A == item_1,
+    B == item_2,
+    C == other1.
+'gensym survives backtracking' :-
+    ( gensym(g, _), fail ; true ),
+    gensym(g, X),
+    X == g2.
+reset_gensym :-
+    gensym(r, _),
+    reset_gensym(r),
+    gensym(r, X),
+    X == r1.
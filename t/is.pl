@@ -0,0 +1,258 @@
+% Tests for is/2 and arithmetic evaluation
+%
+% is/2 is defined in ISO §8.6.1 and evaluable functors in §9
+:- use_module(library(tap)).
+
+% Tests derived from examples in ISO §8.6.1.4
+'simple addition' :-
+    Result is 3 + 11.0,
+    Result =:= 14.0.
+'bound expression' :-
+    X = 1 + 2,
+    Y is X * 3,
+    Y = 9.
+'already bound'(fail) :-
+    3 is 3 + 3.
+'unbound expression'(throws(instantiation_error)) :-
+    _ is _ + 3.
+'not evaluable'(throws(type_error(evaluable, foo/0))) :-
+    _ is foo + 1.
+
+% an integer and a float give a float
+'mixed addition' :-
+    X is 1 + 0.0,
+    X == 1.0,
+    Y is 9007199254740993 + 0.0,
+    Y \== 9007199254740993.
+'mixed subtraction' :-
+    X is 3 - 1.0,
+    X == 2.0.
+'mixed multiplication' :-
+    X is 2 * 1.0,
+    X == 2.0.
+'mixed power' :-
+    X is 2.0 ** 2,
+    X == 4.0,
+    Y is 2 ** 2.0,
+    Y == 4.0.
+
+% unary functions
+'unary minus' :-
+    X is -(7),
+    X = -7.
+'unary plus' :-
+    X is +(7),
+    X = 7.
+abs :-
+    X is abs(-3),
+    X = 3,
+    Y is abs(-1.5),
+    Y =:= 1.5.
+sign :-
+    -1 is sign(-20),
+    0 is sign(0),
+    1 is sign(99999999999999999999999).
+'single element list' :-
+    X is "a",
+    X = 0'a.
+
+% integer division
+'integer division truncates' :-
+    X is 7 // 2,
+    X = 3,
+    Y is -7 // 2,
+    Y = -3.
+'div floors' :-
+    X is -7 div 2,
+    X = -4.
+mod :-
+    X is 7 mod -2,
+    X = -1,
+    Y is -7 mod 2,
+    Y = 1.
+rem :-
+    X is 7 rem -2,
+    X = 1,
+    Y is -7 rem 2,
+    Y = -1.
+'integer division by zero'(throws(evaluation_error(zero_divisor))) :-
+    _ is 1 // 0.
+'division by zero'(throws(evaluation_error(zero_divisor))) :-
+    _ is 1 / 0.
+'float division by zero'(throws(evaluation_error(zero_divisor))) :-
+    _ is 1.5 / 0.0.
+'mod by zero'(throws(evaluation_error(zero_divisor))) :-
+    _ is 3 mod 0.
+'integer division of floats'(throws(type_error(integer, _))) :-
+    _ is 7.5 // 2.
+'exact division' :-
+    X is 6 / 3,
+    X == 2.
+'rational division' :-
+    X is 1 / 3,
+    Y is X * 3,
+    Y =:= 1.
+
+% min and max
+min :-
+    X is min(2, 3),
+    X = 2.
+max :-
+    X is max(2, 3.5),
+    X =:= 3.5.
+
+% powers
+'integer power' :-
+    X is 2 ** 100,
+    X = 1267650600228229401496703205376.
+'caret power' :-
+    X is 3 ^ 3,
+    X = 27.
+'negative exponent' :-
+    X is 2 ** -1,
+    X =:= 0.5.
+'caret negative exponent'(throws(type_error(float, 2))) :-
+    _ is 2 ^ -1.
+'caret negative exponent of one' :-
+    X is 1 ^ -1,
+    X == 1,
+    Y is (-1) ^ -3,
+    Y == -1.
+'zero to negative power'(throws(evaluation_error(zero_divisor))) :-
+    _ is 0 ^ -1.
+'huge power'(throws(resource_error(memory))) :-
+    _ is 2 ** 10000000000.
+'huge power of one' :-
+    X is 1 ** 100000000000000000000,
+    X == 1,
+    Y is (-1) ^ 10000000001,
+    Y == -1.
+'float power' :-
+    X is 4 ** 0.5,
+    X =:= 2.
+'float power overflow'(throws(evaluation_error(float_overflow))) :-
+    _ is 10.0 ** 400.
+'float product overflow'(throws(evaluation_error(float_overflow))) :-
+    _ is 1.0e308 * 10.
+'float sum overflow'(throws(evaluation_error(float_overflow))) :-
+    _ is 1.0e308 + 1.0e308.
+
+% bitwise operations
+'shift left' :-
+    X is 1 << 70,
+    X = 1180591620717411303424.
+'shift right' :-
+    X is 16 >> 2,
+    X = 4,
+    Y is -16 >> 2,
+    Y = -4.
+'huge shift left'(throws(resource_error(memory))) :-
+    _ is 1 << 10000000000.
+'huge shift right' :-
+    X is 5 >> 100000000000000000000,
+    X == 0,
+    Y is -5 >> 100000000000000000000,
+    Y == -1.
+'shift by most negative count' :-
+    X is 1 << -9223372036854775808,
+    X == 0.
+'shifts are left associative' :-
+    X is 64 >> 2 >> 1,
+    X = 8,
+    current_op(400, yfx, >>).
+'shift binds looser than power' :-
+    X is 2 ** 3 >> 1,
+    X =:= 4.
+'bitwise and' :-
+    X is 12 /\ 10,
+    X = 8.
+'bitwise or' :-
+    X is 12 \/ 10,
+    X = 14.
+xor :-
+    X is 12 xor 10,
+    X = 6.
+'bitwise negation' :-
+    X is \ 5,
+    X = -6.
+msb :-
+    X is msb(1000),
+    X = 9.
+gcd :-
+    X is gcd(12, 18),
+    X = 6.
+
+% transcendental functions
+sqrt :-
+    X is sqrt(16),
+    X =:= 4.
+'sqrt of negative'(throws(evaluation_error(undefined))) :-
+    _ is sqrt(-1).
+'sin and cos' :-
+    X is sin(0),
+    X =:= 0,
+    Y is cos(0),
+    Y =:= 1.
+atan2 :-
+    X is atan2(1, 1),
+    Y is pi / 4,
+    X =:= Y.
+copysign :-
+    X is copysign(2, -1),
+    X =:= -2.0,
+    Y is copysign(-2, 0.0),
+    Y =:= 2.0.
+'copysign of negative zero' :-
+    X is copysign(1, -0.0),
+    X =:= -1.0,
+    Y is copysign(1, -(0.0)),
+    Y =:= -1.0.
+'negative zero equals zero' :-
+    -0.0 =:= 0.0.
+'negative zero is written with its sign' :-
+    X is -(0.0),
+    term_to_atom(X, A),
+    A == '-0.0'.
+'exp and log' :-
+    X is log(exp(2)),
+    X =:= 2.
+'log of zero'(throws(evaluation_error(undefined))) :-
+    _ is log(0).
+'log with base' :-
+    X is round(log(2, 8)),
+    X = 3.
+constants :-
+    X is truncate(pi * 100),
+    X = 314,
+    Y is truncate(e * 100),
+    Y = 271.
+
+% conversions
+float :-
+    X is float(7),
+    X =:= 7.
+truncate :-
+    X is truncate(-3.7),
+    X = -3.
+round :-
+    X is round(2.5),
+    X = 3,
+    Y is round(-2.5),
+    Y = -3.
+ceiling :-
+    X is ceiling(2.1),
+    X = 3.
+floor :-
+    X is floor(-2.1),
+    X = -3.
+'rational floor' :-
+    X is floor(-7/2),
+    X = -4.
+'float_integer_part and float_fractional_part' :-
+    X is float_integer_part(3.75),
+    X =:= 3,
+    Y is float_fractional_part(3.75),
+    Y =:= 0.75.
+'large integer to float' :-
+    X is float(2 ** 70),
+    X =:= 2 ** 70.
@@ -0,0 +1,20 @@
+% Tests for keysort/2
+%
+% As defined in ISO §8.4.4
+:- use_module(library(tap)).
+
+% examples taken from ISO §8.4.4.4
+typical :-
+    keysort([1-z, 1-a, 0-b], L),
+    L == [0-b, 1-z, 1-a].
+'keeps duplicates' :-
+    keysort([a-1, a-1], L),
+    L == [a-1, a-1].
+empty :-
+    keysort([], []).
+'not a pair'(throws(type_error(pair, foo))) :-
+    keysort([a-1, foo], _).
+'partial list'(throws(instantiation_error)) :-
+    keysort([a-1|_], _).
+'variable element'(throws(instantiation_error)) :-
+    keysort([a-1, _], _).
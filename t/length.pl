@@ -1,4 +1,4 @@
-% Tests for sort/2
+% Tests for length/2
 %
 % Part of the de facto standard.
 :- use_module(library(tap)).
@@ -15,3 +15,27 @@ singleton :-
 'build a list' :-
     length(Xs, 3),
     Xs = [_,_,_].
+
+'partial list' :-
+    length([a, b|T], 4),
+    T = [_, _].
+
+'too short'(fail) :-
+    length([a, b|_], 1).
+
+'enumerate lengths' :-
+    findall(N, limit(3, length(_, N)), Ns),
+    Ns == [0, 1, 2].
+
+'enumerate partial lists' :-
+    findall(L, limit(2, length([a|L], _)), Ls),
+    Ls = [[], [_]].
+
+'not a list'(throws(error(type_error(list, foo), _))) :-
+    length(foo, _).
+
+'not an integer'(throws(error(type_error(integer, a), _))) :-
+    length(_, a).
+
+'negative length'(throws(error(domain_error(not_less_than_zero, -1), _))) :-
+    length(_, -1).
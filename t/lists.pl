@@ -0,0 +1,165 @@
+% Tests for library(lists)
+%
+% These predicates follow SWI-Prolog's library(lists)
+
+% helpers
+even(X) :-
+    0 =:= X mod 2.
+
+:- use_module(library(tap)).
+
+'append/3' :-
+    append([a, b], [c], L),
+    L == [a, b, c].
+'append/3 splits' :-
+    findall(X-Y, append(X, Y, [1, 2]), L),
+    L == [[]-[1, 2], [1]-[2], [1, 2]-[]].
+'append/3 prefix'(fail) :-
+    append([b], _, [a, b]).
+'append/2' :-
+    append([[a], [], [b, c]], L),
+    L == [a, b, c].
+
+'member/2' :-
+    findall(X, member(X, [a, b, c]), L),
+    L == [a, b, c].
+'member/2 unifies' :-
+    member(f(X), [g(1), f(2)]),
+    X == 2.
+'member/2 missing'(fail) :-
+    member(d, [a, b, c]).
+'member/2 leaves no choice point' :-
+    findall(x, (member(X, [a, b]), X == b), L),
+    L == [x].
+
+'reverse/2' :-
+    reverse([a, b, c], L),
+    L == [c, b, a].
+'reverse/2 empty' :-
+    reverse([], L),
+    L == [].
+'reverse/2 partial list' :-
+    reverse(L, [a, b]),
+    L == [b, a].
+
+'nth0/3' :-
+    nth0(1, [a, b, c], X),
+    X == b.
+'nth1/3' :-
+    nth1(1, [a, b, c], X),
+    X == a.
+'nth0/3 out of range'(fail) :-
+    nth0(3, [a, b, c], _).
+'nth1/3 zero'(fail) :-
+    nth1(0, [a, b, c], _).
+'nth0/3 enumerates' :-
+    findall(I-X, nth0(I, [a, b], X), L),
+    L == [0-a, 1-b].
+'nth1/3 finds index' :-
+    findall(I, nth1(I, [a, b, a], a), L),
+    L == [1, 3].
+'nth0/3 partial list' :-
+    nth0(2, L, x),
+    L = [A, B, C|T],
+    var(A), var(B), var(T),
+    C == x.
+'nth0/3 type error'(throws(error(type_error(integer, a), _))) :-
+    nth0(a, [a, b], _).
+
+'last/2' :-
+    last([a, b, c], X),
+    X == c.
+'last/2 empty'(fail) :-
+    last([], _).
+
+'select/3' :-
+    findall(X-R, select(X, [a, b, c], R), L),
+    L == [a-[b, c], b-[a, c], c-[a, b]].
+'select/3 inserts' :-
+    findall(L, select(x, L, [a, b]), Ls),
+    Ls == [[x, a, b], [a, x, b], [a, b, x]].
+'selectchk/3' :-
+    findall(R, selectchk(a, [b, a, c, a], R), L),
+    L == [[b, c, a]].
+'selectchk/3 missing'(fail) :-
+    selectchk(d, [a, b], _).
+
+'subtract/3' :-
+    subtract([a, b, c, a, d], [a, c], L),
+    L == [b, d].
+'delete/3' :-
+    delete([a, f(1), b, f(2)], f(_), L),
+    L == [a, b].
+'exclude/3' :-
+    exclude(even, [1, 2, 3, 4, 5], L),
+    L == [1, 3, 5].
+
+'permutation/2' :-
+    findall(P, permutation([a, b, c], P), L),
+    L == [[a, b, c], [a, c, b], [b, a, c], [b, c, a], [c, a, b], [c, b, a]].
+'permutation/2 reversed' :-
+    permutation(L, [a, b]),
+    L == [a, b].
+'permutation/2 different lengths'(fail) :-
+    permutation([a, b], [a]).
+
+'flatten/2' :-
+    flatten([a, [b, [c, d], []], e], L),
+    L == [a, b, c, d, e].
+'flatten/2 variables' :-
+    flatten([X, [Y]], L),
+    L == [X, Y].
+'flatten/2 non list' :-
+    flatten(a, L),
+    L == [a].
+
+'sum_list/2' :-
+    sum_list([1, 2, 3.5], X),
+    X =:= 6.5.
+'sum_list/2 empty' :-
+    sum_list([], X),
+    X == 0.
+'max_list/2' :-
+    max_list([3, 1, 4, 1, 5], X),
+    X == 5.
+'min_list/2' :-
+    min_list([3, 1, 4, 1, 5], X),
+    X == 1.
+'max_list/2 empty'(fail) :-
+    max_list([], _).
+'sum_list/2 partial list'(throws(error(instantiation_error, _))) :-
+    sum_list([1|_], _).
+
+'numlist/3' :-
+    numlist(1, 5, L),
+    L == [1, 2, 3, 4, 5].
+'numlist/3 single' :-
+    numlist(3, 3, L),
+    L == [3].
+'numlist/3 empty'(fail) :-
+    numlist(5, 1, _).
+'numlist/3 type error'(throws(error(type_error(integer, a), _))) :-
+    numlist(a, 5, _).
+
+'list_to_set/2' :-
+    list_to_set([a, b, a, c, b], L),
+    L == [a, b, c].
+'list_to_set/2 identical only' :-
+    list_to_set([X, Y, X, 1, 1.0], L),
+    L == [X, Y, 1, 1.0].
+
+'max_member/2' :-
+    max_member(X, [b, f(a), 3, c]),
+    X == f(a).
+'max_member/2 empty'(fail) :-
+    max_member(_, []).
+'max_member/2 unifies'(fail) :-
+    max_member(b, [a, c]).
+'min_member/2' :-
+    min_member(X, [b, f(a), 3, c]),
+    X == 3.
+'min_member/2 variable is smallest' :-
+    min_member(X, [b, Y, 3]),
+    X == Y.
+'min_member/2 empty'(fail) :-
+    min_member(_, []).
@@ -0,0 +1,37 @@
+% Tests for must_be/2
+%
+% This isn't defined in ISO.  It follows SWI-Prolog's library(error).
+:- use_module(library(tap)).
+
+integer :-
+    must_be(integer, 3).
+'not an integer'(throws(error(type_error(integer, a), _))) :-
+    must_be(integer, a).
+'unbound integer'(throws(error(instantiation_error, _))) :-
+    must_be(integer, _).
+positive_integer :-
+    must_be(positive_integer, 1).
+'zero is not positive'(throws(error(type_error(positive_integer, 0), _))) :-
+    must_be(positive_integer, 0).
+nonneg :-
+    must_be(nonneg, 0).
+'negative'(throws(error(type_error(nonneg, -1), _))) :-
+    must_be(nonneg, -1).
+atom :-
+    must_be(atom, a).
+'not an atom'(throws(error(type_error(atom, f(a)), _))) :-
+    must_be(atom, f(a)).
+callable :-
+    must_be(callable, f(a)).
+boolean :-
+    must_be(boolean, false).
+'not a boolean'(throws(error(type_error(boolean, yes), _))) :-
+    must_be(boolean, yes).
+list :-
+    must_be(list, [a, b]).
+'partial list'(throws(error(instantiation_error, _))) :-
+    must_be(list, [a|_]).
+'not a list'(throws(error(type_error(list, [a|b]), _))) :-
+    must_be(list, [a|b]).
+'unknown type'(throws(error(domain_error(type, colour), _))) :-
+    must_be(colour, red).
@@ -40,3 +40,50 @@ is :-
 'repeated is' :-
     X is 9,
     X is 3*3.
+
+% Tests derived from examples in ISO §8.7.1.4
+'less than' :-
+    0 < 1.
+'less than failing'(fail) :-
+    1.0 < 1.
+'less than with expressions' :-
+    3*2 < 7-0.
+'less or equal' :-
+    1.0 =< 1,
+    0 =< 1.
+'greater than' :-
+    1 > 0.
+'greater or equal' :-
+    1 >= 1.0.
+'not equal' :-
+    0 =\= 1.
+'not equal failing'(fail) :-
+    1.0 =\= 1.
+'unbound left'(throws(instantiation_error)) :-
+    _ < 1.
+'unbound right'(throws(instantiation_error)) :-
+    1 >= _.
+'not evaluable'(throws(type_error(evaluable, foo/0))) :-
+    foo =:= 1.
+
+% comparisons must be exact, not rounded through float64
+'large integers' :-
+    9007199254740993 > 9007199254740992,
+    9007199254740993 =\= 9007199254740992.
+'large integer and float' :-
+    9007199254740993 > 9007199254740992.0.
+'rationals' :-
+    1r3 < 0.33333333333333333334,
+    1r3 > 0.33333333333333333333,
+    2r3 =\= 0.6666666666666666.
+'huge integers' :-
+    2**200 + 1 > 2**200,
+    2**200 < 2**200 + 1.
+'nan is unordered' :-
+    \+ nan < 1,
+    \+ nan > 1,
+    \+ nan =:= nan,
+    nan =\= nan.
+'infinity' :-
+    inf > 2**2000,
+    -inf < -(2**2000).
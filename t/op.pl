@@ -0,0 +1,88 @@
+% Tests for op/3 and the machine's operator table
+%
+% See ISO §8.14.3
+:- op(700, xfx, ===>).
+:- op(200, xf, [weeks, days]).
+
+rule(a ===> b).
+span(2 weeks).
+
+out(Goal, Text) :-
+    with_output_to(atom(Text), Goal).
+
+:- use_module(library(tap)).
+
+'consulted infix operator' :-
+    rule(X),
+    X =.. ['===>', a, b].
+'consulted postfix operator' :-
+    span(X),
+    X == weeks(2).
+'current_op sees consulted operator' :-
+    current_op(700, xfx, ===>).
+'current_op sees list of operators' :-
+    current_op(200, xf, days).
+'read_term_from_atom with new operator' :-
+    op(650, xfy, and),
+    read_term_from_atom('a and b and c', T, []),
+    T == and(a, and(b, c)).
+'term_to_atom with new operator' :-
+    op(650, xfy, and),
+    term_to_atom(T, 'x and y'),
+    T == and(x, y).
+'write with new operator' :-
+    op(650, xfy, and),
+    out(write(and(a, and(b, c))), A),
+    A == 'a and b and c'.
+'write postfix operator' :-
+    span(X),
+    out(write(X), A),
+    A == '2 weeks'.
+'change priority' :-
+    op(100, yfx, +),
+    current_op(P, yfx, +),
+    P == 100.
+'replace infix specifier' :-
+    op(500, xfx, -),
+    \+ current_op(_, yfx, -),
+    current_op(200, fy, -).
+'remove operator' :-
+    op(0, xfx, ===>),
+    \+ current_op(_, _, ===>),
+    out(write('===>'(a, b)), A),
+    A == '===>(a,b)'.
+'op survives backtracking' :-
+    ( op(650, xfy, and), fail ; true ),
+    current_op(650, xfy, and).
+'op survives negation' :-
+    \+ \+ op(650, xfy, and),
+    current_op(650, xfy, and).
+'op inside forall' :-
+    forall(member(O, [===>>, <<===]), op(700, xfx, O)),
+    current_op(700, xfx, ===>>),
+    current_op(700, xfx, <<===).
+'empty operator list' :-
+    op(700, xfx, []).
+
+'op priority unbound'(throws(error(instantiation_error, _))) :-
+    op(_, xfx, foo).
+'op operators unbound'(throws(error(instantiation_error, _))) :-
+    op(700, xfx, [foo, _]).
+'op priority not integer'(throws(error(type_error(integer, high), _))) :-
+    op(high, xfx, foo).
+'op priority out of range'(throws(error(domain_error(operator_priority, 1201), _))) :-
+    op(1201, xfx, foo).
+'op specifier not atom'(throws(error(type_error(atom, 1), _))) :-
+    op(700, 1, foo).
+'op unknown specifier'(throws(error(domain_error(operator_specifier, yfy), _))) :-
+    op(700, yfy, foo).
+'op operator not atom'(throws(error(type_error(atom, 1), _))) :-
+    op(700, xfx, [1]).
+'op operators not list'(throws(error(type_error(list, f(x)), _))) :-
+    op(700, xfx, f(x)).
+'op comma'(throws(error(permission_error(modify, operator, ','), _))) :-
+    op(700, xfx, ',').
+'op bar as prefix'(throws(error(permission_error(create, operator, '|'), _))) :-
+    op(700, fx, '|').
+'op infix and postfix'(throws(error(permission_error(create, operator, weeks), _))) :-
+    op(700, xfx, weeks).
@@ -0,0 +1,71 @@
+% Tests for library(ordsets)
+%
+% These predicates follow SWI-Prolog's library(ordsets)
+:- use_module(library(tap)).
+
+is_ordset :-
+    is_ordset([a, b, c]).
+'is_ordset empty' :-
+    is_ordset([]).
+'is_ordset duplicates'(fail) :-
+    is_ordset([a, a, b]).
+'is_ordset unordered'(fail) :-
+    is_ordset([b, a]).
+'is_ordset partial list'(fail) :-
+    is_ordset([a|_]).
+
+list_to_ord_set :-
+    list_to_ord_set([c, a, b, a], S),
+    S == [a, b, c].
+
+ord_union :-
+    ord_union([a, c, e], [b, c, d], S),
+    S == [a, b, c, d, e].
+'ord_union/2' :-
+    ord_union([[c], [a, b], [b, d]], S),
+    S == [a, b, c, d].
+ord_intersection :-
+    ord_intersection([a, b, c, e], [b, c, d, e], S),
+    S == [b, c, e].
+ord_subtract :-
+    ord_subtract([a, b, c, d], [b, d, f], S),
+    S == [a, c].
+ord_symdiff :-
+    ord_symdiff([a, b, c], [b, c, d], S),
+    S == [a, d].
+
+ord_memberchk :-
+    ord_memberchk(b, [a, b, c]).
+'ord_memberchk missing'(fail) :-
+    ord_memberchk(bb, [a, b, c]).
+'ord_memberchk identical only'(fail) :-
+    ord_memberchk(_, [a, b]).
+
+ord_subset :-
+    ord_subset([b, d], [a, b, c, d]).
+'ord_subset empty' :-
+    ord_subset([], [a]).
+'ord_subset missing'(fail) :-
+    ord_subset([b, e], [a, b, c, d]).
+
+ord_add_element :-
+    ord_add_element([a, c], b, S),
+    S == [a, b, c].
+'ord_add_element present' :-
+    ord_add_element([a, c], c, S),
+    S == [a, c].
+ord_del_element :-
+    ord_del_element([a, b, c], b, S),
+    S == [a, c].
+
+ord_disjoint :-
+    ord_disjoint([a, c], [b, d]).
+'ord_disjoint overlapping'(fail) :-
+    ord_disjoint([a, c], [c, d]).
+ord_intersect :-
+    ord_intersect([a, c], [c, d]).
+ord_empty :-
+    ord_empty([]).
+
+'ord_union type error'(throws(error(type_error(list, foo), _))) :-
+    ord_union(foo, [a], _).
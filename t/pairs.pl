@@ -0,0 +1,29 @@
+% Tests for library(pairs)
+%
+% These predicates follow SWI-Prolog's library(pairs)
+
+% helpers
+atom_key(A, N) :-
+    atom_length(A, N).
+
+:- use_module(library(tap)).
+
+pairs_keys_values :-
+    pairs_keys_values(P, [a, b], [1, 2]),
+    P == [a-1, b-2].
+'pairs_keys_values split' :-
+    pairs_keys_values([a-1, b-2], K, V),
+    K == [a, b],
+    V == [1, 2].
+pairs_keys :-
+    pairs_keys([a-1, b-2], K),
+    K == [a, b].
+pairs_values :-
+    pairs_values([a-1, b-2], V),
+    V == [1, 2].
+transpose_pairs :-
+    transpose_pairs([a-2, b-1, c-2], T),
+    T == [1-b, 2-a, 2-c].
+map_list_to_pairs :-
+    map_list_to_pairs(atom_key, [abc, a, ab], P),
+    P == [3-abc, 1-a, 2-ab].
@@ -0,0 +1,177 @@
+% Tests for rational numbers
+%
+% Golog represents numbers with a fractional part as exact rationals
+% whenever it can.
+:- use_module(library(tap)).
+
+% syntax
+'rational syntax' :-
+    X = 1r3,
+    X =:= 1 rdiv 3.
+'negative rational syntax' :-
+    X = -1r3,
+    X =:= -1 rdiv 3.
+'integral rational syntax is an integer' :-
+    X = 4r2,
+    X == 2.
+
+% type checks
+'rational/1 with rational' :-
+    rational(1r3).
+'rational/1 with integer' :-
+    rational(7).
+'rational/1 with atom'(fail) :-
+    rational(a).
+'rational/1 with variable'(fail) :-
+    rational(_).
+'rational/1 with decimal literal'(fail) :-
+    rational(0.5).
+'rational/1 with float result'(fail) :-
+    X is 1.0/3,
+    rational(X).
+'rational/3 with float'(fail) :-
+    rational(0.5, _, _).
+'rational does not unify with float'(fail) :-
+    1r2 = 0.5.
+'rational/3 with rational' :-
+    rational(-6r4, N, D),
+    N == -3,
+    D == 2.
+'rational/3 with integer' :-
+    rational(5, N, D),
+    N == 5,
+    D == 1.
+'rational/3 with atom'(fail) :-
+    rational(a, _, _).
+
+% division
+'with prefer_rationals, integer division is exact' :-
+    set_prolog_flag(prefer_rationals, true),
+    X is 1/3,
+    rational(X, 1, 3).
+'rational arithmetic is exact' :-
+    X is 1r3 + 1r6,
+    X =:= 1r2,
+    Y is 3 * 1r3,
+    Y =:= 1.
+'rdiv/2' :-
+    X is 1 rdiv 3,
+    X == 1r3.
+'rdiv/2 of integral result' :-
+    X is 6 rdiv 3,
+    X == 2.
+'rdiv/2 by zero'(throws(evaluation_error(zero_divisor))) :-
+    _ is 1 rdiv 0.
+'numerator and denominator' :-
+    N is numerator(6r4),
+    N == 3,
+    D is denominator(6r4),
+    D == 2.
+'numerator of integer' :-
+    N is numerator(7),
+    N == 7,
+    D is denominator(7),
+    D == 1.
+'with prefer_rationals, negative power is exact' :-
+    set_prolog_flag(prefer_rationals, true),
+    X is 2 ** -2,
+    X == 1r4.
+
+% conversion
+'rational/1 function with integer' :-
+    X is rational(3),
+    X == 3.
+'rational/1 function is exact' :-
+    X is rational(float(1r10)),
+    rational(X, _, 36028797018963968).
+'rationalize/1 function is simple' :-
+    X is rationalize(float(1r10)),
+    X == 1r10.
+'rational/1 and rationalize/1 of decimal literal' :-
+    X is rational(0.1),
+    X == 1r10,
+    Y is rationalize(0.1),
+    Y == 1r10.
+'rationalize/1 of third' :-
+    X is rationalize(1 / float(3)),
+    X == 1r3.
+'rationalize/1 of nan'(throws(evaluation_error(undefined))) :-
+    _ is rationalize(nan).
+
+% prefer_rationals flag
+'prefer_rationals defaults to false' :-
+    current_prolog_flag(prefer_rationals, false).
+'without prefer_rationals, division gives a float' :-
+    set_prolog_flag(prefer_rationals, false),
+    X is 1/4,
+    \+ rational(X),
+    X =:= 0.25.
+'without prefer_rationals, exact division stays an integer' :-
+    set_prolog_flag(prefer_rationals, false),
+    X is 4/2,
+    X == 2.
+'without prefer_rationals, rdiv is still exact' :-
+    set_prolog_flag(prefer_rationals, false),
+    X is 1 rdiv 4,
+    rational(X).
+'without prefer_rationals, negative powers give a float' :-
+    set_prolog_flag(prefer_rationals, false),
+    X is 2 ** -1,
+    \+ rational(X).
+'bad prefer_rationals value'(throws(domain_error(flag_value, prefer_rationals+maybe))) :-
+    set_prolog_flag(prefer_rationals, maybe).
+'unknown flag'(throws(domain_error(prolog_flag, no_such_flag))) :-
+    set_prolog_flag(no_such_flag, true).
+'unbound flag value'(throws(instantiation_error)) :-
+    set_prolog_flag(prefer_rationals, _).
+'enumerate flags' :-
+    findall(F, current_prolog_flag(F, _), Flags),
+    memberchk(prefer_rationals, Flags).
+
+% rounding to decimal places
+'round half_up' :-
+    round_rational(2.675, 2, half_up, X),
+    X =:= 2.68.
+'round half_even' :-
+    round_rational(2.665, 2, half_even, X),
+    X =:= 2.66,
+    round_rational(2.675, 2, half_even, Y),
+    Y =:= 2.68.
+'round half_down' :-
+    round_rational(2.675, 2, half_down, X),
+    X =:= 2.67.
+'round negative half_up' :-
+    round_rational(-2.675, 2, half_up, X),
+    X =:= -2.68.
+'round up' :-
+    round_rational(1r3, 2, up, X),
+    X =:= 0.34.
+'round down' :-
+    round_rational(2r3, 2, down, X),
+    X =:= 0.66.
+'round ceiling and floor' :-
+    round_rational(-1r3, 1, ceiling, X),
+    X =:= -0.3,
+    round_rational(-1r3, 1, floor, Y),
+    Y =:= -0.4.
+'round gives a rational' :-
+    round_rational(2.675, 2, half_up, X),
+    rational(X).
+'round to integer' :-
+    round_rational(5r2, 0, half_even, X),
+    X == 2.
+'round bad mode'(throws(domain_error(rounding_mode, sideways))) :-
+    round_rational(1r3, 2, sideways, _).
+'round negative places'(throws(domain_error(not_less_than_zero, -1))) :-
+    round_rational(1r3, -1, half_up, _).
+'round non number'(throws(type_error(number, a))) :-
+    round_rational(a, 2, half_up, _).
+'format for currency' :-
+    format_rational(1r2, 2, half_up, A),
+    A == '0.50'.
+'format rounds' :-
+    format_rational(10r3, 2, half_even, A),
+    A == '3.33'.
+'format negative' :-
+    format_rational(-1r8, 2, half_even, A),
+    A == '-0.12'.
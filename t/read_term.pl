@@ -0,0 +1,82 @@
+% Tests for read_term/2,3, read/1,2, term_to_atom/2, term_string/2 and
+% read_term_from_atom/3
+%
+% These predicates follow ISO and SWI-Prolog
+:- use_module(library(tap)).
+
+'read clauses from a stream' :-
+    open('t/cut.pl', read, S),
+    read(S, T1),
+    read_term(S, T2, [variable_names(Vs)]),
+    read(S, T3),
+    close(S),
+    T1 == (:- use_module(library(tap))),
+    T2 = (multiple_cuts :- _),
+    Vs = ['X'=X, 'Xs'=Xs],
+    var(X),
+    var(Xs),
+    T3 == end_of_file.
+'read at end of stream with eof_code' :-
+    open('t/cut.pl', read, S, [eof_action(eof_code)]),
+    read(S, _),
+    read(S, _),
+    read(S, T1),
+    read(S, T2),
+    close(S),
+    T1 == end_of_file,
+    T2 == end_of_file.
+'read past end of stream'(throws(error(permission_error(input, past_end_of_stream, _), _))) :-
+    open('t/cut.pl', read, S, [eof_action(error)]),
+    read(S, _),
+    read(S, _),
+    read(S, end_of_file),
+    read(S, _).
+'read from output stream'(throws(error(permission_error(input, stream, user_output), _))) :-
+    read(user_output, _).
+'read_term bad option'(throws(error(domain_error(read_option, foo), _))) :-
+    read_term(user_input, _, [foo]).
+
+'read_term_from_atom' :-
+    read_term_from_atom('foo(X, Y, X)', T, []),
+    T = foo(A, B, C),
+    A == C,
+    A \== B.
+'read_term_from_atom with full stop' :-
+    read_term_from_atom('a :- b.', T, []),
+    T == (a :- b).
+'read_term_from_atom variables' :-
+    read_term_from_atom('f(X, _, Y, X, _Z)', T,
+                        [variables(Vs), variable_names(Ns), singletons(Ss)]),
+    T = f(X, Anon, Y, X, Z),
+    Vs == [X, Anon, Y, Z],
+    Ns == ['X'=X, 'Y'=Y, '_Z'=Z],
+    Ss == ['Y'=Y].
+'read_term_from_atom syntax error'(throws(error(syntax_error(_), _))) :-
+    read_term_from_atom('foo(', _, []).
+'read_term_from_atom quiet syntax error'(fail) :-
+    read_term_from_atom('foo(', _, [syntax_errors(quiet)]).
+'read_term_from_atom two terms'(throws(error(syntax_error(_), _))) :-
+    read_term_from_atom('a. b.', _, []).
+'read_term_from_atom uses operators' :-
+    read_term_from_atom('X is 1 + 2 * 3', T, []),
+    T = (_ is 1 + (2 * 3)).
+
+'term_string write' :-
+    term_string(1 + 'A', S),
+    string(S),
+    string_to_atom(S, '1+\'A\'').
+'term_string read' :-
+    string_to_atom(S, 'f(X, Y)'),
+    term_string(T, S),
+    T = f(A, B),
+    A \== B.
+'term_string read codes' :-
+    term_string(T, "f(X)"),
+    T = f(A),
+    var(A).
+'term_to_atom uses operators' :-
+    term_to_atom((a :- b), A),
+    A == 'a:-b'.
+'term_to_atom with full stop' :-
+    term_to_atom(T, 'foo. '),
+    T == foo.
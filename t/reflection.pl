@@ -0,0 +1,108 @@
+% Tests for inspecting the loaded program
+%
+% These predicates follow ISO §8.8 and §8.14.4 and SWI-Prolog
+:- dynamic counter/1.
+
+color(red).
+color(green).
+color(blue).
+
+double(X, Y) :-
+    Y is 2 * X.
+
+:- use_module(library(tap)).
+
+'current_predicate user' :-
+    current_predicate(color/1).
+'current_predicate enumerates arity' :-
+    findall(A, current_predicate(color/A), As),
+    As == [1].
+'current_predicate wrong arity'(fail) :-
+    current_predicate(color/2).
+'current_predicate undefined'(fail) :-
+    current_predicate(no_such_predicate/_).
+'current_predicate dynamic' :-
+    current_predicate(counter/1).
+'current_predicate foreign' :-
+    current_predicate(atom_length/2).
+'current_predicate enumerates' :-
+    findall(P, current_predicate(P), Ps),
+    memberchk(double/2, Ps),
+    memberchk(atom_length/2, Ps),
+    \+ memberchk('$cut_to'/1, Ps).
+'current_predicate type error'(throws(error(type_error(predicate_indicator, foo), _))) :-
+    current_predicate(foo).
+
+'current_predicate/2 head' :-
+    current_predicate(Name, double(_, _)),
+    Name == double.
+'current_predicate/2 name' :-
+    current_predicate(color, Head),
+    Head = color(_).
+'current_predicate/2 undefined'(fail) :-
+    current_predicate(_, no_such_predicate(_)).
+
+'predicate_property number_of_clauses' :-
+    predicate_property(color(_), number_of_clauses(N)),
+    N == 3.
+'predicate_property defined' :-
+    predicate_property(double(_, _), defined).
+'predicate_property dynamic' :-
+    predicate_property(counter(_), dynamic),
+    predicate_property(counter(_), number_of_clauses(0)).
+'predicate_property static'(fail) :-
+    predicate_property(color(_), dynamic).
+'predicate_property built_in' :-
+    predicate_property(atom_length(_, _), built_in),
+    predicate_property(atom_length(_, _), foreign).
+'predicate_property library isn\'t built_in'(fail) :-
+    predicate_property(length(_, _), built_in).
+'predicate_property file' :-
+    predicate_property(color(_), file(File)),
+    atom_concat(_, 'reflection.pl', File).
+'predicate_property line_count' :-
+    predicate_property(double(_, _), line_count(Line)),
+    Line == 10.
+'predicate_property undefined'(fail) :-
+    predicate_property(no_such_predicate, _).
+'predicate_property enumerates heads' :-
+    findall(H, predicate_property(H, dynamic), Hs),
+    Hs = [counter(_)].
+
+'clause fact' :-
+    findall(C-B, clause(color(C), B), Pairs),
+    Pairs == [red-true, green-true, blue-true].
+'clause rule' :-
+    clause(double(A, B), Body),
+    Body == (B is 2 * A).
+'clause dynamic'(fail) :-
+    clause(counter(_), _).
+'clause undefined'(fail) :-
+    clause(no_such_predicate, _).
+'clause foreign'(throws(error(permission_error(access, private_procedure, atom_length/2), _))) :-
+    clause(atom_length(_, _), _).
+'clause unbound head'(throws(error(instantiation_error, _))) :-
+    clause(_, _).
+'clause bad body'(throws(error(type_error(callable, 7), _))) :-
+    clause(color(_), 7).
+
+'current_op infix' :-
+    current_op(P, T, is),
+    P == 700,
+    T == xfx.
+'current_op minus' :-
+    findall(P-T, current_op(P, T, -), Ops),
+    msort(Ops, Sorted),
+    Sorted == [200-fy, 500-yfx].
+'current_op enumerates' :-
+    findall(Op, current_op(1200, xfx, Op), Ops),
+    msort(Ops, Sorted),
+    Sorted == [(-->), (:-)].
+'current_op not an operator'(fail) :-
+    current_op(_, _, foo).
+'current_op bad priority'(throws(error(domain_error(operator_priority, 1201), _))) :-
+    current_op(1201, _, _).
+'current_op bad specifier'(throws(error(domain_error(operator_specifier, xyz), _))) :-
+    current_op(_, xyz, _).
+'current_op bad operator'(throws(error(type_error(atom, 7), _))) :-
+    current_op(_, _, 7).
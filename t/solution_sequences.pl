@@ -0,0 +1,166 @@
+% Tests for limit/2, offset/2, order_by/2, distinct/1,2, call_nth/2
+% and findnsols/4
+%
+% These predicates follow SWI-Prolog's library(solution_sequences)
+
+% helpers
+elem(X, [X|_]).
+elem(X, [_|T]) :-
+    elem(X, T).
+
+% count/1 solutions then throw, so tests notice if a goal runs too far
+upto(X, Max) :-
+    elem(X, [1, 2, 3, 4, 5]),
+    (   X > Max
+    ->  throw(too_far)
+    ;   true
+    ).
+
+age(peter, 7).
+age(ann, 11).
+age(pat, 8).
+age(tom, 5).
+age(mike, 11).
+
+:- use_module(library(tap)).
+
+limit :-
+    findall(X, limit(2, elem(X, [a, b, c])), L),
+    L == [a, b].
+'limit cuts the goal' :-
+    findall(X, limit(3, upto(X, 3)), L),
+    L == [1, 2, 3].
+'limit more than available' :-
+    findall(X, limit(5, elem(X, [a, b])), L),
+    L == [a, b].
+'limit zero'(fail) :-
+    limit(0, true).
+'limit unbound'(throws(error(instantiation_error, context(limit/2, _)))) :-
+    limit(_, true).
+'limit not a count'(throws(error(type_error(integer, a), context(limit/2, _)))) :-
+    limit(a, true).
+'offset not a count'(throws(error(type_error(integer, a), context(offset/2, _)))) :-
+    offset(a, true).
+'call_nth not a count'(throws(error(type_error(integer, a), context(call_nth/2, _)))) :-
+    call_nth(true, a).
+'limit infinite' :-
+    findall(X, limit(infinite, elem(X, [a, b])), L),
+    L == [a, b].
+'limit is deterministic after the last' :-
+    limit(1, elem(X, [a, b])),
+    X == a,
+    !.
+
+offset :-
+    findall(X, offset(2, elem(X, [a, b, c, d])), L),
+    L == [c, d].
+'offset zero' :-
+    findall(X, offset(0, elem(X, [a, b])), L),
+    L == [a, b].
+'offset past the end' :-
+    findall(X, offset(5, elem(X, [a, b])), L),
+    L == [].
+'offset negative'(throws(error(domain_error(not_less_than_zero, -1), context(offset/2, _)))) :-
+    offset(-1, true).
+'paging' :-
+    findall(X, limit(2, offset(1, elem(X, [a, b, c, d]))), L),
+    L == [b, c].
+
+order_by :-
+    findall(N-A, order_by([asc(A)], age(N, A)), L),
+    L == [tom-5, peter-7, pat-8, ann-11, mike-11].
+'order_by desc' :-
+    findall(N, order_by([desc(A)], age(N, A)), L),
+    L == [ann, mike, pat, peter, tom].
+'order_by two keys' :-
+    findall(N, order_by([desc(A), asc(N)], age(N, A)), L),
+    L == [ann, mike, pat, peter, tom].
+'order_by second key descending' :-
+    findall(N, order_by([desc(A), desc(N)], age(N, A)), L),
+    L == [mike, ann, pat, peter, tom].
+'order_by empty'(throws(error(domain_error(non_empty_list, []), context(order_by/2, _)))) :-
+    order_by([], true).
+'order_by bad spec'(throws(error(domain_error(order_specifier, up(_)), context(order_by/2, _)))) :-
+    order_by([up(_)], true).
+'order_by variable'(throws(error(instantiation_error, context(order_by/2, _)))) :-
+    order_by(_, true).
+
+distinct :-
+    findall(X, distinct(elem(X, [a, b, a, c, b])), L),
+    L == [a, b, c].
+'distinct witness' :-
+    findall(N, distinct(A, age(N, A)), L),
+    L == [peter, ann, pat, tom].
+'distinct variants' :-
+    findall(X, distinct(X, elem(X, [f(_), f(_), g(_)])), L),
+    L = [f(_), g(_)].
+'distinct shared variables' :-
+    findall(X, distinct(X, elem(X, [f(A, A), f(_, _), f(B, B)])), L),
+    L = [f(C, C), f(D, E)],
+    D \== E.
+'distinct numbers' :-
+    findall(X, distinct(elem(X, [1, 1.0, 1, 1.0])), L),
+    L == [1, 1.0].
+'distinct many solutions' :-
+    numlist(1, 3000, Ns),
+    findall(X, distinct(X, (elem(N, Ns), X is N mod 1000)), L),
+    length(L, 1000).
+'distinct calls are independent' :-
+    findall(X-Y, (distinct(elem(X, [a, a])), distinct(elem(Y, [a, a]))), L),
+    L == [a-a].
+'distinct on backtracking' :-
+    findall(X, (distinct(X, elem(X, [a, b, a])), X \== a), L),
+    L == [b].
+
+call_nth :-
+    findall(X-N, call_nth(elem(X, [a, b, c]), N), L),
+    L == [a-1, b-2, c-3].
+'call_nth bound' :-
+    call_nth(elem(X, [a, b, c]), 2),
+    X == b.
+'call_nth cuts the goal' :-
+    findall(X, call_nth(upto(X, 2), 2), L),
+    L == [2].
+'call_nth past the end'(fail) :-
+    call_nth(elem(_, [a, b]), 3).
+'call_nth zero'(throws(error(domain_error(not_less_than_one, 0), context(call_nth/2, _)))) :-
+    call_nth(true, 0).
+
+findnsols :-
+    findall(L, findnsols(2, X, elem(X, [a, b, c, d, e]), L), Ls),
+    Ls == [[a, b], [c, d], [e]].
+'findnsols exact multiple' :-
+    findall(L, findnsols(2, X, elem(X, [a, b]), L), Ls),
+    Ls == [[a, b], []].
+'findnsols first chunk' :-
+    findnsols(3, X, upto(X, 3), L),
+    !,
+    L == [1, 2, 3].
+'findnsols no solutions' :-
+    findnsols(2, _, fail, L),
+    L == [].
+'findnsols leaves template unbound' :-
+    findall(X-L, findnsols(2, X, elem(X, [a, b, c, d]), L), Ls),
+    Ls = [X1-[a, b], X2-[c, d], X3-[]],
+    var(X1), var(X2), var(X3).
+'findnsols copies solutions' :-
+    findnsols(1, f(X, Y), elem(X, [Y, Y]), L),
+    !,
+    L = [f(A, B)],
+    A == B,
+    A \== Y.
+'findnsols keeps global variables' :-
+    findall(L, findnsols(1, X, (elem(X, [a, b]), nb_setval(findnsols, X)), L), _),
+    nb_getval(findnsols, V),
+    V == b.
+'findnsols zero'(throws(error(domain_error(not_less_than_one, 0), context(findnsols/4, _)))) :-
+    findnsols(0, _, true, _).
+'findnsols unbound count'(throws(error(instantiation_error, _))) :-
+    findnsols(_, _, true, _).
+
+variant :-
+    f(A, B, A) =@= f(C, D, C),
+    A \== C,
+    B \== D.
+'not variant' :-
+    f(A, A) \=@= f(_, _).
@@ -17,3 +17,37 @@ typical :-
 
 'complex terms' :-
     sort([sue, hello(world), 9, 42.95], [9, 42.95, sue, hello(world)]).
+
+'duplicates are identical, not unifiable' :-
+    sort([f(_), f(_)], L),
+    length(L, 2).
+'large integers' :-
+    sort([9007199254740993, 9007199254740992], L),
+    L == [9007199254740992, 9007199254740993].
+'rationals' :-
+    sort([1r2, 1r3, 2r3], L),
+    L == [1r3, 1r2, 2r3].
+'partial list'(throws(instantiation_error)) :-
+    sort([a|_], _).
+'not a list'(throws(type_error(list, foo))) :-
+    sort(foo, _).
+'sorted not a list'(throws(type_error(list, [a|b]))) :-
+    sort([a], [a|b]).
+
+% sort/4
+'sort/4 on whole term' :-
+    sort(0, @>=, [1,3,2,3], L),
+    L == [3,3,2,1].
+'sort/4 descending without duplicates' :-
+    sort(0, @>, [1,3,2,3], L),
+    L == [3,2,1].
+'sort/4 on key is stable' :-
+    sort(1, @=<, [f(2,a), f(1,b), f(2,c), f(1,d)], L),
+    L == [f(1,b), f(1,d), f(2,a), f(2,c)].
+'sort/4 on key removes duplicate keys' :-
+    sort(1, @<, [f(2,a), f(1,b), f(2,c)], L),
+    L == [f(1,b), f(2,a)].
+'sort/4 bad order'(throws(domain_error(order, <))) :-
+    sort(0, <, [], _).
+'sort/4 bad key'(throws(type_error(compound, a))) :-
+    sort(1, @<, [f(1), a], _).
@@ -0,0 +1,138 @@
+% Tests for streams
+%
+% These predicates follow ISO §8.11, §8.12 and §8.13.  Tests which read
+% to the end of a file use a short one.
+read_chars(S, Chars) :-
+    get_char(S, C),
+    ( C == end_of_file ->
+        Chars = []
+    ; Chars = [C|Rest],
+      read_chars(S, Rest)
+    ).
+
+:- use_module(library(tap)).
+
+'standard aliases' :-
+    stream_property(In, alias(user_input)),
+    stream_property(In, input),
+    stream_property(Out, alias(user_output)),
+    stream_property(Out, output),
+    stream_property(Err, alias(user_error)),
+    stream_property(Err, mode(append)).
+'current streams' :-
+    current_input(In),
+    stream_property(In, alias(user_input)),
+    current_output(Out),
+    stream_property(Out, alias(user_output)).
+'current_input domain error'(throws(error(domain_error(stream, foo), _))) :-
+    current_input(foo).
+
+'open and read chars' :-
+    open('t/stream.pl', read, S),
+    get_char(S, C1),
+    peek_char(S, C2),
+    get_char(S, C3),
+    close(S),
+    C1 == '%',
+    C2 == ' ',
+    C3 == ' '.
+'open with alias' :-
+    open('t/stream.pl', read, S, [alias(tests)]),
+    get_char(tests, C),
+    stream_property(S, alias(A)),
+    close(tests),
+    C == '%',
+    A == tests.
+'stream properties' :-
+    open('t/stream.pl', read, S),
+    stream_property(S, file_name(F)),
+    stream_property(S, mode(M)),
+    stream_property(S, type(T)),
+    stream_property(S, eof_action(E)),
+    stream_property(S, end_of_stream(End)),
+    close(S),
+    atom_concat(_, 'stream.pl', F),
+    M == read,
+    T == text,
+    E == error,
+    End == not.
+'set_input' :-
+    open('t/stream.pl', read, S),
+    set_input(S),
+    get_char(C),
+    current_input(In),
+    close(S),
+    current_input(After),
+    C == '%',
+    In == S,
+    stream_property(After, alias(user_input)).
+'binary stream' :-
+    open('t/stream.pl', read, S, [type(binary)]),
+    get_byte(S, B),
+    stream_property(S, type(T)),
+    close(S),
+    B == 37,
+    T == binary.
+'read to end' :-
+    open('t/cut.pl', read, S),
+    read_chars(S, Chars),
+    close(S),
+    length(Chars, N),
+    N > 10.
+'end_of_file error'(throws(error(permission_error(input, past_end_of_stream, _), _))) :-
+    open('t/cut.pl', read, S),
+    read_chars(S, _),
+    get_char(S, _).
+'eof_action eof_code' :-
+    open('t/cut.pl', read, S, [eof_action(eof_code)]),
+    read_chars(S, _),
+    get_char(S, C1),
+    get_char(S, C2),
+    close(S),
+    C1 == end_of_file,
+    C2 == end_of_file.
+'at_end_of_stream' :-
+    open('t/cut.pl', read, S),
+    \+ at_end_of_stream(S),
+    read_chars(S, _),
+    at_end_of_stream(S),
+    close(S).
+
+'open missing file'(throws(error(existence_error(source_sink, 'no/such/file'), _))) :-
+    open('no/such/file', read, _).
+'open bad mode'(throws(error(domain_error(io_mode, sideways), _))) :-
+    open('t/stream.pl', sideways, _).
+'open bound stream'(throws(error(uninstantiation_error(s), _))) :-
+    open('t/stream.pl', read, s).
+'open bad option'(throws(error(domain_error(stream_option, colour(red)), _))) :-
+    open('t/stream.pl', read, _, [colour(red)]).
+'open duplicate alias'(throws(error(permission_error(open, source_sink, alias(user_input)), _))) :-
+    open('t/stream.pl', read, _, [alias(user_input)]).
+'unknown alias'(throws(error(existence_error(stream, nope), _))) :-
+    get_char(nope, _).
+'closed stream'(throws(error(existence_error(stream, _), _))) :-
+    open('t/stream.pl', read, S),
+    close(S),
+    get_char(S, _).
+'not a stream'(throws(error(domain_error(stream_or_alias, 7), _))) :-
+    get_char(7, _).
+'read from output'(throws(error(permission_error(input, stream, user_output), _))) :-
+    get_char(user_output, _).
+'write to input'(throws(error(permission_error(output, stream, user_input), _))) :-
+    put_char(user_input, a).
+'get_byte from text'(throws(error(permission_error(input, text_stream, user_input), _))) :-
+    get_byte(user_input, _).
+'get_char from binary'(throws(error(permission_error(input, binary_stream, S), _))) :-
+    open('t/stream.pl', read, S, [type(binary)]),
+    get_char(S, _).
+'get_char bad char'(throws(error(type_error(in_character, ab), _))) :-
+    get_char(user_input, ab).
+'put_char bad char'(throws(error(type_error(character, 7), _))) :-
+    put_char(user_output, 7).
+'put_byte unbound'(throws(error(instantiation_error, _))) :-
+    put_byte(_).
+'close standard stream' :-
+    close(user_output),
+    stream_property(_, alias(user_output)).
+'bad stream property'(throws(error(domain_error(stream_property, colour(_)), _))) :-
+    stream_property(_, colour(_)).
@@ -0,0 +1,135 @@
+% Tests for packed strings and the double_quotes flag
+%
+% String predicates follow the semantics of SWI-Prolog
+:- set_prolog_flag(double_quotes, string).
+:- use_module(library(tap)).
+
+'double quotes read as string' :-
+    X = "hello",
+    string(X).
+'string is not a list'(fail) :-
+    "abc" = [_|_].
+'string is atomic' :-
+    functor("abc", Name, Arity),
+    Name == "abc",
+    Arity == 0.
+'atom is not a string'(fail) :-
+    string(abc).
+'string unification' :-
+    "abc" == "abc".
+'string vs atom'(fail) :-
+    "abc" = abc.
+'standard order' :-
+    msort([f(x), "b", b, 1, "a"], L),
+    L == [1, b, "a", "b", f(x)].
+'one character string evaluates' :-
+    X is "a" + 0,
+    X == 97.
+
+'double_quotes flag' :-
+    current_prolog_flag(double_quotes, string).
+'double_quotes codes' :-
+    set_prolog_flag(double_quotes, codes),
+    term_to_atom(T, '"ab"'),
+    T == [97, 98].
+'double_quotes chars' :-
+    set_prolog_flag(double_quotes, chars),
+    term_to_atom(T, '"ab"'),
+    T == [a, b].
+'double_quotes atom' :-
+    set_prolog_flag(double_quotes, atom),
+    term_to_atom(T, '"ab"'),
+    T == ab.
+'double_quotes bad value'(throws(error(domain_error(flag_value, _), _))) :-
+    set_prolog_flag(double_quotes, bytes).
+
+string_concat :-
+    string_concat("abc", def, S),
+    S == "abcdef".
+'string_concat enumerate' :-
+    findall(A-B, string_concat(A, B, "ab"), L),
+    L == [""-"ab", "a"-"b", "ab"-""].
+'string_concat with atoms' :-
+    string_concat(ab, X, abc),
+    X == "c".
+'string_concat all bound' :-
+    string_concat(a, "b", ab).
+'string_concat all bound mismatch'(fail) :-
+    string_concat(a, b, ac).
+'string_concat variables'(throws(error(instantiation_error, _))) :-
+    string_concat(_, _, _).
+
+sub_string :-
+    sub_string("hello world", 6, 5, _, S),
+    S == "world".
+'sub_string search' :-
+    findall(B, sub_string("banana", B, _, _, "an"), L),
+    L == [1, 3].
+'sub_string with atoms' :-
+    sub_string(abc, B, L, A, b),
+    B-L-A == 1-1-1.
+'sub_string with codes' :-
+    sub_string("abc", B, _, _, "b"),
+    B == 1.
+
+string_code :-
+    string_code(1, "abc", C),
+    C == 0'a.
+'string_code out of range'(fail) :-
+    string_code(4, "abc", _).
+'string_code zero'(fail) :-
+    string_code(0, "abc", _).
+
+split_string :-
+    split_string("a.b.c.d", ".", "", L),
+    L == ["a", "b", "c", "d"].
+'split_string with padding' :-
+    split_string("/home//jan///nice/path", "/", "", L),
+    L == ["", "home", "", "jan", "", "", "nice", "path"].
+'split_string fields' :-
+    split_string("SWI-Prolog, 7.0", ",", " ", L),
+    L == ["SWI-Prolog", "7.0"].
+'split_string strip only' :-
+    split_string("  a word ", "", " ", L),
+    L == ["a word"].
+
+string_lower :-
+    string_lower("Hello", S),
+    S == "hello".
+string_upper :-
+    string_upper(hello, S),
+    S == "HELLO".
+
+number_string :-
+    number_string(N, " 42 "),
+    N == 42.
+'number_string float' :-
+    number_string(1.5, S),
+    S == "1.5".
+'number_string syntax'(throws(error(syntax_error(_), _))) :-
+    number_string(_, "forty").
+
+string_chars :-
+    string_chars(S, [h, i]),
+    S == "hi".
+'string_chars reverse' :-
+    string_chars("hi", L),
+    L == [h, i].
+string_codes :-
+    string_codes(S, [0'h, 0'i]),
+    S == "hi".
+'string_codes reverse' :-
+    string_codes(hi, L),
+    L == [0'h, 0'i].
+string_to_atom :-
+    string_to_atom("abc", A),
+    A == abc.
+'string_to_atom reverse' :-
+    string_to_atom(S, abc),
+    S == "abc".
+string_length :-
+    string_length("λx", N),
+    N == 2.
+'atomic_list_concat with strings' :-
+    atomic_list_concat([a, "b", 1], X),
+    X == ab1.
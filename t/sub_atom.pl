@@ -0,0 +1,88 @@
+% Tests for atom_concat/3, sub_atom/5, atomic_list_concat/2,3 and
+% term_to_atom/2
+%
+% atom_concat/3 and sub_atom/5 are defined in ISO §8.16.2 and §8.16.3
+:- use_module(library(tap)).
+
+% examples from ISO §8.16.2.4
+'atom_concat hello' :-
+    atom_concat('hello', ' world', S),
+    S == 'hello world'.
+'atom_concat suffix' :-
+    atom_concat(T, ' world', 'small world'),
+    T == small.
+'atom_concat mismatch'(fail) :-
+    atom_concat(hello, ' world', 'small world').
+'atom_concat enumerate' :-
+    findall(T1+T2, atom_concat(T1, T2, hello), L),
+    L == [''+hello, h+ello, he+llo, hel+lo, hell+o, hello+''].
+'atom_concat variables'(throws(error(instantiation_error, _))) :-
+    atom_concat(small, _, _).
+'atom_concat number'(throws(error(type_error(atom, 1), _))) :-
+    atom_concat(1, a, _).
+
+% examples from ISO §8.16.3.4
+'sub_atom prefix' :-
+    sub_atom(abracadabra, 0, 5, _, S2),
+    S2 == abrac.
+'sub_atom suffix' :-
+    sub_atom(abracadabra, _, 5, 0, S2),
+    S2 == dabra.
+'sub_atom middle' :-
+    sub_atom(abracadabra, 3, L, 3, S2),
+    L == 5,
+    S2 == acada.
+'sub_atom occurrences' :-
+    findall(B-A, sub_atom(abracadabra, B, 2, A, ab), L),
+    L == [0-9, 7-2].
+'sub_atom character' :-
+    sub_atom('Banana', 3, 2, _, S2),
+    S2 == an.
+'sub_atom length' :-
+    findall(S2, sub_atom(charity, _, 3, _, S2), L),
+    L == [cha, har, ari, rit, ity].
+'sub_atom all' :-
+    findall(B-L-A, sub_atom(ab, B, L, A, _), Xs),
+    Xs == [0-0-2, 0-1-1, 0-2-0, 1-0-1, 1-1-0, 2-0-0].
+'sub_atom first of many' :-
+    with_output_to(atom(Atom), format('~*c', [5000, 0'a])),
+    sub_atom(Atom, B, L, _, S),
+    !,
+    B-L-S == 0-0-''.
+'sub_atom cut after search' :-
+    sub_atom(banana, B, _, _, an),
+    !,
+    B == 1.
+'sub_atom out of range'(fail) :-
+    sub_atom(abc, 2, 2, _, _).
+'sub_atom variable'(throws(error(instantiation_error, _))) :-
+    sub_atom(_, _, _, _, _).
+'sub_atom sub'(throws(error(type_error(atom, 1), _))) :-
+    sub_atom(abc, _, _, _, 1).
+'sub_atom before'(throws(error(type_error(integer, a), _))) :-
+    sub_atom(abc, a, _, _, _).
+
+'atomic_list_concat join' :-
+    atomic_list_concat([a, 'B', 1, 2.5], X),
+    X == 'aB12.5'.
+'atomic_list_concat separator' :-
+    atomic_list_concat([a, b, c], '-', X),
+    X == 'a-b-c'.
+'atomic_list_concat split' :-
+    atomic_list_concat(L, ',', 'a,b,,c'),
+    L == [a, b, '', c].
+'atomic_list_concat empty separator'(throws(error(domain_error(non_empty_atom, ''), _))) :-
+    atomic_list_concat(_, '', abc).
+'atomic_list_concat compound'(throws(error(type_error(atomic, f(x)), _))) :-
+    atomic_list_concat([a, f(x)], _).
+
+'term_to_atom write' :-
+    term_to_atom(foo(a, 1), A),
+    A == 'foo(a,1)'.
+'term_to_atom read' :-
+    term_to_atom(T, 'bar(X, Y, X)'),
+    T = bar(A, B, C),
+    A == C,
+    A \== B.
+'term_to_atom syntax'(throws(error(syntax_error(_), _))) :-
+    term_to_atom(_, 'foo(').
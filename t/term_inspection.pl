@@ -0,0 +1,216 @@
+% Tests for term creation and decomposition
+%
+% As defined in ISO §8.5 plus setarg/3 and nb_setarg/3
+
+% helpers
+count_solutions(Goal, Count) :-
+    State = count(0),
+    (   call(Goal),
+        arg(1, State, C0),
+        C is C0 + 1,
+        nb_setarg(1, State, C),
+        fail
+    ;   arg(1, State, Count)
+    ).
+member_(X, [X|_]).
+member_(X, [_|T]) :-
+    member_(X, T).
+
+:- use_module(library(tap)).
+
+% functor/3 examples taken from ISO §8.5.1.4
+'functor of compound' :-
+    functor(foo(a,b,c), foo, 3).
+'functor name and arity' :-
+    functor(foo(a,b,c), N, A),
+    N == foo,
+    A == 3.
+'functor constructs' :-
+    functor(X, foo, 3),
+    X = foo(A, B, C),
+    var(A), var(B), var(C),
+    A \== B.
+'functor of atomic' :-
+    functor(X, foo, 0),
+    X == foo,
+    functor(1.5, N, A),
+    N == 1.5,
+    A == 0.
+'functor of list' :-
+    functor([_|_], N, A),
+    N == '.',
+    A == 2.
+'functor unbound'(throws(instantiation_error)) :-
+    functor(_, _, 3).
+'functor bad name'(throws(type_error(atomic, foo(a)))) :-
+    functor(_, foo(a), 1).
+'functor number name'(throws(type_error(atom, 1.5))) :-
+    functor(_, 1.5, 1).
+'functor bad arity'(throws(type_error(integer, a))) :-
+    functor(_, foo, a).
+'functor negative arity'(throws(domain_error(not_less_than_zero, -1))) :-
+    functor(_, foo, -1).
+
+% arg/3 examples taken from ISO §8.5.2.4
+'arg of compound' :-
+    arg(1, foo(a,b), a).
+'arg binds' :-
+    arg(1, foo(X,b), a),
+    X == a.
+'arg out of range'(fail) :-
+    arg(3, foo(a,b), _).
+'arg zero'(fail) :-
+    arg(0, foo(a,b), _).
+'arg enumerates' :-
+    findall(N-A, arg(N, foo(a,b,c), A), L),
+    L == [1-a, 2-b, 3-c].
+'arg enumerates matching' :-
+    findall(N, arg(N, foo(a,b,a), a), L),
+    L == [1, 3].
+'arg of atom'(throws(type_error(compound, atom))) :-
+    arg(1, atom, _).
+'arg of variable'(throws(instantiation_error)) :-
+    arg(1, _, _).
+'arg bad index'(throws(type_error(integer, a))) :-
+    arg(a, foo(a), _).
+
+% =../2 examples taken from ISO §8.5.3.4
+'univ of compound' :-
+    foo(a,b) =.. L,
+    L == [foo, a, b].
+'univ constructs' :-
+    X =.. [foo, a, b],
+    X == foo(a,b).
+'univ of atomic' :-
+    X =.. [1.5],
+    X == 1.5,
+    abc =.. L,
+    L == [abc].
+'univ partial list'(throws(instantiation_error)) :-
+    _ =.. [foo|_].
+'univ empty list'(throws(domain_error(non_empty_list, []))) :-
+    _ =.. [].
+'univ compound name'(throws(type_error(atomic, f(a)))) :-
+    _ =.. [f(a), b].
+'univ number name'(throws(type_error(atom, 1))) :-
+    _ =.. [1, b].
+
+% copy_term/2 examples taken from ISO §8.5.4.4
+'copy_term renames' :-
+    copy_term(f(X, Y, X), C),
+    C = f(A, B, A2),
+    A == A2,
+    A \== B,
+    A \== X.
+'copy_term of ground' :-
+    copy_term(f(a), C),
+    C == f(a).
+'copy_term keeps bindings' :-
+    X = a,
+    copy_term(f(X, _), f(A, _)),
+    A == a.
+
+% term_variables/2 examples taken from ISO §8.5.5.4
+'term_variables order' :-
+    term_variables(t(X, f(Y, X), Z), Vs),
+    Vs == [X, Y, Z].
+'term_variables of ground' :-
+    term_variables(f(a, b), Vs),
+    Vs == [].
+'term_variables bound' :-
+    X = f(Y),
+    term_variables(g(X, Z), Vs),
+    Vs == [Y, Z].
+
+% setarg/3 and nb_setarg/3
+'setarg changes argument' :-
+    T = f(a, b),
+    setarg(1, T, c),
+    T == f(c, b).
+'setarg with bound variables inside' :-
+    T = f(X, b),
+    X = 1,
+    setarg(2, T, c),
+    T == f(1, c).
+'setarg undone on backtracking' :-
+    T = f(a),
+    (   setarg(1, T, b),
+        fail
+    ;   true
+    ),
+    T == f(a).
+'setarg survives cut' :-
+    T = f(a),
+    (   ( setarg(1, T, b) -> fail ; true )
+    ;   true
+    ),
+    T == f(a).
+'setarg undone by exception' :-
+    T = f(a),
+    catch((setarg(1, T, b), throw(oops)), oops, true),
+    T == f(a).
+'setarg undone after negation' :-
+    T = f(a),
+    \+ \+ setarg(1, T, b),
+    T == f(a).
+'setarg undone after forall' :-
+    T = f(a),
+    forall(member_(X, [b, c]), setarg(1, T, X)),
+    T == f(a).
+'setarg kept by with_output_to' :-
+    T = f(a),
+    with_output_to(atom(_), setarg(1, T, b)),
+    T == f(b).
+'setarg from with_output_to undone on backtracking' :-
+    T = f(a),
+    (   with_output_to(atom(_), setarg(1, T, b)),
+        fail
+    ;   true
+    ),
+    T == f(a).
+'setarg twice' :-
+    T = f(a, b),
+    setarg(1, T, c),
+    setarg(2, T, d),
+    T == f(c, d).
+'setarg value bound later' :-
+    T = f(a),
+    setarg(1, T, X),
+    X = 3,
+    T == f(3).
+'setarg of nested term' :-
+    T = g(f(A)),
+    A = 1,
+    arg(1, T, S),
+    setarg(1, S, b),
+    T == g(f(b)).
+'setarg seen by findall' :-
+    Z = f(a),
+    findall(Y, (setarg(1, Z, b), Y = Z), L),
+    L == [f(b)],
+    Z == f(a).
+'setarg seen by bagof' :-
+    Z = f(a),
+    bagof(Y, (setarg(1, Z, b), Y = Z), L),
+    L == [f(b)].
+'setarg seen by aggregate_all' :-
+    Z = f(a),
+    aggregate_all(bag(Y), (member_(X, [b, c]), setarg(1, Z, X), Y = Z), L),
+    L == [f(b), f(c)].
+'findall results unchanged by later setarg' :-
+    Z = f(a),
+    findall(Z, setarg(1, Z, b), [W]),
+    setarg(1, Z, c),
+    W == f(b).
+'setarg out of range'(fail) :-
+    setarg(2, f(a), b).
+'setarg of atom'(throws(type_error(compound, a))) :-
+    setarg(1, a, b).
+'nb_setarg survives backtracking' :-
+    count_solutions(member_(_, [a,b,c]), N),
+    N == 3.
+'nb_setarg copies value' :-
+    T = f(a),
+    nb_setarg(1, T, g(X)),
+    T = f(g(Y)),
+    X \== Y.
@@ -0,0 +1,46 @@
+% Tests for calling unknown procedures and dynamic/1
+%
+% These predicates follow ISO §7.7.7 and SWI-Prolog
+:- dynamic declared/1.
+:- dynamic((also_declared/0, listed/2)).
+:- dynamic([in_list/1]).
+:- dynamic defined/1.
+defined(a).
+
+:- use_module(library(tap)).
+
+'unknown procedure'(throws(error(existence_error(procedure, nope/1), _))) :-
+    nope(_).
+'unknown atom procedure'(throws(error(existence_error(procedure, nope/0), _))) :-
+    nope.
+'existence error is catchable' :-
+    catch(nope(1, 2), error(existence_error(procedure, PI), _), true),
+    PI == nope/2.
+'wrong arity is unknown'(throws(error(existence_error(procedure, defined/2), _))) :-
+    defined(_, _).
+'unknown fail'(fail) :-
+    set_prolog_flag(unknown, fail),
+    nope(_).
+'unknown fail survives backtracking'(fail) :-
+    ( set_prolog_flag(unknown, fail), fail ; true ),
+    nope.
+'dynamic without clauses'(fail) :-
+    declared(_).
+'dynamic conjunction'(fail) :-
+    ( also_declared ; listed(_, _) ).
+'dynamic list'(fail) :-
+    in_list(_).
+'dynamic with clauses' :-
+    defined(X),
+    X == a.
+'dynamic at runtime'(fail) :-
+    dynamic(later/1),
+    later(_).
+'dynamic instantiation error'(throws(error(instantiation_error, _))) :-
+    dynamic(_).
+'dynamic type error'(throws(error(type_error(predicate_indicator, foo), _))) :-
+    dynamic(foo).
+'dynamic bad arity'(throws(error(domain_error(not_less_than_zero, -1), _))) :-
+    dynamic(foo/(-1)).
+'dynamic builtin'(throws(error(permission_error(modify, static_procedure, atom_length/2), _))) :-
+    dynamic(atom_length/2).
@@ -0,0 +1,74 @@
+% Tests for with_output_to/2 and format/3 sinks
+%
+% These predicates follow SWI-Prolog
+:- use_module(library(tap)).
+
+'atom sink' :-
+    with_output_to(atom(A), (put_char(h), put_char(i))),
+    A == hi.
+'string sink' :-
+    with_output_to(string(S), format("~w and ~w", [a, b])),
+    string(S),
+    string_to_atom(S, 'a and b').
+'codes sink' :-
+    with_output_to(codes(Cs), put_char(x)),
+    Cs == [0'x].
+'chars sink' :-
+    with_output_to(chars(Cs), (put_char(x), nl)),
+    char_code(NL, 10),
+    Cs == [x, NL].
+'empty output' :-
+    with_output_to(atom(A), true),
+    A == ''.
+'goal bindings are kept' :-
+    with_output_to(atom(A), X = 1),
+    A == '',
+    X == 1.
+'proves goal once' :-
+    findall(A, with_output_to(atom(A), member(_, [a, b])), As),
+    As == [''].
+'nested sinks' :-
+    with_output_to(atom(Outer), (
+        put_char(a),
+        with_output_to(atom(Inner), put_char(b)),
+        put_char(c)
+    )),
+    Outer == ac,
+    Inner == b.
+'current output is a stream' :-
+    with_output_to(atom(A), (current_output(S), put_char(S, z))),
+    A == z.
+'output restored after failure' :-
+    current_output(Before),
+    \+ with_output_to(atom(_), (put_char(a), fail)),
+    current_output(After),
+    Before == After.
+'output restored after exception' :-
+    current_output(Before),
+    catch(with_output_to(atom(_), throw(oops)), oops, true),
+    current_output(After),
+    Before == After.
+'goal fails'(fail) :-
+    with_output_to(atom(_), fail).
+'unbound sink'(throws(error(instantiation_error, _))) :-
+    with_output_to(_, true).
+'bad sink'(throws(error(domain_error(output_sink, file(x)), _))) :-
+    with_output_to(file(x), true).
+
+'format/3 atom' :-
+    format(atom(A), "~a-~w~n", [x, f(y)]),
+    char_code(NL, 10),
+    atom_concat('x-f(y)', NL, A).
+'format/3 string' :-
+    format(string(S), "~~~w", hello),
+    string_to_atom(S, '~hello').
+'format/3 codes' :-
+    format(codes(Cs), "ok", []),
+    Cs == [0'o, 0'k].
+'format/3 stream' :-
+    with_output_to(atom(A), (current_output(S), format(S, "~w", [1]))),
+    A == '1'.
+'format too few arguments'(throws(error(format('not enough arguments'), _))) :-
+    format(atom(_), "~w ~w", [a]).
+'format too many arguments'(throws(error(format('too many arguments'), _))) :-
+    format(atom(_), "~w", [a, b]).
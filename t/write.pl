@@ -0,0 +1,180 @@
+% Tests for write/1, writeq/1, print/1, write_canonical/1, write_term/2
+% and numbervars/3
+%
+% Expected output follows SWI-Prolog
+out(Goal, Text) :-
+    with_output_to(atom(Text), Goal).
+
+portray(secret(_)) :-
+    write('<hidden>').
+
+:- use_module(library(tap)).
+
+'write atom' :-
+    out(write('hello world'), A),
+    A == 'hello world'.
+'writeq atom' :-
+    out(writeq('hello world'), A),
+    A == '\'hello world\''.
+'writeq special atoms' :-
+    out(writeq(f([], '{}', '[]', ',', '|')), A),
+    A == 'f([],{},[],\',\',\'|\')'.
+'writeq distinct variables' :-
+    copy_term(X, Y),
+    out(writeq(X-Y), A),
+    atom_codes(A, Codes),
+    atom_codes(B, Codes),
+    term_to_atom(P-Q, B),
+    P \== Q.
+'writeq same variable' :-
+    out(writeq(f(X, X)), A),
+    term_to_atom(f(P, Q), A),
+    P == Q.
+'write_canonical distinct variables' :-
+    findall(X, member(X, [_, _]), [V, W]),
+    out(write_canonical(V-W), A),
+    term_to_atom(P-Q, A),
+    P \== Q.
+'term_to_atom round trip' :-
+    term_to_atom(X-Y, A),
+    term_to_atom(P-Q, A),
+    P \== Q,
+    var(X), var(Y).
+'write infix operators' :-
+    out(write(1+2*3), A),
+    A == '1+2*3'.
+'write brackets lower priority' :-
+    out(write((1+2)*3), A),
+    A == '(1+2)*3'.
+'write left associative' :-
+    out(write(1-2-3), A),
+    A == '1-2-3'.
+'write right operand of yfx' :-
+    out(write(1-(2-3)), A),
+    A == '1-(2-3)'.
+'write clause' :-
+    out(write((a :- b, c ; d)), A),
+    A == 'a:-b,c;d'.
+'write alphabetic operator' :-
+    out(write(X is 1 mod 2), A),
+    X = x,
+    sub_atom(A, _, _, 0, ' is 1 mod 2').
+'write negative number operand' :-
+    out(write(1 - -1), A),
+    A == '1- -1'.
+'write prefix minus on number' :-
+    out(write(-(1)), A),
+    A == '- 1'.
+'write prefix minus on atom' :-
+    out(write(-a), A),
+    A == '-a'.
+'write prefix operator with bracketed operand' :-
+    out(write(-(1+2)), A),
+    A == '- (1+2)'.
+'write adjacent symbolic operators' :-
+    out(write(a - (-b)), A),
+    A == 'a- -b'.
+'write bracketed prefix operator' :-
+    X =.. [=, a, (:- b)],
+    out(write(X), A),
+    A == 'a=(:-b)'.
+'write comma argument' :-
+    out(write(f((a, b))), A),
+    A == 'f((a,b))'.
+'write high priority argument' :-
+    out(write(f((a :- b))), A),
+    A == 'f((a:-b))'.
+'write operator as operand' :-
+    X =.. [=, -, a],
+    out(writeq(X), A),
+    A == '(-)=a'.
+'write operator as argument' :-
+    out(writeq(f(+)), A),
+    A == 'f(+)'.
+'write curly term' :-
+    X =.. ['{}', (a, b)],
+    out(write(X), A),
+    A == '{a,b}'.
+'write lists' :-
+    out(write([a, b|c]), A),
+    A == '[a,b|c]'.
+'write code list' :-
+    out(write([0'a, 0'b]), A),
+    A == '[97,98]'.
+'write string' :-
+    string_to_atom(S, 'a b'),
+    out(write(S), A),
+    A == 'a b'.
+'writeq string' :-
+    string_to_atom(S, 'a b'),
+    out(writeq(S), A),
+    A == '"a b"'.
+'write numbervars' :-
+    out(write(f('$VAR'(0), '$VAR'(25), '$VAR'(27))), A),
+    A == 'f(A,Z,B1)'.
+'writeq integral float' :-
+    out(writeq(1.0), A),
+    A == '1.0'.
+'writeq integral float result' :-
+    X is 1.5 * 2,
+    out(writeq(X), A),
+    A == '3.0',
+    term_to_atom(Y, A),
+    Y == X.
+'write_canonical' :-
+    out(write_canonical(f('$VAR'(1), 1+a, 'B')), A),
+    A == 'f(\'$VAR\'(1),+(1,a),\'B\')'.
+'write_term ignore_ops' :-
+    out(write_term(1+2, [ignore_ops(true)]), A),
+    A == '+(1,2)'.
+'write_term spacing' :-
+    out(write_term(f(a, [b, c]), [spacing(next_argument)]), A),
+    A == 'f(a, [b, c])'.
+'write_term max_depth list' :-
+    out(write_term([1, 2, 3, 4, 5, 6], [max_depth(3)]), A),
+    A == '[1,2|...]'.
+'write_term max_depth compound' :-
+    out(write_term(f(g(h(i))), [max_depth(2)]), A),
+    A == 'f(g(...))'.
+'write_term quoted' :-
+    out(write_term('A'-b, [quoted(true)]), A),
+    A == '\'A\'-b'.
+'write_term unquoted by default' :-
+    out(write_term(f('A', '$VAR'(1)), []), A),
+    A == 'f(A,$VAR(1))'.
+'write_term to stream' :-
+    out((current_output(S), write_term(S, a+b, [])), A),
+    A == 'a+b'.
+'print calls portray' :-
+    out(print(f(secret(x), 'A')), A),
+    A == 'f(<hidden>,\'A\')'.
+'write ignores portray' :-
+    out(write(secret(x)), A),
+    A == 'secret(x)'.
+'write dict' :-
+    out(write(point{x: 1, y: 2}), A),
+    A == 'point{x:1,y:2}'.
+'write_term unknown option'(throws(error(domain_error(write_option, foo), _))) :-
+    write_term(a, [foo]).
+'write_term bad boolean'(throws(error(domain_error(write_option, quoted(yes)), _))) :-
+    write_term(a, [quoted(yes)]).
+'write_term partial options'(throws(error(instantiation_error, _))) :-
+    write_term(a, [quoted(true)|_]).
+'write to input stream'(throws(error(permission_error(output, stream, user_input), _))) :-
+    write(user_input, a).
+
+'numbervars' :-
+    T = f(X, Y, X),
+    numbervars(T, 0, End),
+    End == 2,
+    T == f('$VAR'(0), '$VAR'(1), '$VAR'(0)).
+'numbervars from start' :-
+    numbervars(g(X), 23, End),
+    End == 24,
+    out(print(X), A),
+    A == 'X'.
+'numbervars ground' :-
+    numbervars(a, 5, End),
+    End == 5.
+'numbervars start unbound'(throws(error(instantiation_error, _))) :-
+    numbervars(_, _, _).
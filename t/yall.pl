@@ -0,0 +1,59 @@
+% Tests for lambda expressions
+%
+% These follow SWI-Prolog's library(yall)
+:- use_module(library(tap)).
+
+'>> with maplist' :-
+    maplist([X, Y]>>(Y is X * 2), [1, 2, 3], L),
+    L == [2, 4, 6].
+'>> without parameters' :-
+    call([]>>true).
+'>> extra arguments' :-
+    call([X]>>atom_length(X), abc, N),
+    N == 3.
+'>> more parameters than arguments' :-
+    call([X, _]>>(X = a), A),
+    A == a.
+'>> copies the lambda' :-
+    maplist([X]>>(Y = X), [a, b]),
+    var(Y).
+'>> sees bindings made before the call' :-
+    Y = 10,
+    maplist([X, Z]>>(Z is X + Y), [1, 2], L),
+    L == [11, 12].
+'>> with foldl' :-
+    foldl([X, A0, A]>>(A is A0 + X), [1, 2, 3], 0, Sum),
+    Sum == 6.
+'>> nested' :-
+    maplist([L, N]>>foldl([X, A0, A]>>(A is A0 + X), L, 0, N), [[1, 2], [3]], Ns),
+    Ns == [3, 3].
+'>> fails'(fail) :-
+    call([X]>>(X > 5), 3).
+
+'/ shares free variables' :-
+    maplist(Y/[X]>>(X = Y), [A, B]),
+    var(Y),
+    A == B.
+'/ binds free variables' :-
+    call(Y/[X]>>(Y = X), a),
+    Y == a.
+'/ with >> reads like SWI-Prolog' :-
+    T = Y/[X]>>true,
+    T =.. [>>, Y/[X], true].
+'/ with a plain goal' :-
+    call(Y/(Y = b)),
+    Y == b.
+
+'lambda-local parameters' :-
+    maplist(\X^Y^(Y is X + 1), [1, 2], L),
+    L == [2, 3].
+'lambda-local copies the lambda' :-
+    maplist(\X^(Y = X), [a, b]),
+    var(Y).
+'lambda-local with free variables' :-
+    call(Y/(\X^(Y = X)), c),
+    Y == c.
+
+'^ binds parameters' :-
+    call(X^Y^atom_length(X, Y), abc, N),
+    N == 3.
@@ -0,0 +1,734 @@
+package term
+
+// Evaluable functors for arithmetic expressions.  See ISO §9 for most
+// definitions.  Common extensions from SWI-Prolog are included too.
+//
+// Integer operands stay integers and rational operands stay rational
+// whenever the result can be represented exactly.  Floating point is
+// only used as a last resort, or when a function is inherently
+// irrational (sqrt, sin, etc.)
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+)
+
+var evaluable0 map[string]func() (Number, error)
+var evaluable1 map[string]func(Number) (Number, error)
+var evaluable2 map[string]func(Number, Number) (Number, error)
+
+// yieldsRational names the functions whose integer arguments produce a
+// float, rather than a rational, when the prefer_rationals flag is false.
+// For ^/2, that's a type_error(float, Base) instead.
+var yieldsRational = map[string]bool{"/": true, "**": true, "^": true}
+
+// maxIntegerBits limits the size of integers built by shifts and powers.
+// Larger results raise resource_error(memory) instead of exhausting it.
+const maxIntegerBits = 1 << 26
+
+func init() {
+	evaluable0 = map[string]func() (Number, error){
+		"e":            constantFloat(math.E),
+		"epsilon":      constantFloat(math.Nextafter(1, 2) - 1),
+		"inf":          constantFloat(math.Inf(1)),
+		"infinite":     constantFloat(math.Inf(1)),
+		"nan":          constantFloat(math.NaN()),
+		"pi":           constantFloat(math.Pi),
+		"random_float": arithmeticRandomFloat,
+	}
+
+	evaluable1 = map[string]func(Number) (Number, error){
+		"+":                     arithmeticPlus,
+		"-":                     ArithmeticNegate,
+		"\\":                    arithmeticBitNot,
+		"abs":                   ArithmeticAbs,
+		"acos":                  floatFunction(math.Acos),
+		"acosh":                 floatFunction(math.Acosh),
+		"asin":                  floatFunction(math.Asin),
+		"asinh":                 floatFunction(math.Asinh),
+		"atan":                  floatFunction(math.Atan),
+		"atanh":                 floatFunction(math.Atanh),
+		"ceiling":               ArithmeticCeiling,
+		"cos":                   floatFunction(math.Cos),
+		"cosh":                  floatFunction(math.Cosh),
+		"cot":                   floatFunction(func(x float64) float64 { return 1 / math.Tan(x) }),
+		"denominator":           arithmeticDenominator,
+		"exp":                   floatFunction(math.Exp),
+		"float":                 ArithmeticFloat,
+		"float_fractional_part": arithmeticFloatFractionalPart,
+		"float_integer_part":    arithmeticFloatIntegerPart,
+		"floor":                 ArithmeticFloor,
+		"integer":               ArithmeticRound,
+		"log":                   arithmeticLog,
+		"log2":                  arithmeticLog2,
+		"msb":                   arithmeticMsb,
+		"numerator":             arithmeticNumerator,
+		"random":                arithmeticRandom,
+		"rational":              ArithmeticRational,
+		"rationalize":           ArithmeticRationalize,
+		"round":                 ArithmeticRound,
+		"sign":                  ArithmeticSign,
+		"sin":                   floatFunction(math.Sin),
+		"sinh":                  floatFunction(math.Sinh),
+		"sqrt":                  arithmeticSqrt,
+		"tan":                   floatFunction(math.Tan),
+		"tanh":                  floatFunction(math.Tanh),
+		"truncate":              ArithmeticTruncate,
+	}
+
+	evaluable2 = map[string]func(Number, Number) (Number, error){
+		"*":        ArithmeticMultiply,
+		"**":       ArithmeticPower,
+		"+":        ArithmeticAdd,
+		"-":        ArithmeticMinus,
+		"/":        ArithmeticDivide,
+		"//":       ArithmeticIntDivide,
+		"/\\":      integerFunction(func(z, x, y *big.Int) { z.And(x, y) }),
+		"<<":       arithmeticShiftLeft,
+		">>":       arithmeticShiftRight,
+		"\\/":      integerFunction(func(z, x, y *big.Int) { z.Or(x, y) }),
+		"^":        ArithmeticPower,
+		"atan":     arithmeticAtan2,
+		"atan2":    arithmeticAtan2,
+		"copysign": arithmeticCopySign,
+		"div":      ArithmeticFloorDivide,
+		"gcd":      integerFunction(func(z, x, y *big.Int) { z.GCD(nil, nil, x, y) }),
+		"log":      arithmeticLogBase,
+		"max":      ArithmeticMax,
+		"min":      ArithmeticMin,
+		"mod":      ArithmeticMod,
+		"rdiv":     ArithmeticRationalDivide,
+		"rem":      ArithmeticRem,
+		"xor":      integerFunction(func(z, x, y *big.Int) { z.Xor(x, y) }),
+	}
+}
+
+// floatResult converts the result of a floating point computation
+// into a Golog number.  NaN and infinite values are reported as
+// evaluation errors unless one of the inputs was already NaN or infinite.
+func floatResult(r float64, inputs ...float64) (Number, error) {
+	if math.IsNaN(r) {
+		for _, x := range inputs {
+			if math.IsNaN(x) {
+				return NewFloat64(r), nil
+			}
+		}
+		return nil, EvaluationError("undefined")
+	}
+	if math.IsInf(r, 0) {
+		for _, x := range inputs {
+			if math.IsInf(x, 0) || math.IsNaN(x) {
+				return NewFloat64(r), nil
+			}
+		}
+		return nil, EvaluationError("float_overflow")
+	}
+	return NewFloat64(r), nil
+}
+
+// floatRatResult returns a Rational which stands for a float with r's
+// exact value.  A value too large for a float is reported as an
+// evaluation error, like floatResult does.
+func floatRatResult(r *big.Rat) (Number, error) {
+	if f, _ := r.Float64(); math.IsInf(f, 0) {
+		return nil, EvaluationError("float_overflow")
+	}
+	return newFloatRat(r), nil
+}
+
+// ratResult returns an integer if r has no fractional part, otherwise
+// a rational
+func ratResult(r *big.Rat) Number {
+	if r.IsInt() {
+		return NewBigInt(new(big.Int).Set(r.Num()))
+	}
+	return NewBigRat(r)
+}
+
+// isZero returns true if n is numerically equal to zero
+func isZero(n Number) bool {
+	switch x := n.(type) {
+	case *Integer:
+		return x.Value().Sign() == 0
+	case *Rational:
+		return x.Value().Sign() == 0
+	}
+	return n.Float64() == 0
+}
+
+// mustInteger returns n's value if it's an integer, otherwise a type error.
+// Rationals with an integral value are floats, so they're rejected too.
+func mustInteger(n Number) (*big.Int, error) {
+	if x, ok := n.(*Integer); ok {
+		return x.Value(), nil
+	}
+	return nil, TypeError("integer", n)
+}
+
+// floatToInt converts a float which has no fractional part into an
+// integer.  Infinite and NaN values have no integer representation.
+func floatToInt(f float64) (Number, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, EvaluationError("undefined")
+	}
+	i, _ := big.NewFloat(f).Int(nil)
+	return NewBigInt(i), nil
+}
+
+func constantFloat(f float64) func() (Number, error) {
+	return func() (Number, error) {
+		return NewFloat64(f), nil
+	}
+}
+
+// floatFunction adapts a float64 function from package math into an
+// evaluable functor
+func floatFunction(f func(float64) float64) func(Number) (Number, error) {
+	return func(a Number) (Number, error) {
+		x := a.Float64()
+		return floatResult(f(x), x)
+	}
+}
+
+// integerFunction adapts a big.Int operation into an evaluable functor
+// which requires both arguments to be integers
+func integerFunction(f func(z, x, y *big.Int)) func(Number, Number) (Number, error) {
+	return func(a, b Number) (Number, error) {
+		x, err := mustInteger(a)
+		if err != nil {
+			return nil, err
+		}
+		y, err := mustInteger(b)
+		if err != nil {
+			return nil, err
+		}
+		z := new(big.Int)
+		f(z, x, y)
+		return NewBigInt(z), nil
+	}
+}
+
+func arithmeticPlus(a Number) (Number, error) {
+	return a, nil
+}
+
+// ArithmeticNegate returns -a.  The negation of a zero Rational which
+// stands for a float is the float -0.0
+func ArithmeticNegate(a Number) (Number, error) {
+	switch x := a.(type) {
+	case *Integer:
+		return NewBigInt(new(big.Int).Neg(x.Value())), nil
+	case *Rational:
+		if x.Value().Sign() == 0 {
+			return NewFloat64(math.Copysign(0, -1)), nil
+		}
+		return &Rational{val: new(big.Rat).Neg(x.Value()), float: x.float}, nil
+	}
+	return NewFloat64(-a.Float64()), nil
+}
+
+// ArithmeticAbs returns the absolute value of a
+func ArithmeticAbs(a Number) (Number, error) {
+	switch x := a.(type) {
+	case *Integer:
+		return NewBigInt(new(big.Int).Abs(x.Value())), nil
+	case *Rational:
+		return &Rational{val: new(big.Rat).Abs(x.Value()), float: x.float}, nil
+	}
+	return NewFloat64(math.Abs(a.Float64())), nil
+}
+
+// ArithmeticSign returns -1, 0 or 1 depending on the sign of a.  Floats
+// produce a float result; integers and rationals produce an integer.
+func ArithmeticSign(a Number) (Number, error) {
+	switch x := a.(type) {
+	case *Integer:
+		return NewInt64(int64(x.Value().Sign())), nil
+	case *Rational:
+		if !x.float {
+			return NewInt64(int64(x.Value().Sign())), nil
+		}
+	}
+	f := a.Float64()
+	switch {
+	case math.IsNaN(f):
+		return NewFloat64(f), nil
+	case f > 0:
+		return NewFloat64(1), nil
+	case f < 0:
+		return NewFloat64(-1), nil
+	}
+	return NewFloat64(0), nil
+}
+
+// ArithmeticMin returns the smaller of a and b
+func ArithmeticMin(a, b Number) (Number, error) {
+	if NumberCmp(b, a) < 0 {
+		return b, nil
+	}
+	return a, nil
+}
+
+// ArithmeticMax returns the larger of a and b
+func ArithmeticMax(a, b Number) (Number, error) {
+	if NumberCmp(b, a) > 0 {
+		return b, nil
+	}
+	return a, nil
+}
+
+// ArithmeticFloat converts a to a floating point number
+func ArithmeticFloat(a Number) (Number, error) {
+	if _, ok := a.(*Float); ok {
+		return a, nil
+	}
+	x := a.Float64()
+	if math.IsInf(x, 0) {
+		return nil, EvaluationError("float_overflow")
+	}
+	return NewFloat64(x), nil
+}
+
+// ArithmeticTruncate returns the integer nearest a, in the
+// direction of zero
+func ArithmeticTruncate(a Number) (Number, error) {
+	switch x := a.(type) {
+	case *Integer:
+		return x, nil
+	case *Rational:
+		r := x.Value()
+		return NewBigInt(new(big.Int).Quo(r.Num(), r.Denom())), nil
+	}
+	return floatToInt(math.Trunc(a.Float64()))
+}
+
+// ArithmeticFloor returns the largest integer not greater than a
+func ArithmeticFloor(a Number) (Number, error) {
+	switch x := a.(type) {
+	case *Integer:
+		return x, nil
+	case *Rational:
+		// denominators are always positive, so Euclidean division
+		// is the same as flooring
+		r := x.Value()
+		return NewBigInt(new(big.Int).Div(r.Num(), r.Denom())), nil
+	}
+	return floatToInt(math.Floor(a.Float64()))
+}
+
+// ArithmeticCeiling returns the smallest integer not less than a
+func ArithmeticCeiling(a Number) (Number, error) {
+	switch x := a.(type) {
+	case *Integer:
+		return x, nil
+	case *Rational:
+		r := x.Value()
+		neg := new(big.Int).Neg(r.Num())
+		floor := new(big.Int).Div(neg, r.Denom())
+		return NewBigInt(floor.Neg(floor)), nil
+	}
+	return floatToInt(math.Ceil(a.Float64()))
+}
+
+// ArithmeticRound returns the integer nearest a.  Halfway values are
+// rounded away from zero.
+func ArithmeticRound(a Number) (Number, error) {
+	switch x := a.(type) {
+	case *Integer:
+		return x, nil
+	case *Rational:
+		// floor(|r| + 1/2) with the original sign
+		r := x.Value()
+		abs := new(big.Rat).Abs(r)
+		abs.Add(abs, big.NewRat(1, 2))
+		i := new(big.Int).Div(abs.Num(), abs.Denom())
+		if r.Sign() < 0 {
+			i.Neg(i)
+		}
+		return NewBigInt(i), nil
+	}
+	return floatToInt(math.Round(a.Float64()))
+}
+
+func arithmeticFloatIntegerPart(a Number) (Number, error) {
+	switch x := a.(type) {
+	case *Integer:
+		return ArithmeticFloat(x)
+	case *Rational:
+		r := x.Value()
+		i := new(big.Int).Quo(r.Num(), r.Denom())
+		return newFloatRat(new(big.Rat).SetInt(i)), nil
+	}
+	x := a.Float64()
+	return floatResult(math.Trunc(x), x)
+}
+
+func arithmeticFloatFractionalPart(a Number) (Number, error) {
+	switch x := a.(type) {
+	case *Integer:
+		return NewFloat64(0), nil
+	case *Rational:
+		r := x.Value()
+		i := new(big.Int).Quo(r.Num(), r.Denom())
+		return newFloatRat(new(big.Rat).Sub(r, new(big.Rat).SetInt(i))), nil
+	}
+	x := a.Float64()
+	_, frac := math.Modf(x)
+	return floatResult(frac, x)
+}
+
+func arithmeticSqrt(a Number) (Number, error) {
+	x := a.Float64()
+	if x < 0 {
+		return nil, EvaluationError("undefined")
+	}
+	return floatResult(math.Sqrt(x), x)
+}
+
+func arithmeticLog(a Number) (Number, error) {
+	x := a.Float64()
+	if x <= 0 {
+		return nil, EvaluationError("undefined")
+	}
+	return floatResult(math.Log(x), x)
+}
+
+func arithmeticLog2(a Number) (Number, error) {
+	x := a.Float64()
+	if x <= 0 {
+		return nil, EvaluationError("undefined")
+	}
+	return floatResult(math.Log2(x), x)
+}
+
+// log(Base, X)
+func arithmeticLogBase(a, b Number) (Number, error) {
+	base := a.Float64()
+	x := b.Float64()
+	if base <= 0 || x <= 0 {
+		return nil, EvaluationError("undefined")
+	}
+	if base == 1 {
+		return nil, EvaluationError("zero_divisor")
+	}
+	return floatResult(math.Log(x)/math.Log(base), base, x)
+}
+
+func arithmeticAtan2(a, b Number) (Number, error) {
+	y := a.Float64()
+	x := b.Float64()
+	if x == 0 && y == 0 {
+		return nil, EvaluationError("undefined")
+	}
+	return floatResult(math.Atan2(y, x), y, x)
+}
+
+func arithmeticCopySign(a, b Number) (Number, error) {
+	x := a.Float64()
+	y := b.Float64()
+	return floatResult(math.Copysign(x, y), x, y)
+}
+
+func arithmeticBitNot(a Number) (Number, error) {
+	x, err := mustInteger(a)
+	if err != nil {
+		return nil, err
+	}
+	return NewBigInt(new(big.Int).Not(x)), nil
+}
+
+// msb(X) is the index of X's most significant 1 bit
+func arithmeticMsb(a Number) (Number, error) {
+	x, err := mustInteger(a)
+	if err != nil {
+		return nil, err
+	}
+	if x.Sign() <= 0 {
+		return nil, TypeError("not_less_than_one", a)
+	}
+	return NewInt64(int64(x.BitLen() - 1)), nil
+}
+
+func arithmeticRandom(a Number) (Number, error) {
+	x, err := mustInteger(a)
+	if err != nil {
+		return nil, err
+	}
+	if x.Sign() <= 0 {
+		return nil, DomainError("positive_integer", a)
+	}
+	if !x.IsInt64() {
+		return nil, RepresentationError("max_integer")
+	}
+	return NewInt64(rand.Int63n(x.Int64())), nil
+}
+
+func arithmeticRandomFloat() (Number, error) {
+	for {
+		f := rand.Float64()
+		if f > 0 { // the interval is open at both ends
+			return NewFloat64(f), nil
+		}
+	}
+}
+
+// integerDivision performs integer division after making sure that both
+// arguments are integers and the divisor isn't zero
+func integerDivision(a, b Number, f func(q, r, x, y *big.Int)) (Number, error) {
+	x, err := mustInteger(a)
+	if err != nil {
+		return nil, err
+	}
+	y, err := mustInteger(b)
+	if err != nil {
+		return nil, err
+	}
+	if y.Sign() == 0 {
+		return nil, EvaluationError("zero_divisor")
+	}
+	q, r := new(big.Int), new(big.Int)
+	f(q, r, x, y)
+	return NewBigInt(q), nil
+}
+
+// ArithmeticIntDivide implements //, integer division truncating
+// toward zero
+func ArithmeticIntDivide(a, b Number) (Number, error) {
+	return integerDivision(a, b, func(q, r, x, y *big.Int) {
+		q.Quo(x, y)
+	})
+}
+
+// ArithmeticFloorDivide implements div, integer division truncating
+// toward negative infinity
+func ArithmeticFloorDivide(a, b Number) (Number, error) {
+	return integerDivision(a, b, func(q, r, x, y *big.Int) {
+		q.QuoRem(x, y, r)
+		if r.Sign() != 0 && r.Sign() != y.Sign() {
+			q.Sub(q, big.NewInt(1))
+		}
+	})
+}
+
+// ArithmeticRem implements rem whose result has the same sign
+// as the dividend
+func ArithmeticRem(a, b Number) (Number, error) {
+	return integerDivision(a, b, func(q, r, x, y *big.Int) {
+		q.Rem(x, y)
+	})
+}
+
+// ArithmeticMod implements mod whose result has the same sign
+// as the divisor
+func ArithmeticMod(a, b Number) (Number, error) {
+	return integerDivision(a, b, func(q, r, x, y *big.Int) {
+		q.Rem(x, y)
+		if q.Sign() != 0 && q.Sign() != y.Sign() {
+			q.Add(q, y)
+		}
+	})
+}
+
+// shift x by n bits, left if n is positive, right if negative
+func shift(a, b Number, left bool) (Number, error) {
+	x, err := mustInteger(a)
+	if err != nil {
+		return nil, err
+	}
+	n, err := mustInteger(b)
+	if err != nil {
+		return nil, err
+	}
+	bits := new(big.Int).Abs(n)
+	if n.Sign() < 0 {
+		left = !left
+	}
+	z := new(big.Int)
+	switch {
+	case x.Sign() == 0:
+		// zero stays zero
+	case !left && bits.Cmp(big.NewInt(int64(x.BitLen()))) >= 0:
+		if x.Sign() < 0 {
+			z.SetInt64(-1) // every bit shifted out
+		}
+	case !left:
+		z.Rsh(x, uint(bits.Int64()))
+	case !bits.IsInt64() || int64(x.BitLen())+bits.Int64() > maxIntegerBits:
+		return nil, ResourceError("memory")
+	default:
+		z.Lsh(x, uint(bits.Int64()))
+	}
+	return NewBigInt(z), nil
+}
+
+func arithmeticShiftLeft(a, b Number) (Number, error) {
+	return shift(a, b, true)
+}
+
+func arithmeticShiftRight(a, b Number) (Number, error) {
+	return shift(a, b, false)
+}
+
+// ArithmeticPower implements both **/2 and ^/2.  An integer or rational
+// raised to an integer power produces an exact result.  A negative
+// integer exponent produces a rational, as if one had divided.  All other
+// cases produce a float.
+func ArithmeticPower(a, b Number) (Number, error) {
+	if n, ok := b.(*Integer); ok {
+		var base *big.Rat
+		switch x := a.(type) {
+		case *Integer:
+			base = new(big.Rat).SetInt(x.Value())
+		case *Rational:
+			// a float base stays exact only if the result fits in a float
+			if f := math.Pow(x.Float64(), n.Float64()); x.float && (f == 0 || math.IsInf(f, 0)) {
+				return floatResult(f, x.Float64(), n.Float64())
+			}
+			base = x.Value()
+		}
+		if base != nil {
+			r, err := ratPower(base, n.Value())
+			if err != nil {
+				return nil, err
+			}
+			return mixedRat(r, a, b)
+		}
+	}
+
+	// as floats
+	x, y := a.Float64(), b.Float64()
+	if x == 0 && y < 0 {
+		return nil, EvaluationError("zero_divisor")
+	}
+	return floatResult(math.Pow(x, y), x, y)
+}
+
+// ratPower raises a rational to an integer power exactly
+func ratPower(base *big.Rat, exp *big.Int) (*big.Rat, error) {
+	if exp.Sign() < 0 && base.Sign() == 0 {
+		return nil, EvaluationError("zero_divisor")
+	}
+	e := new(big.Int).Abs(exp)
+
+	// the result has at least (bits-1)*e bits, so refuse huge ones
+	// before computing them.  0, 1 and -1 stay small for any exponent.
+	bits := base.Num().BitLen()
+	if n := base.Denom().BitLen(); n > bits {
+		bits = n
+	}
+	if bits > 1 && (!e.IsInt64() || e.Int64() > maxIntegerBits/int64(bits-1)) {
+		return nil, ResourceError("memory")
+	}
+	num := new(big.Int).Exp(base.Num(), e, nil)
+	den := new(big.Int).Exp(base.Denom(), e, nil)
+	if exp.Sign() < 0 {
+		num, den = den, num
+	}
+	return new(big.Rat).SetFrac(num, den), nil
+}
+
+// mustRational returns the exact value of an integer or rational.  Other
+// numbers raise type_error(rational, X)
+func mustRational(n Number) (*big.Rat, error) {
+	switch x := n.(type) {
+	case *Integer:
+		return new(big.Rat).SetInt(x.Value()), nil
+	case *Rational:
+		if !x.float {
+			return x.Value(), nil
+		}
+	}
+	return nil, TypeError("rational", n)
+}
+
+func arithmeticNumerator(a Number) (Number, error) {
+	r, err := mustRational(a)
+	if err != nil {
+		return nil, err
+	}
+	return NewBigInt(new(big.Int).Set(r.Num())), nil
+}
+
+func arithmeticDenominator(a Number) (Number, error) {
+	r, err := mustRational(a)
+	if err != nil {
+		return nil, err
+	}
+	return NewBigInt(new(big.Int).Set(r.Denom())), nil
+}
+
+// ArithmeticRationalDivide divides two integers or rationals producing
+// an exact result, regardless of the prefer_rationals flag.
+func ArithmeticRationalDivide(a, b Number) (Number, error) {
+	x, err := mustRational(a)
+	if err != nil {
+		return nil, err
+	}
+	y, err := mustRational(b)
+	if err != nil {
+		return nil, err
+	}
+	if y.Sign() == 0 {
+		return nil, EvaluationError("zero_divisor")
+	}
+	return ratResult(new(big.Rat).Quo(x, y)), nil
+}
+
+// ArithmeticRational converts a number into the rational which has
+// exactly the same value.  Integers and rationals are unchanged.
+func ArithmeticRational(a Number) (Number, error) {
+	if x, ok := a.(*Rational); ok {
+		return ratResult(x.Value()), nil
+	}
+	if _, ok := a.(*Float); !ok {
+		return a, nil
+	}
+	f := a.Float64()
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, EvaluationError("undefined")
+	}
+	return ratResult(new(big.Rat).SetFloat64(f)), nil
+}
+
+// ArithmeticRationalize converts a binary float, a *Float, into the
+// simplest rational which converts back into the same float, where
+// rational/1 gives the float's exact binary value.  The reader reads
+// decimal literals like 0.1 as a Rational with the exact value 1r10, so
+// both functions give 1r10 for those.  They differ only for values
+// which are already binary floats, such as the result of float(1r10):
+// rationalize gives 1r10 and rational gives
+// 3602879701896397r36028797018963968.
+func ArithmeticRationalize(a Number) (Number, error) {
+	if x, ok := a.(*Rational); ok {
+		return ratResult(x.Value()), nil
+	}
+	if _, ok := a.(*Float); !ok {
+		return a, nil
+	}
+	f := a.Float64()
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, EvaluationError("undefined")
+	}
+
+	// walk the continued fraction expansion of f until a convergent
+	// rounds to the same float
+	x := new(big.Rat).SetFloat64(f)
+	p0, q0 := big.NewInt(0), big.NewInt(1)
+	p1, q1 := big.NewInt(1), big.NewInt(0)
+	for {
+		n := new(big.Int).Div(x.Num(), x.Denom()) // floor
+		p2 := new(big.Int).Add(new(big.Int).Mul(n, p1), p0)
+		q2 := new(big.Int).Add(new(big.Int).Mul(n, q1), q0)
+		r := new(big.Rat).SetFrac(p2, q2)
+		if g, _ := r.Float64(); g == f {
+			return ratResult(r), nil
+		}
+		frac := new(big.Rat).Sub(x, new(big.Rat).SetInt(n))
+		if frac.Sign() == 0 {
+			return ratResult(r), nil
+		}
+		x = frac.Inv(frac)
+		p0, q0, p1, q1 = p1, q1, p2, q2
+	}
+}
@@ -33,6 +33,12 @@ type Bindings interface {
 	// Resolve_ is like Resolve() but panics on error.
 	Resolve_(*Variable) Term
 
+	// SetArg returns a new Environment, like the old one, but in which
+	// the i-th argument (counting from 0) of a compound term is t.  The
+	// compound term itself doesn't change, so older environments still
+	// see the original argument.  It's the basis of setarg/3.
+	SetArg(*Compound, int, Term) Bindings
+
 	// Size returns the number of variable bindings in this environment.
 	Size() int
 
@@ -50,12 +56,51 @@ func NewBindings() Bindings {
 	var newEnv envMap
 	newEnv.bindings = ps.NewMap()
 	newEnv.names = ps.NewMap()
+	newEnv.args = ps.NewMap()
 	return &newEnv
 }
 
+// OccursCheck describes what happens when unification would bind a
+// variable to a term containing that same variable.  It corresponds to
+// the occurs_check Prolog flag.
+type OccursCheck int
+
+const (
+	// OccursCheckFalse binds the variable anyway, creating a cyclic term
+	OccursCheckFalse OccursCheck = iota
+
+	// OccursCheckTrue makes unification fail
+	OccursCheckTrue
+
+	// OccursCheckError raises an occurs_check(Var, Term) error
+	OccursCheckError
+)
+
+// WithOccursCheck returns bindings like b which perform the given
+// occurs check whenever a variable is bound.
+func WithOccursCheck(b Bindings, mode OccursCheck) Bindings {
+	env, ok := b.(*envMap)
+	if !ok || env.occursCheck == mode {
+		return b
+	}
+	newEnv := env.clone()
+	newEnv.occursCheck = mode
+	return newEnv
+}
+
 type envMap struct {
-	bindings ps.Map // v.Indicator() => Term
-	names    ps.Map // v.Name => *Variable
+	bindings    ps.Map // v.Indicator() => Term
+	names       ps.Map // v.Name => *Variable
+	args        ps.Map // compound term's address => *replacedArgs
+	occursCheck OccursCheck
+}
+
+// replacedArgs holds the arguments of a compound term after SetArg.
+// Holding the compound term itself keeps its address from being reused
+// by another term while these arguments apply.
+type replacedArgs struct {
+	compound *Compound
+	args     []Term
 }
 
 func (self *envMap) Bind(v *Variable, val Term) (Bindings, error) {
@@ -66,6 +111,13 @@ func (self *envMap) Bind(v *Variable, val Term) (Bindings, error) {
 	}
 
 	// at this point, we know that v is a free variable
+	if self.occursCheck != OccursCheckFalse && self.occurs(v, val) {
+		if self.occursCheck == OccursCheckError {
+			formal := NewCallable("occurs_check", v, val.ReplaceVariables(self))
+			panic(isoError(formal))
+		}
+		return self, CantUnify
+	}
 
 	// create a new environment with the binding in place
 	newEnv := self.clone()
@@ -96,6 +148,35 @@ func (self *envMap) Resolve(v *Variable) (Term, error) {
 		}
 	}
 }
+func (self *envMap) SetArg(c *Compound, i int, t Term) Bindings {
+	c = c.identity()
+	args := make([]Term, c.Arity())
+	old, _ := self.arguments(c)
+	copy(args, old)
+	args[i] = t
+
+	newEnv := self.clone()
+	newEnv.args = self.args.Set(argsKey(c), &replacedArgs{c, args})
+	return newEnv
+}
+
+// arguments returns the arguments of compound term c in this
+// environment.  The second return value is true if SetArg replaced any
+// of them.
+func (self *envMap) arguments(c *Compound) ([]Term, bool) {
+	if self.args.Size() > 0 {
+		if x, ok := self.args.Lookup(argsKey(c.identity())); ok {
+			return x.(*replacedArgs).args, true
+		}
+	}
+	return c.Args, false
+}
+
+// argsKey identifies a compound term whose arguments SetArg replaced
+func argsKey(c *Compound) string {
+	return fmt.Sprintf("%p", c)
+}
+
 func (self *envMap) Size() int {
 	return self.bindings.Size()
 }
@@ -107,6 +188,38 @@ func (self *envMap) Value(v *Variable) (Term, error) {
 	}
 	return value.(Term), nil
 }
+
+// occurs returns true if variable v appears in t, taking into account
+// variables which are already bound
+func (self *envMap) occurs(v *Variable, t Term) bool {
+	switch x := t.(type) {
+	case *Variable:
+		if x.Indicator() == v.Indicator() {
+			return true
+		}
+		if value, err := self.Value(x); err == nil {
+			return self.occurs(v, value)
+		}
+	case *Compound:
+		args, _ := self.arguments(x)
+		for _, arg := range args {
+			if self.occurs(v, arg) {
+				return true
+			}
+		}
+	case *Dict:
+		if self.occurs(v, x.Tag()) {
+			return true
+		}
+		for _, value := range x.Values() {
+			if self.occurs(v, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (self *envMap) clone() *envMap {
 	newEnv := *self
 	return &newEnv
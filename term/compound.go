@@ -52,6 +52,10 @@ type Compound struct {
 	Func   string
 	Args   []Term
 	ucache *unificationCache
+
+	// origin is the compound term which ReplaceVariables copied to
+	// create this one.  Bindings.SetArg treats them as the same term.
+	origin *Compound
 }
 type unificationCache struct {
 	// 0 means UnificationHash hasn't been calculated yet
@@ -68,8 +72,24 @@ func (self *Compound) Arity() int {
 func (self *Compound) Arguments() []Term {
 	return self.Args
 }
+
+// SetArg destructively replaces the i-th argument (counting from 0) of
+// this compound term and returns the argument it replaced.  Every
+// reference to this term sees the change, so only use it if you know
+// what you're doing.  It's the basis of nb_setarg/3.  Bindings.SetArg
+// makes a change which can be undone.
+func (self *Compound) SetArg(i int, t Term) Term {
+	old := self.Args[i]
+	args := make([]Term, len(self.Args)) // don't disturb shared slices
+	copy(args, self.Args)
+	args[i] = t
+	self.Args = args
+	self.ucache = &unificationCache{}
+	return old
+}
+
 func (self *Compound) String() string {
-	if IsString(self) {
+	if IsCodeList(self) {
 		return PrettyString(self)
 	}
 	if IsList(self) {
@@ -98,8 +118,35 @@ func (self *Compound) Indicator() string {
 	return Sprintf("%s/%d", self.Name(), self.Arity())
 }
 
+// identity returns the compound term which Bindings.SetArg changes
+// when it changes this one
+func (self *Compound) identity() *Compound {
+	if self.origin != nil {
+		return self.origin
+	}
+	return self
+}
+
+// argumentsIn returns this term's arguments in env.  They differ from
+// Arguments() if Bindings.SetArg replaced any of them.  The second
+// return value is true if it did.
+func (self *Compound) argumentsIn(env Bindings) ([]Term, bool) {
+	if e, ok := env.(*envMap); ok {
+		return e.arguments(self)
+	}
+	return self.Args, false
+}
+
+// copyWith returns a copy of this compound term with new arguments.
+// Bindings.SetArg treats the copy and the original as the same term.
+func (self *Compound) copyWith(args []Term) *Compound {
+	c := NewCallable(self.Name(), args...).(*Compound)
+	c.origin = self.identity()
+	return c
+}
+
 func (self *Compound) ReplaceVariables(env Bindings) Term {
-	args := self.Arguments()
+	args, replaced := self.argumentsIn(env)
 	for i, arg := range args {
 		newArg := arg.ReplaceVariables(env)
 		if arg != newArg { // argument changed. build a new compound term
@@ -115,12 +162,15 @@ func (self *Compound) ReplaceVariables(env Bindings) Term {
 					}
 				}
 			}
-			newTerm := NewCallable(self.Name(), newArgs...)
-			return newTerm
+			return self.copyWith(newArgs)
 		}
 	}
 
-	// no variables were replaced.  reuse the same compound term
+	// no variables were replaced.  reuse the same compound term unless
+	// SetArg replaced its arguments
+	if replaced {
+		return self.copyWith(args)
+	}
 	return self
 }
 
@@ -145,8 +195,8 @@ func (a *Compound) Unify(e Bindings, x Term) (Bindings, error) {
 	// try unifying each subterm
 	var err error
 	env := e
-	aArgs := a.Arguments()
-	bArgs := b.Arguments()
+	aArgs, _ := a.argumentsIn(e)
+	bArgs, _ := b.argumentsIn(e)
 	for i := 0; i < arity; i++ {
 		env, err = aArgs[i].Unify(env, bArgs[i])
 		if err != nil {
@@ -0,0 +1,234 @@
+package term
+
+import . "fmt"
+import "bytes"
+import "sort"
+import "github.com/mndrix/ps"
+
+// Dict is a SWI-Prolog style dictionary: a tag and a set of key-value
+// pairs written like Tag{k1: v1, k2: v2}.  Keys are atoms or small
+// integers.  The pairs are held in a persistent map so that adding or
+// removing a key shares structure with the original dict.
+type Dict struct {
+	tag     Term
+	entries ps.Map // dictKey(key) => *dictEntry
+}
+
+type dictEntry struct {
+	key   Term
+	value Term
+}
+
+// NewDict creates an empty dict with the given tag.  The tag is usually
+// an atom or an unbound variable.
+func NewDict(tag Term) *Dict {
+	return &Dict{tag: tag, entries: ps.NewMap()}
+}
+
+// Returns true if term t is a dict
+func IsDict(t Term) bool {
+	return t.Type() == DictType
+}
+
+// IsDictKey returns true if t may be used as a key in a dict.  Only
+// atoms and integers which fit in 64 bits qualify.
+func IsDictKey(t Term) bool {
+	switch x := t.(type) {
+	case *Atom:
+		return true
+	case *Integer:
+		return x.Value().IsInt64()
+	}
+	return false
+}
+
+// dictKey encodes a key term as a string for the underlying map
+func dictKey(key Term) string {
+	switch x := key.(type) {
+	case *Atom:
+		return "a" + x.Name()
+	case *Integer:
+		return "i" + x.Value().String()
+	}
+	panic(Sprintf("Invalid dict key: %s", key))
+}
+
+// Tag returns this dict's tag
+func (self *Dict) Tag() Term {
+	return self.tag
+}
+
+// WithTag returns a dict like this one but with a different tag
+func (self *Dict) WithTag(tag Term) *Dict {
+	return &Dict{tag: tag, entries: self.entries}
+}
+
+// Size returns the number of key-value pairs in this dict
+func (self *Dict) Size() int {
+	return self.entries.Size()
+}
+
+// Get returns the value associated with key, if there is one.
+func (self *Dict) Get(key Term) (Term, bool) {
+	if !IsDictKey(key) {
+		return nil, false
+	}
+	entry, ok := self.entries.Lookup(dictKey(key))
+	if !ok {
+		return nil, false
+	}
+	return entry.(*dictEntry).value, true
+}
+
+// Put returns a new dict in which key is associated with value.  Any
+// existing value for key is replaced.  Panics if key isn't a valid dict
+// key; see IsDictKey.
+func (self *Dict) Put(key, value Term) *Dict {
+	entries := self.entries.Set(dictKey(key), &dictEntry{key, value})
+	return &Dict{tag: self.tag, entries: entries}
+}
+
+// Delete returns a new dict without key.  If key isn't present, the
+// same dict is returned.
+func (self *Dict) Delete(key Term) *Dict {
+	if _, ok := self.Get(key); !ok {
+		return self
+	}
+	return &Dict{tag: self.tag, entries: self.entries.Delete(dictKey(key))}
+}
+
+// Keys returns this dict's keys in the standard order of terms
+func (self *Dict) Keys() []Term {
+	keys := make([]Term, 0, self.Size())
+	self.entries.ForEach(func(_ string, v interface{}) {
+		keys = append(keys, v.(*dictEntry).key)
+	})
+	sort.Slice(keys, func(i, j int) bool { return Precedes(keys[i], keys[j]) })
+	return keys
+}
+
+// Values returns this dict's values in the same order as Keys()
+func (self *Dict) Values() []Term {
+	keys := self.Keys()
+	values := make([]Term, len(keys))
+	for i, key := range keys {
+		values[i], _ = self.Get(key)
+	}
+	return values
+}
+
+func (self *Dict) String() string {
+	var buf bytes.Buffer
+	if IsVariable(self.tag) {
+		Fprintf(&buf, "_")
+	} else {
+		Fprintf(&buf, "%s", self.tag)
+	}
+	Fprintf(&buf, "{")
+	for i, key := range self.Keys() {
+		if i > 0 {
+			Fprintf(&buf, ", ")
+		}
+		value, _ := self.Get(key)
+		Fprintf(&buf, "%s:%s", key, value)
+	}
+	Fprintf(&buf, "}")
+	return buf.String()
+}
+
+func (self *Dict) Type() int {
+	return DictType
+}
+
+func (self *Dict) Indicator() string {
+	return Sprintf("dict/%d", self.Size())
+}
+
+func (self *Dict) ReplaceVariables(env Bindings) Term {
+	changed := false
+	tag := self.tag.ReplaceVariables(env)
+	if tag != self.tag {
+		changed = true
+	}
+	entries := self.entries
+	self.entries.ForEach(func(k string, v interface{}) {
+		entry := v.(*dictEntry)
+		value := entry.value.ReplaceVariables(env)
+		if value != entry.value {
+			changed = true
+			entries = entries.Set(k, &dictEntry{entry.key, value})
+		}
+	})
+
+	// no variables were replaced.  reuse the same dict
+	if !changed {
+		return self
+	}
+	return &Dict{tag: tag, entries: entries}
+}
+
+// Unify unifies two dicts if they have exactly the same keys.  Tags
+// and the values of each key are unified pairwise.
+func (a *Dict) Unify(e Bindings, x Term) (Bindings, error) {
+	if IsVariable(x) {
+		return x.Unify(e, a)
+	}
+	if !IsDict(x) {
+		return e, CantUnify
+	}
+	b := x.(*Dict)
+	if a.Size() != b.Size() {
+		return e, CantUnify
+	}
+
+	env, err := a.tag.Unify(e, b.tag)
+	if err != nil {
+		return e, err
+	}
+	for _, key := range a.Keys() {
+		bValue, ok := b.Get(key)
+		if !ok {
+			return e, CantUnify
+		}
+		aValue, _ := a.Get(key)
+		env, err = aValue.Unify(env, bValue)
+		if err != nil {
+			return e, err // return original environment along with error
+		}
+	}
+	return env, nil
+}
+
+// compareDicts orders dicts by size, then tag, then by their key-value
+// pairs in standard order of keys.  This matches SWI-Prolog.
+func (o StandardOrder) compareDicts(x, y *Dict) int {
+	if x.Size() != y.Size() {
+		return cmpInt(int64(x.Size()), int64(y.Size()))
+	}
+	if c := o.Compare(x.tag, y.tag); c != 0 {
+		return c
+	}
+	xKeys, yKeys := x.Keys(), y.Keys()
+	for i := range xKeys {
+		if c := Compare(xKeys[i], yKeys[i]); c != 0 {
+			return c
+		}
+		xValue, _ := x.Get(xKeys[i])
+		yValue, _ := y.Get(yKeys[i])
+		if c := o.Compare(xValue, yValue); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// mapDict returns a dict like x with f applied to its tag and each of
+// its values
+func mapDict(x *Dict, f func(Term) Term) *Dict {
+	d := NewDict(f(x.tag))
+	x.entries.ForEach(func(k string, v interface{}) {
+		entry := v.(*dictEntry)
+		d.entries = d.entries.Set(k, &dictEntry{entry.key, f(entry.value)})
+	})
+	return d
+}
@@ -0,0 +1,123 @@
+package term
+
+// Exception is a Go error value which carries a Prolog term (the "ball"
+// in ISO terminology).  Foreign predicates raise Prolog exceptions by
+// panicking with an *Exception.  The Golog machine catches those panics
+// and hands the ball to catch/3.
+type Exception struct {
+	ball        Term
+	wantContext bool // true if the machine should fill in the context
+}
+
+// NewException creates an exception which throws the given ball.
+func NewException(ball Term) *Exception {
+	return &Exception{ball: ball}
+}
+
+// Ball returns the term being thrown by this exception.
+func (self *Exception) Ball() Term {
+	return self.ball
+}
+
+func (self *Exception) Error() string {
+	return self.ball.String()
+}
+
+// WantsContext returns true if the machine should fill in the context
+// of this exception's error(Formal, Context) ball.  That's the case for
+// errors built by helpers like TypeError, but not for balls thrown by
+// throw/1 or NewException.
+func (self *Exception) WantsContext() bool {
+	return self.wantContext
+}
+
+// isoError builds error(Formal, _) per ISO §7.12.1.  The second argument
+// is left unbound so the machine can fill in some context.
+func isoError(formal Term) *Exception {
+	ball := NewCallable("error", formal, NewVar("_"))
+	return &Exception{ball: ball, wantContext: true}
+}
+
+// InstantiationError is raised when an argument, or one of its
+// components, is a variable.  See ISO §7.12.2(a)
+func InstantiationError() *Exception {
+	return isoError(NewAtom("instantiation_error"))
+}
+
+// TypeError is raised when an argument, or one of its components, has
+// the wrong type.  For example, TypeError("integer", x)
+// See ISO §7.12.2(b)
+func TypeError(typ string, culprit Term) *Exception {
+	return isoError(NewCallable("type_error", NewAtom(typ), culprit))
+}
+
+// DomainError is raised when an argument has the right type but its
+// value is outside the permitted domain.  See ISO §7.12.2(c)
+func DomainError(domain string, culprit Term) *Exception {
+	return isoError(NewCallable("domain_error", NewAtom(domain), culprit))
+}
+
+// ExistenceError is raised when an object on which an operation is to
+// be performed does not exist.  See ISO §7.12.2(d)
+func ExistenceError(kind string, culprit Term) *Exception {
+	return isoError(NewCallable("existence_error", NewAtom(kind), culprit))
+}
+
+// PermissionError is raised when an operation is not permitted on an
+// object.  See ISO §7.12.2(e)
+func PermissionError(action, kind string, culprit Term) *Exception {
+	formal := NewCallable("permission_error", NewAtom(action), NewAtom(kind), culprit)
+	return isoError(formal)
+}
+
+// RepresentationError is raised when an implementation defined limit
+// has been breached.  See ISO §7.12.2(f)
+func RepresentationError(limit string) *Exception {
+	return isoError(NewCallable("representation_error", NewAtom(limit)))
+}
+
+// EvaluationError is raised when an arithmetic function has no value
+// for the given arguments.  For example, EvaluationError("zero_divisor")
+// See ISO §7.12.2(g)
+func EvaluationError(e string) *Exception {
+	return isoError(NewCallable("evaluation_error", NewAtom(e)))
+}
+
+// ResourceError is raised when Prolog runs out of some resource.  For
+// example, ResourceError("memory")  See ISO §7.12.2(h)
+func ResourceError(resource string) *Exception {
+	return isoError(NewCallable("resource_error", NewAtom(resource)))
+}
+
+// SyntaxError is raised when a sequence of characters which is being
+// input as a read-term does not conform to the syntax.  For example,
+// SyntaxError("illegal_number")  See ISO §7.12.2(i)
+func SyntaxError(description string) *Exception {
+	return isoError(NewCallable("syntax_error", NewAtom(description)))
+}
+
+// UninstantiationError is raised when an argument, which should be a
+// variable for output, is bound.  See ISO/IEC 13211-1:1995/Cor.2:2012
+// §7.12.2(k)
+func UninstantiationError(culprit Term) *Exception {
+	return isoError(NewCallable("uninstantiation_error", culprit))
+}
+
+// IOError is raised when the operating system reports an error while
+// performing some action, like read or write, on a stream.  As defined
+// in SWI-Prolog
+func IOError(action string, stream Term) *Exception {
+	return isoError(NewCallable("io_error", NewAtom(action), stream))
+}
+
+// FormatError is raised when format/2 can't interpret its template or
+// arguments.
+func FormatError(message string) *Exception {
+	return isoError(NewCallable("format", NewAtom(message)))
+}
+
+// DuplicateKeyError is raised when a dict would contain the same key
+// twice.
+func DuplicateKeyError(key Term) *Exception {
+	return isoError(NewCallable("duplicate_key", key))
+}
@@ -1,7 +1,8 @@
 package term
 
-import "fmt"
+import "math"
 import "strconv"
+import "strings"
 import "math/big"
 
 type Float float64
@@ -9,7 +10,7 @@ type Float float64
 func NewFloat(text string) Number {
 	r, ok := NewRational(text)
 	if ok {
-		return r
+		return newFloatRat(r.Value())
 	}
 	f, err := strconv.ParseFloat(text, 64)
 	maybePanic(err)
@@ -24,8 +25,28 @@ func (self *Float) Value() float64 {
 	return float64(*self)
 }
 
+// String shows the shortest decimal representation which reads back as
+// the same float.  It always includes a decimal point, so the result
+// doesn't read back as an integer.
 func (self *Float) String() string {
-	return fmt.Sprintf("%g", self.Value())
+	f := self.Value()
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if strings.Contains(s, ".") {
+		return s
+	}
+	if i := strings.IndexByte(s, 'e'); i >= 0 {
+		return s[:i] + ".0" + s[i:]
+	}
+	return s + ".0"
 }
 func (self *Float) Type() int {
 	return FloatType
@@ -39,7 +60,7 @@ func (a *Float) Unify(e Bindings, b Term) (Bindings, error) {
 		return b.Unify(e, a)
 	}
 	if IsFloat(b) {
-		if a.Value() == b.(*Float).Value() {
+		if a.Value() == b.(Number).Float64() {
 			return e, nil
 		}
 	}
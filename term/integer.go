@@ -159,7 +159,11 @@ func (self *Integer) ReplaceVariables(env Bindings) Term {
 
 // implement Number interface
 func (self *Integer) Float64() float64 {
-	return float64(self.Value().Int64())
+	if self.Value().IsInt64() {
+		return float64(self.Value().Int64())
+	}
+	f, _ := new(big.Float).SetInt(self.Value()).Float64()
+	return f
 }
 
 func (self *Integer) LosslessInt() (*big.Int, bool) {
@@ -1,6 +1,6 @@
 package term
 
-import "fmt"
+import "math"
 import "math/big"
 import . "github.com/mndrix/golog/util"
 
@@ -34,14 +34,10 @@ func IsFloat(t Term) bool {
 	return t.Type() == FloatType
 }
 
-// Returns true if term t is a rational number
+// Returns true if term t is a rational number, like 1r3.  Integers and
+// Rationals which stand for floats aren't.
 func IsRational(t Term) bool {
-	switch t.(type) {
-	case *Rational:
-		return true
-	default:
-		return false
-	}
+	return t.Type() == RationalType
 }
 
 // Returns true if term is a number (integer, float, etc)
@@ -49,49 +45,100 @@ func IsNumber(t Term) bool {
 	return IsInteger(t) || IsFloat(t) || IsRational(t)
 }
 
+// ArithmeticFlags adjusts the way ArithmeticEvalWithFlags evaluates an
+// expression.  A machine derives these values from its Prolog flags.
+type ArithmeticFlags struct {
+	// PreferRationals makes /, ** and ^ produce an exact rational when
+	// integer arguments have no integer result.  Otherwise, those
+	// functions produce a float.
+	PreferRationals bool
+}
+
+// DefaultArithmeticFlags are the flags used by ArithmeticEval
+var DefaultArithmeticFlags = ArithmeticFlags{
+	PreferRationals: false,
+}
+
 // Evaluate an arithmetic expression to produce a number.  This is
-// conceptually similar to Prolog: X is Expression.  Returns false if
-// the expression cannot be evaluated (unbound variables, in)
+// conceptually similar to Prolog: X is Expression.  Returns an *Exception
+// error if the expression cannot be evaluated.  For example, an unbound
+// variable produces an instantiation_error and an unknown functor
+// produces type_error(evaluable, Name/Arity).  See ISO §9.1
 func ArithmeticEval(t0 Term) (Number, error) {
+	return ArithmeticEvalWithFlags(t0, DefaultArithmeticFlags)
+}
+
+// ArithmeticEvalWithFlags is like ArithmeticEval but evaluates the
+// expression according to the given flags.
+func ArithmeticEvalWithFlags(t0 Term, flags ArithmeticFlags) (Number, error) {
 	Debugf("arith eval: %s\n", t0)
 
 	// number terms require no additional evaluation
-	if IsNumber(t0) {
+	switch t0.Type() {
+	case VariableType:
+		return nil, InstantiationError()
+	case IntegerType, RationalType, FloatType:
 		return t0.(Number), nil
+	case StringType: // a one character string evaluates to its code
+		runes := []rune(t0.(*String).Text())
+		if len(runes) == 1 {
+			return NewCode(runes[0]), nil
+		}
+		return nil, TypeError("evaluable", t0)
+	case ErrorType, DictType:
+		return nil, TypeError("evaluable", t0)
 	}
-	var t Callable
-	if IsCallable(t0) {
-		t = t0.(Callable)
-	} else {
-		msg := fmt.Sprintf("Unexpected arithmetic term: %s", t0)
-		panic(msg)
-	}
+	t := t0.(Callable)
 
-	// evaluate arithmetic expressions
-	if t.Arity() == 2 {
-		// evaluate arguments recursively
+	// a single element list evaluates its element. See ISO §9.1.7
+	if t.Arity() == 2 && t.Name() == "." {
 		args := t.Arguments()
-		a, b, err := ArithmeticEval2(args[0], args[1])
-		if err != nil {
-			return nil, err
+		if IsEmptyList(args[1]) {
+			return ArithmeticEvalWithFlags(args[0], flags)
 		}
+	}
 
-		switch t.Name() {
-		case "*": // multiplication
-			return ArithmeticMultiply(a, b)
-		case "+": // addition
-			return ArithmeticAdd(a, b)
-		case "-": // subtraction
-			return ArithmeticMinus(a, b)
-		case "/": // division
-			return ArithmeticDivide(a, b)
+	// evaluate arithmetic expressions
+	switch t.Arity() {
+	case 0:
+		if f, ok := evaluable0[t.Name()]; ok {
+			return f()
+		}
+	case 1:
+		if f, ok := evaluable1[t.Name()]; ok {
+			a, err := ArithmeticEvalWithFlags(t.Arguments()[0], flags)
+			if err != nil {
+				return nil, err
+			}
+			return f(a)
+		}
+	case 2:
+		if f, ok := evaluable2[t.Name()]; ok {
+			args := t.Arguments()
+			a, err := ArithmeticEvalWithFlags(args[0], flags)
+			if err != nil {
+				return nil, err
+			}
+			b, err := ArithmeticEvalWithFlags(args[1], flags)
+			if err != nil {
+				return nil, err
+			}
+			n, err := f(a, b)
+			if err == nil && !flags.PreferRationals && yieldsRational[t.Name()] {
+				if IsInteger(a) && IsInteger(b) && IsRational(n) {
+					if t.Name() == "^" { // an integer power must be an integer
+						return nil, TypeError("float", a)
+					}
+					return ArithmeticFloat(n)
+				}
+			}
+			return n, err
 		}
-
 	}
 
 	// this term doesn't look like an expression
-	msg := fmt.Errorf("Not an expression: %s", t)
-	return nil, msg
+	indicator := NewCallable("/", NewAtom(t.Name()), NewInt64(int64(t.Arity())))
+	return nil, TypeError("evaluable", indicator)
 }
 
 func ArithmeticEval2(first, second Term) (Number, Number, error) {
@@ -111,9 +158,9 @@ func ArithmeticEval2(first, second Term) (Number, Number, error) {
 func ArithmeticAdd(a, b Number) (Number, error) {
 
 	// as integers?
-	if xi, ok := a.LosslessInt(); ok {
-		if yi, ok := b.LosslessInt(); ok {
-			r := new(big.Int).Add(xi, yi)
+	if x, ok := a.(*Integer); ok {
+		if y, ok := b.(*Integer); ok {
+			r := new(big.Int).Add(x.Value(), y.Value())
 			return NewBigInt(r), nil
 		}
 	}
@@ -122,24 +169,37 @@ func ArithmeticAdd(a, b Number) (Number, error) {
 	if xr, ok := a.LosslessRat(); ok {
 		if yr, ok := b.LosslessRat(); ok {
 			r := new(big.Rat).Add(xr, yr)
-			return NewBigRat(r), nil
+			return mixedRat(r, a, b)
 		}
 	}
 
 	// as floats?
-	r := a.Float64() + b.Float64()
-	return NewFloat64(r), nil
+	x, y := a.Float64(), b.Float64()
+	return floatResult(x+y, x, y)
+}
+
+// mixedRat returns the result r of arithmetic on a and b.  It stands for
+// a float if either a or b is a float.  Otherwise, it's exact.
+func mixedRat(r *big.Rat, a, b Number) (Number, error) {
+	if IsFloat(a) || IsFloat(b) {
+		return floatRatResult(r)
+	}
+	return ratResult(r), nil
 }
 
 // Divide two Golog numbers returning the result as a new Golog number.
 // The return value uses the most precise internal type possible.
+// Dividing by zero is an evaluation_error(zero_divisor).
 func ArithmeticDivide(a, b Number) (Number, error) {
+	if isZero(b) {
+		return nil, EvaluationError("zero_divisor")
+	}
 
 	// as integers?
-	if xi, ok := a.LosslessInt(); ok {
-		if yi, ok := b.LosslessInt(); ok {
-			r := new(big.Rat).SetFrac(xi, yi)
-			return NewBigRat(r), nil
+	if x, ok := a.(*Integer); ok {
+		if y, ok := b.(*Integer); ok {
+			r := new(big.Rat).SetFrac(x.Value(), y.Value())
+			return ratResult(r), nil
 		}
 	}
 
@@ -147,22 +207,22 @@ func ArithmeticDivide(a, b Number) (Number, error) {
 	if xr, ok := a.LosslessRat(); ok {
 		if yr, ok := b.LosslessRat(); ok {
 			r := new(big.Rat).Quo(xr, yr)
-			return NewBigRat(r), nil
+			return mixedRat(r, a, b)
 		}
 	}
 
 	// as floats?
-	r := a.Float64() / b.Float64()
-	return NewFloat64(r), nil
+	x, y := a.Float64(), b.Float64()
+	return floatResult(x/y, x, y)
 }
 
 // Subtract two Golog numbers returning the result as a new Golog number
 func ArithmeticMinus(a, b Number) (Number, error) {
 
 	// as integers?
-	if xi, ok := a.LosslessInt(); ok {
-		if yi, ok := b.LosslessInt(); ok {
-			r := new(big.Int).Sub(xi, yi)
+	if x, ok := a.(*Integer); ok {
+		if y, ok := b.(*Integer); ok {
+			r := new(big.Int).Sub(x.Value(), y.Value())
 			return NewBigInt(r), nil
 		}
 	}
@@ -171,22 +231,22 @@ func ArithmeticMinus(a, b Number) (Number, error) {
 	if xr, ok := a.LosslessRat(); ok {
 		if yr, ok := b.LosslessRat(); ok {
 			r := new(big.Rat).Sub(xr, yr)
-			return NewBigRat(r), nil
+			return mixedRat(r, a, b)
 		}
 	}
 
 	// as floats?
-	r := a.Float64() - b.Float64()
-	return NewFloat64(r), nil
+	x, y := a.Float64(), b.Float64()
+	return floatResult(x-y, x, y)
 }
 
 // Multiply two Golog numbers returning the result as a new Golog number
 func ArithmeticMultiply(a, b Number) (Number, error) {
 
 	// as integers?
-	if xi, ok := a.LosslessInt(); ok {
-		if yi, ok := b.LosslessInt(); ok {
-			r := new(big.Int).Mul(xi, yi)
+	if x, ok := a.(*Integer); ok {
+		if y, ok := b.(*Integer); ok {
+			r := new(big.Int).Mul(x.Value(), y.Value())
 			return NewBigInt(r), nil
 		}
 	}
@@ -195,13 +255,13 @@ func ArithmeticMultiply(a, b Number) (Number, error) {
 	if xr, ok := a.LosslessRat(); ok {
 		if yr, ok := b.LosslessRat(); ok {
 			r := new(big.Rat).Mul(xr, yr)
-			return NewBigRat(r), nil
+			return mixedRat(r, a, b)
 		}
 	}
 
 	// as floats?
-	r := a.Float64() * b.Float64()
-	return NewFloat64(r), nil
+	x, y := a.Float64(), b.Float64()
+	return floatResult(x*y, x, y)
 }
 
 // Compare two Golog numbers.  Returns
@@ -209,28 +269,69 @@ func ArithmeticMultiply(a, b Number) (Number, error) {
 //    -1 if a <  b
 //     0 if a == b
 //    +1 if a > b
+//
+// The comparison is exact.  Integers, rationals and floats are
+// compared by their mathematical values without rounding through
+// float64.  NaN is considered equal to itself and greater than every
+// other number, which gives a total order.
 func NumberCmp(a, b Number) int {
 	// compare as integers?
-	if xi, ok := a.LosslessInt(); ok {
-		if yi, ok := b.LosslessInt(); ok {
-			return xi.Cmp(yi)
+	if x, ok := a.(*Integer); ok {
+		if y, ok := b.(*Integer); ok {
+			return x.Value().Cmp(y.Value())
 		}
 	}
 
 	// compare as rationals?
-	if xr, ok := a.LosslessRat(); ok {
-		if yr, ok := b.LosslessRat(); ok {
-			return xr.Cmp(yr)
-		}
+	xr, xok := exactRat(a)
+	yr, yok := exactRat(b)
+	if xok && yok {
+		return xr.Cmp(yr)
 	}
 
-	// compare as floats?
-	diff := a.Float64() - b.Float64()
-	if diff < 0 {
+	// at least one side is NaN or infinite
+	x, y := a.Float64(), b.Float64()
+	switch {
+	case math.IsNaN(x) && math.IsNaN(y):
+		return 0
+	case math.IsNaN(x):
+		return 1
+	case math.IsNaN(y):
 		return -1
-	}
-	if diff > 0 {
+	case xok: // b is infinite
+		if y > 0 {
+			return -1
+		}
+		return 1
+	case yok: // a is infinite
+		if x > 0 {
+			return 1
+		}
+		return -1
+	case x < y:
+		return -1
+	case x > y:
 		return 1
 	}
 	return 0
 }
+
+// IsNaN returns true if n is a floating point NaN value
+func IsNaN(n Number) bool {
+	f, ok := n.(*Float)
+	return ok && math.IsNaN(f.Value())
+}
+
+// exactRat returns the exact rational value of a number.  Every
+// finite float has an exact rational value.  Infinite and NaN floats
+// do not.
+func exactRat(n Number) (*big.Rat, bool) {
+	if r, ok := n.LosslessRat(); ok {
+		return r, true
+	}
+	f := n.Float64()
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, false
+	}
+	return new(big.Rat).SetFloat64(f), true
+}
@@ -9,7 +9,9 @@ func IsEmptyList(t Term) bool {
 	return IsAtom(t) && t.(*Atom).Name() == "[]"
 }
 
-func IsString(t Term) bool {
+// IsCodeList returns true if t is a proper list of integers.  Such a
+// list is shown with double quotes.
+func IsCodeList(t Term) bool {
 	if IsEmptyList(t) {
 		return true
 	}
@@ -84,7 +86,11 @@ func PrettyString(t Term) string {
 	return "\"" + strings.Replace(RawString(t), "\"", "\\\"", -1) + "\""
 }
 
+// RawString returns the text of a packed string or a code list
 func RawString(t Term) string {
+	if IsString(t) {
+		return t.(*String).Text()
+	}
 	var chars []rune
 	for !IsEmptyList(t) {
 		c := t.(*Compound)
@@ -2,40 +2,112 @@ package term
 
 import "fmt"
 import "math/big"
+import "strings"
 
-// Rational is a specialized, internal representation of floats.
-// The goal is to facilitate more accurate numeric computations than
-// floats allow.  This isn't always possible, but it's a helpful optimization
-// in many practical circumstances.
-type Rational big.Rat
+// Rational is an exact rational number, like 1r3.  Golog also uses a
+// Rational as a specialized, internal representation of floats, like
+// the decimal literal 0.1.  The goal is to facilitate more accurate
+// numeric computations than floats allow.  This isn't always possible,
+// but it's a helpful optimization in many practical circumstances.  A
+// Rational which stands for a float has FloatType and arithmetic with
+// it produces floats.
+type Rational struct {
+	val   *big.Rat
+	float bool // stands for a float
+}
 
 // NewRational parses a rational's string representation to create a new
-// rational value. Panics if the string's is not a valid rational
+// rational value.  Accepts the formats of big.Rat.SetString plus Prolog's
+// 1r3 notation.  Returns false if the string is not a valid rational.
 func NewRational(text string) (*Rational, bool) {
+	text = strings.Replace(text, "r", "/", 1)
 	r, ok := new(big.Rat).SetString(text)
-	return (*Rational)(r), ok
+	return NewBigRat(r), ok
+}
+
+// NewRationalFromLexeme parses rational number syntax like 1r3.  A
+// rational with an integer value becomes an integer.  Returns false
+// for syntax like 1r0.
+// Unlikely to be useful outside of the parser
+func NewRationalFromLexeme(text string) (Number, bool) {
+	r, ok := NewRational(text)
+	if !ok {
+		return nil, false
+	}
+	return ratResult(r.Value()), true
 }
 
 // Constructs a new Rational value from a big.Rat value
 func NewBigRat(r *big.Rat) *Rational {
-	return (*Rational)(r)
+	return &Rational{val: r}
+}
+
+// newFloatRat returns a Rational which stands for a float with r's
+// exact value
+func newFloatRat(r *big.Rat) *Rational {
+	return &Rational{val: r, float: true}
 }
 
 func (self *Rational) Value() *big.Rat {
-	return (*big.Rat)(self)
+	return self.val
 }
 
+// String shows a rational's exact value, like 1r3, which can be read
+// back in.  One which stands for a float is written in decimal notation
+// when it has a finite decimal expansion, like 0.25, and an integral
+// value is written like 1.0 so that it isn't read back as an integer.
+// Others are written like the nearest float.
 func (self *Rational) String() string {
 	val := self.Value()
+	if !self.float {
+		if val.IsInt() {
+			return val.RatString()
+		}
+		return fmt.Sprintf("%sr%s", val.Num(), val.Denom())
+	}
 	if val.IsInt() {
-		return val.RatString()
+		return val.RatString() + ".0"
+	}
+	if places, ok := decimalPlaces(val); ok {
+		return val.FloatString(places)
+	}
+	return NewFloat64(self.Float64()).String()
+}
+
+// decimalPlaces returns the number of digits needed after the decimal
+// point to write r exactly.  Returns false if r has no finite decimal
+// expansion.
+func decimalPlaces(r *big.Rat) (int, bool) {
+	d := new(big.Int).Set(r.Denom())
+	twos, fives := 0, 0
+	five := big.NewInt(5)
+	m := new(big.Int)
+	for d.Bit(0) == 0 {
+		d.Rsh(d, 1)
+		twos++
+	}
+	for {
+		q, _ := new(big.Int).QuoRem(d, five, m)
+		if m.Sign() != 0 {
+			break
+		}
+		d = q
+		fives++
+	}
+	if d.Cmp(big.NewInt(1)) != 0 {
+		return 0, false
+	}
+	if twos > fives {
+		return twos, true
 	}
-	f, _ := val.Float64()
-	return fmt.Sprintf("%g", f)
+	return fives, true
 }
 
 func (self *Rational) Type() int {
-	return FloatType
+	if self.float {
+		return FloatType
+	}
+	return RationalType
 }
 
 func (self *Rational) Indicator() string {
@@ -46,12 +118,15 @@ func (a *Rational) Unify(e Bindings, b Term) (Bindings, error) {
 	if IsVariable(b) {
 		return b.Unify(e, a)
 	}
-	if IsRational(b) {
-		if a.Value().Cmp(b.(*Rational).Value()) == 0 {
+	if y, ok := b.(*Rational); ok {
+		if a.float == y.float && a.Value().Cmp(y.Value()) == 0 {
 			return e, nil
 		}
 		return e, CantUnify
 	}
+	if !a.float {
+		return e, CantUnify
+	}
 
 	x := a.Value()
 	switch b.Type() {
@@ -93,3 +168,76 @@ func (self *Rational) LosslessInt() (*big.Int, bool) {
 func (self *Rational) LosslessRat() (*big.Rat, bool) {
 	return self.Value(), true
 }
+
+// RoundingMode determines which way RoundRational goes when a value
+// falls between two candidates.
+type RoundingMode int
+
+const (
+	RoundHalfUp   RoundingMode = iota // nearest; ties away from zero
+	RoundHalfEven                     // nearest; ties toward an even digit
+	RoundHalfDown                     // nearest; ties toward zero
+	RoundUp                           // away from zero
+	RoundDown                         // toward zero
+	RoundCeiling                      // toward positive infinity
+	RoundFloor                        // toward negative infinity
+)
+
+var roundingModes = map[string]RoundingMode{
+	"half_up":   RoundHalfUp,
+	"half_even": RoundHalfEven,
+	"half_down": RoundHalfDown,
+	"up":        RoundUp,
+	"down":      RoundDown,
+	"ceiling":   RoundCeiling,
+	"floor":     RoundFloor,
+}
+
+// NewRoundingMode returns the rounding mode with the given name: half_up,
+// half_even, half_down, up, down, ceiling or floor.  Returns false for
+// any other name.
+func NewRoundingMode(name string) (RoundingMode, bool) {
+	mode, ok := roundingModes[name]
+	return mode, ok
+}
+
+// RoundRational rounds r to the given number of decimal places.  The
+// result is exact, so it's suitable for things like currency.
+func RoundRational(r *big.Rat, places int, mode RoundingMode) *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil)
+	x := new(big.Rat).Mul(r, new(big.Rat).SetInt(scale))
+
+	// truncate toward zero then decide whether to move away from zero
+	q, rem := new(big.Int).QuoRem(x.Num(), x.Denom(), new(big.Int))
+	half := new(big.Int).Abs(rem)
+	half.Lsh(half, 1)
+	cmp := half.Cmp(x.Denom()) // compare remainder with one half
+	var away bool
+	switch mode {
+	case RoundHalfUp:
+		away = cmp >= 0
+	case RoundHalfEven:
+		away = cmp > 0 || (cmp == 0 && q.Bit(0) == 1)
+	case RoundHalfDown:
+		away = cmp > 0
+	case RoundUp:
+		away = rem.Sign() != 0
+	case RoundDown:
+		away = false
+	case RoundCeiling:
+		away = rem.Sign() > 0
+	case RoundFloor:
+		away = rem.Sign() < 0
+	}
+	if away {
+		q.Add(q, big.NewInt(int64(x.Sign())))
+	}
+	return new(big.Rat).SetFrac(q, scale)
+}
+
+// FormatDecimal writes r with exactly the given number of decimal places,
+// rounding according to mode.  For example, 1r8 with 2 places and
+// RoundHalfEven is "0.12".
+func FormatDecimal(r *big.Rat, places int, mode RoundingMode) string {
+	return RoundRational(r, places, mode).FloatString(places)
+}
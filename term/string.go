@@ -0,0 +1,55 @@
+package term
+
+import . "fmt"
+
+// String is a packed sequence of characters.  Unlike a code list, it
+// occupies a single term no matter how long it is.  Double quoted text
+// is read as a String when the double_quotes flag is 'string'.
+type String string
+
+// NewString creates a new string term with the given text
+func NewString(text string) *String {
+	return (*String)(&text)
+}
+
+// Unlikely to be useful outside of the parser
+func NewStringFromLexeme(doubleQuoted string) *String {
+	return NewString(doubleQuotedText(doubleQuoted))
+}
+
+// Returns true if term t is a packed string
+func IsString(t Term) bool {
+	return t.Type() == StringType
+}
+
+// Text returns the characters of this string
+func (self *String) Text() string {
+	return string(*self)
+}
+func (self *String) String() string {
+	return PrettyString(self)
+}
+func (self *String) Type() int {
+	return StringType
+}
+func (self *String) Indicator() string {
+	return Sprintf("%q", self.Text())
+}
+
+func (self *String) ReplaceVariables(env Bindings) Term {
+	return self
+}
+
+func (a *String) Unify(e Bindings, b Term) (Bindings, error) {
+	switch t := b.(type) {
+	case *Variable:
+		return b.Unify(e, a)
+	case *String:
+		if *a == *t {
+			return e, nil
+		}
+		return e, CantUnify
+	default:
+		return e, CantUnify
+	}
+}
@@ -8,6 +8,7 @@ package term
 
 import . "fmt"
 import . "regexp"
+import "bytes"
 import "math/big"
 import "math"
 import "strconv"
@@ -22,9 +23,12 @@ var CantUnify error = Errorf("Can't unify the given terms")
 const (
 	VariableType = iota
 	FloatType
+	RationalType
 	IntegerType
 	AtomType
+	StringType
 	CompoundType
+	DictType
 
 	// odd man out
 	ErrorType
@@ -88,9 +92,12 @@ func IsClause(t Term) bool {
 		x := t.(*Compound)
 		return x.Arity() == 2 && x.Name() == ":-"
 	case AtomType,
+		StringType,
 		VariableType,
 		IntegerType,
 		FloatType,
+		RationalType,
+		DictType,
 		ErrorType:
 		return false
 	}
@@ -109,6 +116,11 @@ func IsCallable(t Term) bool {
 	return tp == AtomType || tp == CompoundType
 }
 
+// Returns true if term t is an atom, a string or a number.
+func IsAtomic(t Term) bool {
+	return IsAtom(t) || IsString(t) || IsNumber(t)
+}
+
 // Returns true if term t is a variable.
 func IsVariable(t Term) bool {
 	return t.Type() == VariableType
@@ -144,8 +156,10 @@ func RenameVariables(t Term) Term {
 func renameVariables(t Term, renamed map[string]*Variable) Term {
 	switch t.Type() {
 	case FloatType,
+		RationalType,
 		IntegerType,
 		AtomType,
+		StringType,
 		ErrorType:
 		return t
 	case CompoundType:
@@ -157,6 +171,9 @@ func renameVariables(t Term, renamed map[string]*Variable) Term {
 		newTerm := NewCallable(x.Name(), newArgs...)
 		newTerm.(*Compound).ucache = x.ucache
 		return newTerm
+	case DictType:
+		x := t.(*Dict)
+		return mapDict(x, func(t Term) Term { return renameVariables(t, renamed) })
 	case VariableType:
 		x := t.(*Variable)
 		name := x.Name
@@ -175,13 +192,176 @@ func renameVariables(t Term, renamed map[string]*Variable) Term {
 	panic("Unexpected term type")
 }
 
+// CopyTerm returns a copy of t in which each distinct variable has been
+// replaced by a fresh one.  Unlike RenameVariables, variables are
+// distinguished by identity, not by name, so t may contain variables
+// from different clauses.  See ISO §8.5.4
+func CopyTerm(t Term) Term {
+	renamed := make(map[string]*Variable)
+	return copyTerm(t, renamed)
+}
+
+func copyTerm(t Term, renamed map[string]*Variable) Term {
+	switch t.Type() {
+	case CompoundType:
+		x := t.(*Compound)
+		newArgs := make([]Term, x.Arity())
+		for i, arg := range x.Arguments() {
+			newArgs[i] = copyTerm(arg, renamed)
+		}
+		return NewCallable(x.Name(), newArgs...)
+	case DictType:
+		x := t.(*Dict)
+		return mapDict(x, func(t Term) Term { return copyTerm(t, renamed) })
+	case VariableType:
+		x := t.(*Variable)
+		v, ok := renamed[x.Indicator()]
+		if !ok {
+			v = x.WithNewId()
+			renamed[x.Indicator()] = v
+		}
+		return v
+	}
+	return t
+}
+
+// TermVariables returns each distinct variable in t, in depth-first,
+// left-to-right order.  See ISO §8.5.5
+func TermVariables(t Term) []*Variable {
+	seen := make(map[string]bool)
+	return termVariables(t, seen, nil)
+}
+
+func termVariables(t Term, seen map[string]bool, vars []*Variable) []*Variable {
+	switch t.Type() {
+	case CompoundType:
+		for _, arg := range t.(*Compound).Arguments() {
+			vars = termVariables(arg, seen, vars)
+		}
+	case DictType:
+		x := t.(*Dict)
+		vars = termVariables(x.Tag(), seen, vars)
+		for _, value := range x.Values() {
+			vars = termVariables(value, seen, vars)
+		}
+	case VariableType:
+		x := t.(*Variable)
+		if !seen[x.Indicator()] {
+			seen[x.Indicator()] = true
+			vars = append(vars, x)
+		}
+	}
+	return vars
+}
+
+// IsVariant returns true if a and b are identical except for a one to
+// one renaming of their variables.  See ISO §7.1.6.1
+func IsVariant(a, b Term) bool {
+	return isVariant(a, b, make(map[string]string), make(map[string]string))
+}
+
+func isVariant(a, b Term, ab, ba map[string]string) bool {
+	if a.Type() != b.Type() {
+		return false
+	}
+	switch a.Type() {
+	case VariableType:
+		x, y := a.Indicator(), b.Indicator()
+		if bx, ok := ab[x]; ok {
+			return bx == y
+		}
+		if _, ok := ba[y]; ok {
+			return false
+		}
+		ab[x], ba[y] = y, x
+		return true
+	case CompoundType:
+		x := a.(*Compound)
+		y := b.(*Compound)
+		if x.Name() != y.Name() || x.Arity() != y.Arity() {
+			return false
+		}
+		for i, arg := range x.Arguments() {
+			if !isVariant(arg, y.Arguments()[i], ab, ba) {
+				return false
+			}
+		}
+		return true
+	case DictType:
+		x := a.(*Dict)
+		y := b.(*Dict)
+		if x.Size() != y.Size() || !isVariant(x.Tag(), y.Tag(), ab, ba) {
+			return false
+		}
+		for _, key := range x.Keys() {
+			yValue, ok := y.Get(key)
+			if !ok {
+				return false
+			}
+			xValue, _ := x.Get(key)
+			if !isVariant(xValue, yValue, ab, ba) {
+				return false
+			}
+		}
+		return true
+	}
+	return Compare(a, b) == 0
+}
+
+// VariantKey returns a string which is the same for any two terms
+// which are variants of each other.  Terms which aren't variants
+// usually have different keys, so it's useful for hashing terms before
+// comparing them with IsVariant.
+func VariantKey(t Term) string {
+	var b bytes.Buffer
+	variantKey(&b, t, make(map[string]int))
+	return b.String()
+}
+
+func variantKey(b *bytes.Buffer, t Term, vars map[string]int) {
+	switch t.Type() {
+	case VariableType:
+		n, ok := vars[t.Indicator()]
+		if !ok {
+			n = len(vars)
+			vars[t.Indicator()] = n
+		}
+		Fprintf(b, "_%d", n)
+	case CompoundType:
+		x := t.(*Compound)
+		Fprintf(b, "%q(", x.Name())
+		for i, arg := range x.Arguments() {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			variantKey(b, arg, vars)
+		}
+		b.WriteByte(')')
+	case DictType:
+		x := t.(*Dict)
+		variantKey(b, x.Tag(), vars)
+		b.WriteByte('{')
+		for _, key := range x.Keys() {
+			value, _ := x.Get(key)
+			Fprintf(b, "%s:", key)
+			variantKey(b, value, vars)
+			b.WriteByte(',')
+		}
+		b.WriteByte('}')
+	default:
+		Fprintf(b, "%d:%s", t.Type(), t)
+	}
+}
+
 // Variables returns a ps.Map whose keys are human-readable variable names
 // and those values are *Variable used inside term t.
 func Variables(t Term) ps.Map {
 	names := ps.NewMap()
 	switch t.Type() {
 	case AtomType,
+		StringType,
 		FloatType,
+		RationalType,
 		IntegerType,
 		ErrorType:
 		return names
@@ -197,6 +377,15 @@ func Variables(t Term) ps.Map {
 			})
 		}
 		return names
+	case DictType:
+		x := t.(*Dict)
+		for _, inner := range append([]Term{x.Tag()}, x.Values()...) {
+			innerNames := Variables(inner)
+			innerNames.ForEach(func(key string, val interface{}) {
+				names = names.Set(key, val)
+			})
+		}
+		return names
 	case VariableType:
 		x := t.(*Variable)
 		return names.Set(x.Name, x)
@@ -240,34 +429,48 @@ func QuoteFunctor(name string) string {
 // Nominally, the resulting term is just a chain of cons cells ('.'/2),
 // but it might actually be a more efficient implementation under the hood.
 func NewCodeListFromDoubleQuotedString(s string) Term {
+	return NewCodeList(doubleQuotedText(s))
+}
+
+// doubleQuotedText returns the characters between a string's
+// bracketing double quotes
+func doubleQuotedText(s string) string {
 	// make sure the content is long enough
 	runes := []rune(s)
-	end := len(runes) - 2
-	if end < 0 {
+	if len(runes) < 2 {
 		msg := Sprintf("Code list string must have bracketing double quotes: %s", s)
 		panic(msg)
 	}
-
-	// build a cons cell chain, starting at the end ([])
-	codes := NewAtom(`[]`)
-	for i := end; i > 0; i-- {
-		c := NewCode(runes[i])
-		codes = NewCallable(".", c, codes)
-	}
-
-	return codes
+	return string(runes[1 : len(runes)-1])
 }
 
 // Precedes returns true if the first argument 'term-precedes'
 // the second argument according to ISO §7.2
 func Precedes(a, b Term) bool {
-	aP := precedence(a)
-	bP := precedence(b)
-	if aP < bP {
-		return true
-	}
-	if aP > bP {
-		return false
+	return Compare(a, b) < 0
+}
+
+// Compare returns -1, 0 or 1 depending on whether a precedes, is
+// identical to or follows b in the standard order of terms.  See ISO §7.2
+func Compare(a, b Term) int {
+	return StandardOrder{}.Compare(a, b)
+}
+
+// StandardOrder describes a variant of the standard order of terms.
+// The zero value is Golog's default order.
+type StandardOrder struct {
+	// IsoNumbers puts all floats before all integers, as ISO §7.2
+	// requires, instead of comparing numbers by value.  See Note_1
+	IsoNumbers bool
+}
+
+// Compare is like the Compare function but uses this variant of the
+// standard order.
+func (o StandardOrder) Compare(a, b Term) int {
+	aP := o.precedence(a)
+	bP := o.precedence(b)
+	if aP != bP {
+		return cmpInt(int64(aP), int64(bP))
 	}
 
 	// both terms have the same precedence by type, so delve deeper
@@ -275,65 +478,85 @@ func Precedes(a, b Term) bool {
 	case VariableType:
 		x := a.(*Variable)
 		y := b.(*Variable)
-		return x.Id() < y.Id()
+		return cmpInt(x.Id(), y.Id())
 	case FloatType,
+		RationalType,
 		IntegerType: // See Note_1
-
-		// comparing via float64 breaks in many, many ways.
-		// improve as necessary.
-		x := a.(Number).Float64()
-		y := b.(Number).Float64()
-		if x == y && IsFloat(a) && !IsFloat(b) {
-			return true
+		if c := NumberCmp(a.(Number), b.(Number)); c != 0 {
+			return c
 		}
-		return x < y
+		// equal values are ordered by representation
+		return cmpInt(numberRank(a), numberRank(b))
 	case AtomType:
 		x := a.(*Atom)
 		y := b.(*Atom)
-		return x.Name() < y.Name()
+		return strings.Compare(x.Name(), y.Name())
+	case StringType:
+		x := a.(*String)
+		y := b.(*String)
+		return strings.Compare(x.Text(), y.Text())
 	case CompoundType:
 		x := a.(*Compound)
 		y := b.(*Compound)
-		if x.Arity() < y.Arity() {
-			return true
-		}
-		if x.Arity() > y.Arity() {
-			return false
+		if x.Arity() != y.Arity() {
+			return cmpInt(int64(x.Arity()), int64(y.Arity()))
 		}
-		if x.Name() < y.Name() {
-			return true
-		}
-		if x.Name() > y.Name() {
-			return false
+		if c := strings.Compare(x.Name(), y.Name()); c != 0 {
+			return c
 		}
 		for i := 0; i < x.Arity(); i++ {
-			if Precedes(x.Arguments()[i], y.Arguments()[i]) {
-				return true
-			} else if Precedes(y.Arguments()[i], x.Arguments()[i]) {
-				return false
+			if c := o.Compare(x.Arguments()[i], y.Arguments()[i]); c != 0 {
+				return c
 			}
 		}
-		return false // identical terms
+		return 0 // identical terms
+	case DictType:
+		return o.compareDicts(a.(*Dict), b.(*Dict))
 	}
 
 	msg := Sprintf("Unexpected term type %s\n", a)
 	panic(msg)
 }
-func precedence(t Term) int {
-	value := t.Type()       // Type() promises values in precedence order
-	if value == FloatType { // See Note_1
+func (o StandardOrder) precedence(t Term) int {
+	// Type() promises values in precedence order.  See Note_1
+	value := t.Type()
+	if value == RationalType || (value == FloatType && !o.IsoNumbers) {
 		return IntegerType
 	}
 	return value
 }
 
+// numberRank orders numbers which have the same value: floats, then
+// rationals, then integers
+func numberRank(n Term) int64 {
+	switch n.Type() {
+	case FloatType:
+		return 0
+	case RationalType:
+		return 1
+	}
+	return 2
+}
+
+func cmpInt(a, b int64) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
 // Note_1:
 //
 // I've chosen to willfully violate the ISO standard in §7.2 because it
 // mandates that floats precede all integers.  That means
 // `42.3 @< 9` which isn't helpful.  I don't deviate lightly, but strongly
-// believe it's the right way.
-// Incidentally, SWI-Prolog behaves this way by default.
+// believe it's the right way.  Numbers are compared by their exact value.
+// When a float and an integer have the same value, the float comes first.
+// Incidentally, SWI-Prolog behaves this way by default.  Those who need the
+// standard's order can set the number_order flag to iso.
 
 // UnificationHash generates a special hash value representing the
 // terms in a slice.  Golog uses these hashes to optimize
@@ -382,6 +605,8 @@ func UnificationHash(terms []Term, n uint, preparation bool) uint64 {
 		switch t := term.(type) {
 		case *Atom:
 			hash = hash | (hashString(t.Name()) & mask)
+		case *String:
+			hash = hash | (hashString(t.Text()) & mask)
 		case *Integer:
 			if t.Value().Sign() < 0 || t.Value().Cmp(bigMaxInt64) > 0 {
 				str := Sprintf("%x", t.Value())
@@ -426,7 +651,7 @@ func UnificationHash(terms []Term, n uint, preparation bool) uint64 {
 				}
 			}
 			hash |= (termHash & mask)
-		case *Variable:
+		case *Variable, *Dict: // dicts unify regardless of key order
 			if preparation {
 				hash = hash | mask
 			}
@@ -1,6 +1,8 @@
 package term
 
 import . "regexp"
+import "math"
+import "math/big"
 import "testing"
 
 func TestAtom(t *testing.T) {
@@ -156,3 +158,278 @@ func TestFloat(t *testing.T) {
 		}
 	}
 }
+
+func TestFloatString(t *testing.T) {
+	tests := map[float64]string{
+		0.1:     "0.1",
+		2.0:     "2.0",
+		-3.5:    "-3.5",
+		1e100:   "1.0e+100",
+		1.5e-10: "1.5e-10",
+	}
+	for f, expected := range tests {
+		got := NewFloat64(f).String()
+		if got != expected {
+			t.Errorf("Float %g shown as `%s` wanted `%s`", f, got, expected)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	s := NewString("hello")
+	if !IsString(s) || !IsAtomic(s) {
+		t.Errorf("String isn't a string")
+	}
+	if IsString(NewCodeList("hello")) {
+		t.Errorf("Code list shouldn't be a string")
+	}
+	if s.String() != `"hello"` {
+		t.Errorf("String shown as %s", s)
+	}
+	if _, err := s.Unify(NewBindings(), NewString("hello")); err != nil {
+		t.Errorf("Identical strings don't unify")
+	}
+	if _, err := s.Unify(NewBindings(), NewAtom("hello")); err == nil {
+		t.Errorf("String unified with an atom")
+	}
+	if !Precedes(NewAtom("z"), s) || !Precedes(s, NewCallable("f", s)) {
+		t.Errorf("Strings belong between atoms and compound terms")
+	}
+}
+
+func TestNumberCmp(t *testing.T) {
+	big := NewInt(`9007199254740993`) // 2**53 + 1
+	tests := []struct {
+		a, b Number
+		want int
+	}{
+		{NewInt64(1), NewInt64(2), -1},
+		{big, NewFloat64(9007199254740992), 1},
+		{NewFloat64(9007199254740992), big, -1},
+		{NewFloat("0.1"), NewFloat64(0.1), -1}, // 0.1 as float64 is larger
+		{NewFloat64(1.0), NewInt64(1), 0},
+		{NewFloat64(math.Inf(1)), big, 1},
+		{NewFloat64(math.NaN()), NewFloat64(math.Inf(1)), 1},
+	}
+	for _, test := range tests {
+		if got := NumberCmp(test.a, test.b); got != test.want {
+			t.Errorf("NumberCmp(%s, %s) = %d, wanted %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestRational(t *testing.T) {
+	tests := map[string]string{
+		"1/3":  "1r3",
+		"-2/3": "-2r3",
+		"1/4":  "1r4",
+		"6/3":  "2",
+		"22r7": "22r7",
+	}
+	for text, wanted := range tests {
+		r, ok := NewRational(text)
+		if !ok {
+			t.Errorf("Can't parse rational %s", text)
+			continue
+		}
+		if got := r.String(); got != wanted {
+			t.Errorf("Rational %s printed as %s, wanted %s", text, got, wanted)
+		}
+	}
+
+	// rationals which stand for floats
+	floats := map[string]string{
+		"0.1":          "0.1",
+		"-2.5":         "-2.5",
+		"0.0009765625": "0.0009765625",
+		"2.0":          "2.0",
+		"1/3":          "0.3333333333333333",
+	}
+	for text, wanted := range floats {
+		f := NewFloat(text)
+		if !IsFloat(f) || IsRational(f) {
+			t.Errorf("Float %s has the wrong type", text)
+		}
+		if got := f.String(); got != wanted {
+			t.Errorf("Float %s printed as %s, wanted %s", text, got, wanted)
+		}
+	}
+}
+
+func TestRoundRational(t *testing.T) {
+	tests := []struct {
+		x      string
+		places int
+		mode   RoundingMode
+		want   string
+	}{
+		{"1/8", 2, RoundHalfUp, "0.13"},
+		{"1/8", 2, RoundHalfEven, "0.12"},
+		{"3/8", 2, RoundHalfEven, "0.38"},
+		{"1/8", 2, RoundHalfDown, "0.12"},
+		{"-1/8", 2, RoundHalfUp, "-0.13"},
+		{"1/3", 2, RoundUp, "0.34"},
+		{"-1/3", 2, RoundUp, "-0.34"},
+		{"2/3", 2, RoundDown, "0.66"},
+		{"-1/3", 2, RoundCeiling, "-0.33"},
+		{"-1/3", 2, RoundFloor, "-0.34"},
+		{"1/2", 2, RoundHalfUp, "0.50"},
+		{"5/2", 0, RoundHalfEven, "2"},
+		{"7", 1, RoundHalfUp, "7.0"},
+	}
+	for _, test := range tests {
+		r, _ := new(big.Rat).SetString(test.x)
+		got := FormatDecimal(r, test.places, test.mode)
+		if got != test.want {
+			t.Errorf("FormatDecimal(%s, %d, %d) = %s, wanted %s", test.x, test.places, test.mode, got, test.want)
+		}
+	}
+}
+
+func TestRationalize(t *testing.T) {
+	tests := map[float64]string{
+		0.1:       "1r10",
+		1.0 / 3.0: "1r3",
+		-0.75:     "-3r4",
+		math.Pi:   "245850922r78256779",
+		2:         "2",
+	}
+	for f, wanted := range tests {
+		n, err := ArithmeticRationalize(NewFloat64(f))
+		if err != nil {
+			t.Errorf("rationalize(%g) failed: %s", f, err)
+			continue
+		}
+		if got := n.String(); got != wanted {
+			t.Errorf("rationalize(%g) = %s, wanted %s", f, got, wanted)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b Term
+		want int
+	}{
+		{NewVar("X"), NewInt64(1), -1},
+		{NewInt(`9007199254740993`), NewInt(`9007199254740992`), 1},
+		{NewFloat64(1), NewInt64(1), -1},
+		{NewFloat("1.0"), NewInt64(1), -1},
+		{NewInt64(2), NewAtom("a"), -1},
+		{NewAtom("b"), NewAtom("a"), 1},
+		{NewCallable("z", NewAtom("a")), NewCallable("a", NewAtom("a"), NewAtom("a")), -1},
+		{NewCallable("f", NewInt64(1)), NewCallable("f", NewInt64(1)), 0},
+	}
+	for _, test := range tests {
+		if got := Compare(test.a, test.b); got != test.want {
+			t.Errorf("Compare(%s, %s) = %d, wanted %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestCopyTerm(t *testing.T) {
+	// two distinct variables which share a name
+	x1 := NewVar("X").WithNewId()
+	x2 := NewVar("X").WithNewId()
+	original := NewCallable("f", x1, x2, x1)
+
+	c := CopyTerm(original).(*Compound)
+	args := c.Arguments()
+	if args[0] != args[2] {
+		t.Errorf("copy should share variables: %s", c)
+	}
+	if args[0] == args[1] {
+		t.Errorf("copy should keep distinct variables distinct: %s", c)
+	}
+	if args[0] == x1 {
+		t.Errorf("copy should have fresh variables: %s", c)
+	}
+
+	vars := TermVariables(original)
+	if len(vars) != 2 || vars[0] != x1 || vars[1] != x2 {
+		t.Errorf("wrong term variables: %v", vars)
+	}
+}
+
+func TestIsVariant(t *testing.T) {
+	x := NewVar("X").WithNewId()
+	y := NewVar("Y").WithNewId()
+	z := NewVar("Z").WithNewId()
+	variants := [][2]Term{
+		{NewCallable("f", x, y, x), NewCallable("f", y, z, y)},
+		{NewCallable("g", x), NewCallable("g", y)},
+		{NewAtom("a"), NewAtom("a")},
+	}
+	for _, pair := range variants {
+		if !IsVariant(pair[0], pair[1]) {
+			t.Errorf("%s and %s should be variants", pair[0], pair[1])
+		}
+		if VariantKey(pair[0]) != VariantKey(pair[1]) {
+			t.Errorf("%s and %s should have the same variant key", pair[0], pair[1])
+		}
+	}
+
+	different := [][2]Term{
+		{NewCallable("f", x, x), NewCallable("f", y, z)},
+		{NewCallable("f", x, y), NewCallable("f", z, z)},
+		{NewCallable("f", x), NewCallable("f", NewAtom("a"))},
+		{NewInt64(1), NewFloat64(1)},
+	}
+	for _, pair := range different {
+		if IsVariant(pair[0], pair[1]) {
+			t.Errorf("%s and %s shouldn't be variants", pair[0], pair[1])
+		}
+		if VariantKey(pair[0]) == VariantKey(pair[1]) {
+			t.Errorf("%s and %s shouldn't have the same variant key", pair[0], pair[1])
+		}
+	}
+}
+
+func TestDict(t *testing.T) {
+	x := NewVar("X").WithNewId()
+	d := NewDict(NewAtom("point")).Put(NewAtom("y"), NewInt64(2)).Put(NewAtom("x"), x)
+	if !IsDict(d) || d.Size() != 2 {
+		t.Errorf("Dict isn't a dict with 2 pairs: %s", d)
+	}
+	if d.String() != "point{x:X, y:2}" {
+		t.Errorf("Dict shown as %s", d)
+	}
+	if d.Delete(NewAtom("x")).Size() != 1 || d.Size() != 2 {
+		t.Errorf("Delete should leave the original dict alone")
+	}
+
+	// unification compares keysets, not insertion order
+	other := NewDict(NewAtom("point")).Put(NewAtom("x"), NewInt64(1)).Put(NewAtom("y"), NewInt64(2))
+	env, err := d.Unify(NewBindings(), other)
+	if err != nil {
+		t.Errorf("%s and %s don't unify", d, other)
+	} else if v, _ := env.Resolve(x); v.String() != "1" {
+		t.Errorf("X bound to %s", v)
+	}
+	_, err = d.Unify(NewBindings(), other.Delete(NewAtom("y")))
+	if err == nil {
+		t.Errorf("dicts with different keys unified")
+	}
+
+	// standard order puts dicts after compound terms
+	if !Precedes(NewCallable("f", x), d) || !Precedes(other.Delete(NewAtom("y")), other) {
+		t.Errorf("Dicts are ordered incorrectly")
+	}
+	if len(TermVariables(d)) != 1 || !IsVariant(d, CopyTerm(d)) {
+		t.Errorf("Dict variables aren't handled")
+	}
+}
+
+func TestStandardOrder(t *testing.T) {
+	one, half := NewInt64(1), NewFloat64(1.5)
+	if Compare(one, half) != -1 {
+		t.Errorf("By default, numbers are compared by value")
+	}
+	iso := StandardOrder{IsoNumbers: true}
+	if iso.Compare(one, half) != 1 || iso.Compare(NewFloat64(2), one) != -1 {
+		t.Errorf("ISO order should put floats before integers")
+	}
+	if iso.Compare(NewCallable("f", half), NewCallable("f", one)) != -1 {
+		t.Errorf("ISO order should apply inside compound terms")
+	}
+}
@@ -200,3 +200,53 @@ func TestUnifyVariableAliases2(t *testing.T) {
 		t.Errorf("X1 has the wrong value: %s", x1)
 	}
 }
+
+func TestOccursCheck(t *testing.T) {
+	x := NewVar("X").WithNewId()
+	y := NewVar("Y").WithNewId()
+	fx := NewCallable("f", x)
+
+	if _, err := x.Unify(NewBindings(), fx); err != nil {
+		t.Errorf("Without an occurs check, X = f(X) should unify")
+	}
+	env := WithOccursCheck(NewBindings(), OccursCheckTrue)
+	if _, err := x.Unify(env, fx); err != CantUnify {
+		t.Errorf("With an occurs check, X = f(X) shouldn't unify")
+	}
+	env, _ = y.Unify(env, fx)
+	if _, err := x.Unify(env, NewCallable("g", y)); err != CantUnify {
+		t.Errorf("Occurs check should follow bindings")
+	}
+	if _, err := x.Unify(env, y); err != CantUnify {
+		t.Errorf("X = Y should fail when Y is bound to f(X)")
+	}
+}
+
+func TestSetArg(t *testing.T) {
+	x := NewVar("X").WithNewId()
+	fa := NewCallable("f", NewAtom("a")).(*Compound)
+
+	env0 := NewBindings()
+	env1 := env0.SetArg(fa, 0, x)
+	env2, _ := x.Unify(env1, NewAtom("b"))
+
+	if s := fa.ReplaceVariables(env0).String(); s != "f(a)" {
+		t.Errorf("Original bindings see the change: %s", s)
+	}
+	if s := fa.ReplaceVariables(env2).String(); s != "f(b)" {
+		t.Errorf("Wrong replaced argument: %s", s)
+	}
+	if _, err := fa.Unify(env2, NewCallable("f", NewAtom("a"))); err != CantUnify {
+		t.Errorf("Unification should see the replaced argument")
+	}
+	if fa.String() != "f(a)" {
+		t.Errorf("SetArg changed the term itself: %s", fa)
+	}
+
+	// a copy made by ReplaceVariables is the same term
+	copied := fa.ReplaceVariables(env2).(*Compound)
+	env3 := env2.SetArg(copied, 0, NewAtom("c"))
+	if s := fa.ReplaceVariables(env3).String(); s != "f(c)" {
+		t.Errorf("SetArg of a copy didn't change the original: %s", s)
+	}
+}
@@ -94,6 +94,9 @@ func (a *Variable) Unify(e Bindings, b Term) (Bindings, error) {
 	aTerm = e.Resolve_(a)
 
 	// bind unbound variables
+	if IsVariable(aTerm) && IsVariable(bTerm) && aTerm.Indicator() == bTerm.Indicator() {
+		return e, nil // both sides were already bound to the same variable
+	}
 	if IsVariable(aTerm) {
 		return e.Bind(aTerm.(*Variable), b)
 	}
@@ -0,0 +1,443 @@
+package golog
+
+// write_term/2 and friends write a term as text.  Unless ignore_ops(true)
+// is given, compound terms whose functor is an operator are written in
+// operator notation, using the same operator table as the reader.  The
+// goal is that reading the output gives back the original term.  See
+// ISO §7.10.5
+
+import (
+	"bytes"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mndrix/golog/lex"
+	"github.com/mndrix/golog/read"
+	"github.com/mndrix/golog/term"
+)
+
+// writeOptions are the options accepted by write_term/2
+type writeOptions struct {
+	quoted     bool // quote atoms and strings where needed
+	ignoreOps  bool // write operators in functional notation
+	numbervars bool // write '$VAR'(N) terms as variable names
+	portray    bool // let portray/1 write terms first
+	spacing    bool // spacing(next_argument): a space after argument commas
+	maxDepth   int  // 0 means no limit
+}
+
+// termWriter writes a single term to a stream
+type termWriter struct {
+	writeOptions
+	m       *machine
+	out     *Stream
+	prefix  map[string]read.Operator
+	infix   map[string]read.Operator
+	postfix map[string]read.Operator
+
+	last     rune // last character written, 0 if unknown
+	prefixOp bool // true if the last thing written was a prefix operator
+}
+
+// writeTerm writes t to a stream according to the given options
+func (m *machine) writeTerm(out *Stream, t term.Term, opts writeOptions) {
+	w := &termWriter{
+		writeOptions: opts,
+		m:            m,
+		out:          out,
+		prefix:       make(map[string]read.Operator),
+		infix:        make(map[string]read.Operator),
+		postfix:      make(map[string]read.Operator),
+	}
+	for _, op := range m.operators() {
+		switch op.Specifier {
+		case "fx", "fy":
+			w.prefix[op.Name] = op
+		case "xfx", "xfy", "yfx":
+			w.infix[op.Name] = op
+		default:
+			w.postfix[op.Name] = op
+		}
+	}
+	w.term(t, 1200, 1)
+}
+
+// termText returns the text which writeTerm writes for t
+func (m *machine) termText(t term.Term, opts writeOptions) string {
+	var buf bytes.Buffer
+	m.writeTerm(newOutputStream(&buf, "write"), t, opts)
+	return buf.String()
+}
+
+// emit writes a token, first adding a space if the token would
+// otherwise run into the previous one
+func (w *termWriter) emit(token string) {
+	if token == "" {
+		return
+	}
+	c, _ := utf8.DecodeRuneInString(token)
+	switch {
+	case isAlphanumeric(w.last) && isAlphanumeric(c),
+		lex.IsGraphic(w.last) && lex.IsGraphic(c),
+		w.prefixOp && c == '(',
+		w.prefixOp && (w.last == '-' || w.last == '+') && unicode.IsDigit(c):
+		w.out.write([]byte{' '})
+	}
+	w.out.write([]byte(token))
+	w.last, _ = utf8.DecodeLastRuneInString(token)
+	w.prefixOp = false
+}
+
+// variableName returns a name for v which is different from the name
+// of every other variable.  Reading the name back gives a variable, so
+// distinct variables stay distinct when a term is written and read.
+func variableName(v *term.Variable) string {
+	return "_G" + strconv.FormatInt(v.Id(), 10)
+}
+
+// isAlphanumeric returns true for the characters which make up names
+// and numbers
+func isAlphanumeric(c rune) bool {
+	return c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+// atom writes an atom's name, quoted if necessary
+func (w *termWriter) atom(name string) {
+	if w.quoted && name != "{}" {
+		w.emit(term.QuoteFunctor(name))
+	} else {
+		w.emit(name)
+	}
+}
+
+// isOperator returns true if name is an operator of any kind
+func (w *termWriter) isOperator(name string) bool {
+	_, pre := w.prefix[name]
+	_, in := w.infix[name]
+	_, post := w.postfix[name]
+	return pre || in || post
+}
+
+// term writes t in a context which allows terms of priority up to
+// prec.  depth counts how deeply t is nested, starting from 1.
+func (w *termWriter) term(t term.Term, prec, depth int) {
+	if w.maxDepth > 0 && depth > w.maxDepth {
+		w.emit("...")
+		return
+	}
+	if w.portrayed(t) {
+		return
+	}
+
+	switch x := t.(type) {
+	case *term.Variable:
+		w.emit(variableName(x))
+	case *term.Atom:
+		if prec < 999 && w.isOperator(x.Name()) {
+			w.emit("(")
+			w.atom(x.Name())
+			w.emit(")")
+			return
+		}
+		w.atom(x.Name())
+	case *term.String:
+		if w.quoted {
+			w.emit(x.String())
+		} else {
+			w.emit(x.Text())
+		}
+	case *term.Integer, *term.Float, *term.Rational:
+		w.emit(t.String())
+	case *term.Dict:
+		w.dict(x, depth)
+	case *term.Compound:
+		w.compound(x, prec, depth)
+	default:
+		w.emit(t.String())
+	}
+}
+
+func (w *termWriter) compound(x *term.Compound, prec, depth int) {
+	name, args := x.Name(), x.Arguments()
+	switch {
+	case x.Indicator() == "./2":
+		w.list(x, depth)
+		return
+	case x.Indicator() == "{}/1" && !w.ignoreOps:
+		w.emit("{")
+		w.term(args[0], 1200, depth+1)
+		w.emit("}")
+		return
+	case x.Indicator() == "$VAR/1" && w.numbervars:
+		if name, ok := varName(args[0]); ok {
+			w.emit(name)
+			return
+		}
+	}
+
+	if !w.ignoreOps {
+		if op, ok := w.infix[name]; ok && len(args) == 2 {
+			left, right := op.Priority-1, op.Priority-1
+			switch op.Specifier {
+			case "xfy":
+				right = op.Priority
+			case "yfx":
+				left = op.Priority
+			}
+			open := w.openParen(op.Priority, prec)
+			w.term(args[0], left, depth+1)
+			switch {
+			case name == ",":
+				w.emit(",")
+			case isAlphanumeric([]rune(name)[0]):
+				w.emit(" ")
+				w.atom(name)
+				w.emit(" ")
+			default:
+				w.atom(name)
+			}
+			w.term(args[1], right, depth+1)
+			w.closeParen(open)
+			return
+		}
+		if op, ok := w.prefix[name]; ok && len(args) == 1 {
+			arg := op.Priority
+			if op.Specifier == "fx" {
+				arg--
+			}
+			open := w.openParen(op.Priority, prec)
+			w.atom(name)
+			w.prefixOp = true
+			if w.needsParens(args[0], arg) {
+				w.emit("(")
+				w.term(args[0], 1200, depth+1)
+				w.emit(")")
+			} else {
+				w.term(args[0], arg, depth+1)
+			}
+			w.closeParen(open)
+			return
+		}
+		if op, ok := w.postfix[name]; ok && len(args) == 1 {
+			arg := op.Priority
+			if op.Specifier == "xf" {
+				arg--
+			}
+			open := w.openParen(op.Priority, prec)
+			w.term(args[0], arg, depth+1)
+			w.atom(name)
+			w.closeParen(open)
+			return
+		}
+	}
+
+	w.atom(name)
+	w.out.write([]byte{'('})
+	w.last = '('
+	w.prefixOp = false
+	for i, arg := range args {
+		if i > 0 {
+			w.comma()
+		}
+		w.term(arg, 999, depth+1)
+	}
+	w.emit(")")
+}
+
+// needsParens returns true if a prefix operator's operand must be
+// written in parentheses
+func (w *termWriter) needsParens(t term.Term, prec int) bool {
+	if term.IsAtom(t) {
+		return w.isOperator(t.(*term.Atom).Name())
+	}
+	return w.priority(t) > prec
+}
+
+// priority returns the priority of t when written by this writer
+func (w *termWriter) priority(t term.Term) int {
+	x, ok := t.(*term.Compound)
+	if !ok || w.ignoreOps {
+		return 0
+	}
+	name, arity := x.Name(), x.Arity()
+	if w.numbervars && x.Indicator() == "$VAR/1" {
+		if _, ok := varName(x.Arguments()[0]); ok {
+			return 0
+		}
+	}
+	if op, ok := w.infix[name]; ok && arity == 2 {
+		return op.Priority
+	}
+	if op, ok := w.prefix[name]; ok && arity == 1 {
+		return op.Priority
+	}
+	if op, ok := w.postfix[name]; ok && arity == 1 {
+		return op.Priority
+	}
+	return 0
+}
+
+// openParen writes an opening parenthesis if an operator term of
+// priority p appears where only terms up to prec are allowed.  Returns
+// true if it did.
+func (w *termWriter) openParen(p, prec int) bool {
+	if p <= prec {
+		return false
+	}
+	w.emit("(")
+	return true
+}
+
+func (w *termWriter) closeParen(open bool) {
+	if open {
+		w.emit(")")
+	}
+}
+
+// comma separates arguments and list elements
+func (w *termWriter) comma() {
+	if w.spacing {
+		w.emit(", ")
+	} else {
+		w.emit(",")
+	}
+}
+
+func (w *termWriter) list(x *term.Compound, depth int) {
+	limit := w.maxDepth - depth
+	if limit < 1 {
+		limit = 1
+	}
+
+	w.emit("[")
+	var t term.Term = x
+	for i := 0; ; i++ {
+		c := t.(*term.Compound)
+		if w.maxDepth > 0 && i == limit {
+			w.emit("|")
+			w.emit("...")
+			break
+		}
+		if i > 0 {
+			w.comma()
+		}
+		w.term(c.Arguments()[0], 999, depth+1)
+		t = c.Arguments()[1]
+		if term.IsEmptyList(t) {
+			break
+		}
+		if !term.IsCompound(t) || t.(*term.Compound).Indicator() != "./2" {
+			w.emit("|")
+			w.term(t, 999, depth+1)
+			break
+		}
+	}
+	w.emit("]")
+}
+
+func (w *termWriter) dict(x *term.Dict, depth int) {
+	if term.IsVariable(x.Tag()) {
+		w.emit("_")
+	} else {
+		w.term(x.Tag(), 0, depth)
+	}
+	w.out.write([]byte{'{'})
+	w.last = '{'
+	for i, key := range x.Keys() {
+		if i > 0 {
+			w.comma()
+		}
+		value, _ := x.Get(key)
+		w.term(key, 0, depth+1)
+		w.emit(":")
+		w.term(value, 599, depth+1)
+	}
+	w.emit("}")
+}
+
+// portrayed gives the user's portray/1 predicate a chance to write t.
+// Returns true if portray/1 succeeded.
+func (w *termWriter) portrayed(t term.Term) bool {
+	if !w.portray || term.IsVariable(t) {
+		return false
+	}
+	goal := term.NewCallable("portray", t)
+	if !w.m.isDefined(goal) {
+		return false
+	}
+
+	sub := w.m
+	if _, ok := sub.streams.Lookup(strconv.FormatInt(w.out.id, 10)); !ok {
+		sub = sub.addStream(w.out, "")
+	}
+	sub = sub.clone()
+	sub.output = w.out
+	succeeded := false
+	forEachSolution(sub, goal, func(term.Bindings) bool {
+		succeeded = true
+		return false
+	})
+	if succeeded {
+		w.last = 0
+		w.prefixOp = false
+	}
+	return succeeded
+}
+
+// varName returns the variable name which numbervars(true) writes for
+// the argument of a '$VAR'/1 term.  0 is A, 25 is Z, 26 is A1 and so on.
+func varName(t term.Term) (string, bool) {
+	switch x := t.(type) {
+	case *term.Integer:
+		if x.Value().Sign() < 0 || !x.Value().IsInt64() {
+			return "", false
+		}
+		n := x.Value().Int64()
+		name := string(rune('A' + n%26))
+		if n >= 26 {
+			name += strconv.FormatInt(n/26, 10)
+		}
+		return name, true
+	case *term.Atom:
+		return x.Name(), true
+	}
+	return "", false
+}
+
+// mustWriteOptions converts a write_term/2 option list into writeOptions.
+// Raises an ISO error for invalid options.
+func mustWriteOptions(options term.Term) writeOptions {
+	var opts writeOptions
+	for _, option := range mustProperList(options) {
+		if term.IsVariable(option) {
+			panic(term.InstantiationError())
+		}
+		var value term.Term
+		if term.IsCompound(option) && option.(*term.Compound).Arity() == 1 {
+			value = option.(*term.Compound).Arguments()[0]
+			if term.IsVariable(value) {
+				panic(term.InstantiationError())
+			}
+		}
+		flag := value != nil && isAtomIn(value, "true")
+		switch {
+		case option.Indicator() == "quoted/1" && isAtomIn(value, "true", "false"):
+			opts.quoted = flag
+		case option.Indicator() == "ignore_ops/1" && isAtomIn(value, "true", "false"):
+			opts.ignoreOps = flag
+		case option.Indicator() == "numbervars/1" && isAtomIn(value, "true", "false"):
+			opts.numbervars = flag
+		case option.Indicator() == "portray/1" && isAtomIn(value, "true", "false"):
+			opts.portray = flag
+		case option.Indicator() == "spacing/1" && isAtomIn(value, "standard", "next_argument"):
+			opts.spacing = value.(*term.Atom).Name() == "next_argument"
+		case option.Indicator() == "max_depth/1" && term.IsInteger(value) &&
+			value.(*term.Integer).Value().IsInt64() && value.(*term.Integer).Value().Sign() >= 0:
+			opts.maxDepth = int(value.(*term.Integer).Value().Int64())
+		default:
+			panic(term.DomainError("write_option", option))
+		}
+	}
+	return opts
+}
@@ -0,0 +1,64 @@
+package golog
+
+import (
+	"testing"
+
+	. "github.com/mndrix/golog/term"
+)
+
+// writeq/1 output must read back as the same term
+func TestWriteqRoundTrip(t *testing.T) {
+	m := NewMachine().(*machine)
+	tests := []string{
+		`a :- b, c ; d -> e`,
+		`(a :- b) :- c`,
+		`f((a, b), (c :- d), [x, y|z])`,
+		`1 - -1`,
+		`- 1`,
+		`- a`,
+		`-(-(1))`,
+		`- (1 + 2)`,
+		`1 - (2 - 3)`,
+		`(1 - 2) - 3`,
+		`2 ** -1`,
+		`a = (:- b)`,
+		`X is Y mod 2`,
+		`'hello world' + 'A' + [] + '[]'`,
+		`f(',', '|', +, -)`,
+		`\+ a`,
+		`p :- \+ (a, b)`,
+		`f('.', 'don\'t')`,
+	}
+	for _, test := range tests {
+		original := m.readTerm(test + ".")
+		text := m.termText(original, writeOptions{quoted: true})
+		again := m.readTerm(text + " .")
+		if !IsVariant(original, again) {
+			t.Errorf("%s was written as %s which reads as %s", test, text, again)
+		}
+	}
+}
+
+func TestWriteOptions(t *testing.T) {
+	m := NewMachine().(*machine)
+	tests := []struct {
+		term string
+		opts writeOptions
+		text string
+	}{
+		{`1 + 2 * 3`, writeOptions{}, `1+2*3`},
+		{`1 + 2 * 3`, writeOptions{ignoreOps: true}, `+(1,*(2,3))`},
+		{`f('$VAR'(1))`, writeOptions{numbervars: true}, `f(B)`},
+		{`f('$VAR'(1))`, writeOptions{quoted: true}, `f('$VAR'(1))`},
+		{`f(a, b)`, writeOptions{spacing: true}, `f(a, b)`},
+		{`[1, 2, 3]`, writeOptions{maxDepth: 2}, `[1|...]`},
+		{`'A'`, writeOptions{}, `A`},
+		{`'A'`, writeOptions{quoted: true}, `'A'`},
+	}
+	for _, test := range tests {
+		text := m.termText(m.readTerm(test.term+"."), test.opts)
+		if text != test.text {
+			t.Errorf("Wrote %s as %s, expected %s", test.term, text, test.text)
+		}
+	}
+}